@@ -1,22 +1,150 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
+	"github.com/paul-freeman/satisfactory-story/logistics"
+	"github.com/paul-freeman/satisfactory-story/market"
+	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/state"
 	"github.com/paul-freeman/satisfactory-story/state/http"
+	"github.com/paul-freeman/satisfactory-story/terrain"
 )
 
 func main() {
+	profileEvery := flag.Duration("profile", 0,
+		"print the rolling tick profile at this interval (0 prints it only on shutdown)")
+	workers := flag.Int("workers", 1,
+		"goroutines to spread each tick over (results are identical for any value)")
+	matcher := flag.String("matcher", string(market.MatchAskPrice),
+		"market mechanism: askPrice, kDouble or callAuction")
+	k := flag.Float64("k", 0.5, "surplus split of the kDouble matcher, in [0, 1]")
+	fee := flag.Float64("fee", 0, "exchange fee: share of each trade's value paid to the treasury")
+	marketMaker := flag.String("market-maker", "",
+		"comma-separated recipe products a central dealer makes a market in (empty: none)")
+	modes := flag.String("modes", "",
+		"comma-separated transport modes (conveyor, truck, train, drone) for a logistics network (empty: flat freight)")
+	transit := flag.Bool("transit", false, "ship traded goods with distance-proportional delivery latency")
+	infrastructure := flag.Bool("infrastructure", false, "let the treasury build toll rail lines along busy trade corridors")
+	hubs := flag.Bool("hubs", false, "route long-distance trades through a graph of train stations and truck depots")
+	terrainFile := flag.String("terrain", "", "terrain cost raster (.csv of cell costs, or a greyscale image) for least-cost-path freight")
+	regionFile := flag.String("regions", "", "JSON region outlines to tag locations with (default: built-in approximate placeholder rectangles, not the in-game borders)")
+	landRent := flag.Float64("land-rent", 0, "per-tick rent per square metre of factory footprint per neighbouring building (0: none)")
+	bases := flag.String("bases", "", "semicolon-separated x,y base locations, each hosting the goal sinks (default: the world centre)")
+	bounds := flag.String("bounds", "", "world bounds as xmin,xmax,ymin,ymax (default: the resource nodes' padded extent)")
+	startRegions := flag.String("start-regions", "", "comma-separated map regions the playthrough starts in; nodes elsewhere start locked (empty: all open)")
+	unlockEvery := flag.Int("unlock-every", 0, "ticks between unlocking the locked region nearest a base (0: never)")
+	hubFile := flag.String("hub-file", "", "JSON hub graph for -hubs (default: generated from clusters of resource nodes)")
+	scenarioFile := flag.String("scenario", "",
+		"scenario file, or the name of a bundled one, to start from; flags given alongside override its config")
+	flag.Parse()
+
 	// Create state
 	logLevel := new(slog.Level)
 	l := makeLogger(logLevel)
 	seed := int64(152)
-	s, err := state.New(l, logLevel, seed)
+	config := state.DefaultConfig()
+	var sc *state.Scenario
+	if *scenarioFile != "" {
+		var err error
+		if sc, err = state.OpenScenario(*scenarioFile); err != nil {
+			panic(fmt.Sprintf("failed to load -scenario: %v", err))
+		}
+		config = sc.Config
+	}
+	// Without a scenario every flag applies, defaults included; with one,
+	// only the flags given on the command line.
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	use := func(name string) bool { return sc == nil || given[name] }
+
+	if use("workers") {
+		config.Workers = *workers
+	}
+	if use("matcher") {
+		config.Matcher = market.MatcherKind(*matcher)
+	}
+	if use("k") {
+		config.MatcherK = *k
+	}
+	if use("fee") {
+		config.FeePct = *fee
+	}
+	if use("transit") {
+		config.Transit = *transit
+	}
+	if use("infrastructure") {
+		config.Infrastructure = *infrastructure
+	}
+	if use("hubs") || use("hub-file") {
+		config.HubRouting = *hubs || *hubFile != ""
+	}
+	if use("hub-file") {
+		config.HubFile = *hubFile
+	}
+	if use("terrain") {
+		config.TerrainFile = *terrainFile
+	}
+	if use("regions") {
+		config.RegionFile = *regionFile
+	}
+	if use("land-rent") {
+		config.LandRent = *landRent
+	}
+	if *bases != "" {
+		config.Bases = nil
+		for _, base := range strings.Split(*bases, ";") {
+			p, err := parsePoint(base)
+			if err != nil {
+				panic(fmt.Sprintf("failed to parse -bases: %v", err))
+			}
+			config.Bases = append(config.Bases, p)
+		}
+	}
+	if *bounds != "" {
+		b, err := parseBounds(*bounds)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse -bounds: %v", err))
+		}
+		config.Bounds = &b
+	}
+	if *startRegions != "" {
+		config.StartRegions = strings.Split(*startRegions, ",")
+	}
+	if use("unlock-every") {
+		config.RegionUnlockTicks = *unlockEvery
+	}
+	if *marketMaker != "" {
+		config.MarketMakerProducts = strings.Split(*marketMaker, ",")
+	}
+	if *modes != "" {
+		config.TransportModes = nil
+		for _, name := range strings.Split(*modes, ",") {
+			mode, err := logistics.ParseMode(name)
+			if err != nil {
+				panic(fmt.Sprintf("failed to parse -modes: %v", err))
+			}
+			config.TransportModes = append(config.TransportModes, mode)
+		}
+	}
+	var s *state.State
+	var err error
+	if sc != nil {
+		sc.Config = config
+		s, err = state.NewFromScenario(l, logLevel, seed, sc)
+	} else {
+		s, err = state.NewWithConfig(l, logLevel, seed, config)
+	}
 	if err != nil {
 		panic(fmt.Sprintf("failed to create state: %v", err))
 	}
@@ -24,6 +152,15 @@ func main() {
 	// Start HTTP server
 	go http.Serve(s, ":28100", l, logLevel)
 
+	// Print the tick profile periodically, if asked
+	if *profileEvery > 0 {
+		go func() {
+			for range time.Tick(*profileEvery) {
+				printProfile(os.Stdout, s.Profile(l))
+			}
+		}()
+	}
+
 	// Listen for Ctrl+C
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
@@ -31,9 +168,68 @@ func main() {
 	<-c
 	fmt.Println("\nReceived Ctrl+C, shutting down.")
 	s.ListFactories(l)
+	printProfile(os.Stdout, s.Profile(l))
 	os.Exit(0)
 }
 
+// parsePoint reads a point written as x,y.
+func parsePoint(s string) (point.Point, error) {
+	v, err := parseInts(s, 2)
+	if err != nil {
+		return point.Point{}, err
+	}
+	return point.Point{X: v[0], Y: v[1]}, nil
+}
+
+// parseBounds reads bounds written as xmin,xmax,ymin,ymax.
+func parseBounds(s string) (terrain.Bounds, error) {
+	v, err := parseInts(s, 4)
+	if err != nil {
+		return terrain.Bounds{}, err
+	}
+	return terrain.Bounds{Xmin: v[0], Xmax: v[1], Ymin: v[2], Ymax: v[3]}, nil
+}
+
+// parseInts reads exactly n comma-separated integers.
+func parseInts(s string, n int) ([]int, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != n {
+		return nil, fmt.Errorf("%q: want %d comma-separated integers", s, n)
+	}
+	v := make([]int, n)
+	for i, field := range fields {
+		var err error
+		if v[i], err = strconv.Atoi(strings.TrimSpace(field)); err != nil {
+			return nil, fmt.Errorf("%q: %w", s, err)
+		}
+	}
+	return v, nil
+}
+
+// printProfile writes the rolling tick profile as a table.
+func printProfile(w io.Writer, p http.Profile) {
+	if p.Window == 0 {
+		fmt.Fprintln(w, "tick profile: no ticks yet")
+		return
+	}
+	fmt.Fprintf(w, "tick profile over the last %d ticks:\n", p.Window)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "phase\tp50\tp95\tmax\t")
+	for _, phase := range p.Phases {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", phase.Phase,
+			seconds(phase.Seconds.P50), seconds(phase.Seconds.P95), seconds(phase.Seconds.Max))
+	}
+	fmt.Fprintf(tw, "tick\t%s\t%s\t%s\t\n",
+		seconds(p.TickSeconds.P50), seconds(p.TickSeconds.P95), seconds(p.TickSeconds.Max))
+	fmt.Fprintf(tw, "allocs\t%.0f\t%.0f\t%.0f\t\n", p.Allocs.P50, p.Allocs.P95, p.Allocs.Max)
+	fmt.Fprintf(tw, "alloc bytes\t%.0f\t%.0f\t%.0f\t\n", p.AllocBytes.P50, p.AllocBytes.P95, p.AllocBytes.Max)
+	tw.Flush()
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
 func makeLogger(logLevel *slog.Level) *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 		Level:       logLevel,
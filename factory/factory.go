@@ -12,6 +12,9 @@ import (
 type Factory struct {
 	Name        string
 	RecipeClass string
+	// Building is what the recipe is produced in; its footprint scales
+	// what relocating costs.
+	Building    recipes.Producer
 	Loc         point.Point
 	CreatedTick int
 
@@ -25,12 +28,18 @@ type Factory struct {
 	// -- they are the only market state that persists between ticks.
 	AskPrices map[string]float64
 	BidPrices map[string]float64
+	// RestockExpiry holds, for each input, the tick its standing restock
+	// rung stands through (see RenewRestock).
+	RestockExpiry map[string]int
 
 	// InputStock and OutputStock hold real goods. Production consumes
 	// from InputStock into OutputStock; trades move units between a
 	// seller's OutputStock and a buyer's InputStock.
 	InputStock  production.Inventory
 	OutputStock production.Inventory
+	// Incoming holds inputs bought and still in transit: paid for, not
+	// yet in InputStock. Hunger counts them as good as held.
+	Incoming production.Inventory
 	// ProducedLastTick records whether the recipe ran at all last tick
 	// (observability, not a contractual state).
 	ProducedLastTick bool
@@ -41,8 +50,19 @@ type Factory struct {
 	AvgInputSpend  float64
 	AvgRevenue     float64
 	// RecentTrades is this factory's own memory of who it traded with,
-	// used for the movement gradient.
+	// used to choose where to relocate.
 	RecentTrades []TradeMemory
+	// Place, when set, resolves the site nearest a wanted one that the
+	// factory may stand on -- clear of other buildings and impassable
+	// ground -- or false if there is none; Move relocates only there.
+	// Nil means anywhere.
+	Place func(want point.Point) (point.Point, bool)
+	// Transport, when set, is the per-unit freight between two points
+	// that relocation weighs -- the world's, with its terrain, lines and
+	// hubs. Nil means the flat recipes.UnitTransportCost.
+	Transport func(origin, destination point.Point) float64
+	// LastRelocation is the move Move made this tick, nil if it stayed.
+	LastRelocation *Relocation
 
 	production.Wallet
 }
@@ -67,6 +87,7 @@ func New(
 		BidPrices:   make(map[string]float64),
 		InputStock:  make(production.Inventory),
 		OutputStock: make(production.Inventory),
+		Incoming:    make(production.Inventory),
 		Wallet:      production.NewWallet(seedCapital),
 	}
 }
@@ -86,62 +107,22 @@ func (f *Factory) String() string {
 	return fmt.Sprintf("%s [%s]+>[%s]", f.Name, f.Input.Key(), f.Output.Key())
 }
 
-func (f *Factory) Move() error {
-	if len(f.RecentTrades) == 0 {
-		// No trades means no transport-cost gradient to climb -- the
-		// tie-break below would otherwise always pick the same neighbor
-		// and the factory would march off the map forever while it
-		// waits for its first trade.
-		return nil
-	}
-
-	up := f.Loc.Up(1)
-	down := f.Loc.Down(1)
-	left := f.Loc.Left(1)
-	right := f.Loc.Right(1)
-
-	costsHere := f.transportCostsAt(f.Loc)
-	costsUp := f.transportCostsAt(up)
-	costsDown := f.transportCostsAt(down)
-	costsLeft := f.transportCostsAt(left)
-	costsRight := f.transportCostsAt(right)
-
-	if costsUp <= costsHere && costsUp <= costsDown && costsUp <= costsLeft && costsUp <= costsRight {
-		f.moveTo(f.Loc.Up(max(1, min(100, int(100000*(costsHere-costsUp)))))) // TODO: make this a function of the distance
-		return nil
-	}
-	if costsDown <= costsHere && costsDown <= costsUp && costsDown <= costsLeft && costsDown <= costsRight {
-		f.moveTo(f.Loc.Down(max(1, min(100, int(100000*(costsHere-costsDown)))))) // TODO: make this a function of the distance
-		return nil
-	}
-	if costsLeft <= costsHere && costsLeft <= costsUp && costsLeft <= costsDown && costsLeft <= costsRight {
-		f.moveTo(f.Loc.Left(max(1, min(100, int(100000*(costsHere-costsLeft)))))) // TODO: make this a function of the distance
-		return nil
-	}
-	if costsRight <= costsHere && costsRight <= costsUp && costsRight <= costsDown && costsRight <= costsLeft {
-		f.moveTo(f.Loc.Right(max(1, min(100, int(100000*(costsHere-costsRight)))))) // TODO: make this a function of the distance
-		return nil
-	}
-
-	return nil
-}
-
 var _ production.Producer = (*Factory)(nil)
 
 // transportCostsAt scores a location against the factory's remembered
 // trade partners, weighted by traded quantity.
 func (f *Factory) transportCostsAt(p point.Point) float64 {
+	transport := f.Transport
+	if transport == nil {
+		transport = recipes.UnitTransportCost
+	}
 	c := 0.0
 	for _, tr := range f.RecentTrades {
-		c += tr.Qty * recipes.UnitTransportCost(p, tr.Other)
+		c += tr.Qty * transport(p, tr.Other)
 	}
 	return c
 }
 
-func (f *Factory) moveTo(loc point.Point) {
-	f.Loc = loc
-}
-
 // AskPriceFor returns the standing per-unit sale price for the named
 // product, defaulting on first quote.
 func (f *Factory) AskPriceFor(name string) float64 {
@@ -187,7 +168,7 @@ func (f *Factory) SetBidPrice(name string, price float64) {
 }
 
 // TradeMemory is one remembered trade endpoint: where the counterparty
-// was and how much moved. Movement hill-climbs on these.
+// was and how much moved. Relocation weighs these.
 type TradeMemory struct {
 	Tick  int
 	Other point.Point
@@ -228,13 +209,14 @@ func (f *Factory) ProduceTick(outputCapTicks float64) float64 {
 }
 
 // Hunger is how many units of the named input the factory wants to buy
-// right now: the gap between its input-stock target and what it holds.
+// right now: the gap between its input-stock target and what it holds
+// or has on the way.
 func (f *Factory) Hunger(name string, targetTicks float64) float64 {
 	for _, in := range f.Input {
 		if in.Name != name {
 			continue
 		}
-		h := in.Rate*targetTicks - f.InputStock.Get(name)
+		h := in.Rate*targetTicks - f.InputStock.Get(name) - f.Incoming.Get(name)
 		if h < 0 {
 			return 0
 		}
@@ -243,7 +225,24 @@ func (f *Factory) Hunger(name string, targetTicks float64) float64 {
 	return 0
 }
 
-// RecordTrade remembers a trade endpoint for the movement gradient.
+// Expect records qty units of the named input as bought and in transit.
+func (f *Factory) Expect(name string, qty float64) {
+	if f.Incoming == nil {
+		f.Incoming = make(production.Inventory)
+	}
+	f.Incoming.Add(name, qty)
+}
+
+// Receive moves qty units of the named input from in transit into
+// InputStock.
+func (f *Factory) Receive(name string, qty float64) {
+	if f.Incoming != nil {
+		f.Incoming.Take(name, qty)
+	}
+	f.InputStock.Add(name, qty)
+}
+
+// RecordTrade remembers a trade endpoint for relocation.
 func (f *Factory) RecordTrade(tick int, other point.Point, qty float64) {
 	f.RecentTrades = append(f.RecentTrades, TradeMemory{Tick: tick, Other: other, Qty: qty})
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/recipes"
 )
 
 func Test_Factory_Cash(t *testing.T) {
@@ -62,7 +63,7 @@ func Test_Factory_Move_climbs_toward_lower_transport_cost(t *testing.T) {
 	start := point.Point{X: 500, Y: 500}
 	f := New("Test", "Recipe_Test_C", start, 0,
 		production.Products{{Name: "Ore", Rate: 5}},
-		production.Products{{Name: "Ingot", Rate: 5}}, 0)
+		production.Products{{Name: "Ingot", Rate: 5}}, 100)
 
 	// A real trade partner still gives Move() a gradient to climb -- this
 	// guards against the fix for the tradeless case accidentally
@@ -81,6 +82,24 @@ func Test_Factory_Move_climbs_toward_lower_transport_cost(t *testing.T) {
 	}
 }
 
+func Test_Factory_Move_weighsItsTransportFunction(t *testing.T) {
+	start := point.Point{X: 500, Y: 500}
+	f := New("Test", "Recipe_Test_C", start, 0,
+		production.Products{{Name: "Ore", Rate: 5}},
+		production.Products{{Name: "Ingot", Rate: 5}}, 100)
+	f.RecordTrade(1, point.Point{X: 600, Y: 500}, 5)
+
+	// A world where freight costs the same from anywhere (a line runs
+	// everywhere, say) saves nothing by moving.
+	f.Transport = func(origin, destination point.Point) float64 { return 1 }
+	if err := f.Move(); err != nil {
+		t.Fatalf("Move returned an error: %v", err)
+	}
+	if f.Loc != start {
+		t.Errorf("expected no move when the world's freight is flat, moved to %v", f.Loc)
+	}
+}
+
 func Test_Factory_ProduceTick(t *testing.T) {
 	f := New("Plates", "Recipe_Plates_C", point.Point{X: 0, Y: 0}, 0,
 		production.Products{production.Production{Name: "IronIngot", Rate: 2}},
@@ -131,6 +150,14 @@ func Test_Factory_Hunger(t *testing.T) {
 	if got := f.Hunger("IronIngot", 10); got != 5 {
 		t.Fatalf("partial Hunger = %v, want 5", got)
 	}
+	f.Expect("IronIngot", 3)
+	if got := f.Hunger("IronIngot", 10); got != 2 {
+		t.Fatalf("Hunger with 3 incoming = %v, want 2", got)
+	}
+	f.Receive("IronIngot", 3)
+	if got := f.Hunger("IronIngot", 10); got != 2 || f.Incoming.Get("IronIngot") != 0 {
+		t.Fatalf("Hunger after receiving = %v, want 2 with nothing incoming", got)
+	}
 	f.InputStock.Add("IronIngot", 100)
 	if got := f.Hunger("IronIngot", 10); got != 0 {
 		t.Fatalf("overshoot Hunger = %v, want 0", got)
@@ -179,12 +206,99 @@ func Test_Factory_Move_climbsTowardTradePartners(t *testing.T) {
 	if f.Loc.X != 0 || f.Loc.Y != 0 {
 		t.Fatalf("tradeless factory moved to %v, want (0,0)", f.Loc)
 	}
-	// One partner far to the east: moves toward it (X increases).
+	// One partner far to the east: moves toward it (X increases), but
+	// only once it can pay for the move.
 	f.RecordTrade(1, point.Point{X: 100000, Y: 0}, 5)
 	if err := f.Move(); err != nil {
 		t.Fatalf("Move error: %v", err)
 	}
+	if f.Loc.X != 0 || f.LastRelocation != nil {
+		t.Fatalf("factory with %v cash moved to %v, want it unable to afford the move", f.Cash(), f.Loc)
+	}
+	f.Wallet.Adjust(1000)
+	if err := f.Move(); err != nil {
+		t.Fatalf("Move error: %v", err)
+	}
 	if f.Loc.X <= 0 {
 		t.Fatalf("factory at %v, want X > 0 (moved toward partner)", f.Loc)
 	}
+	if reloc := f.LastRelocation; reloc == nil || f.Cash() != 1100-reloc.Cost || reloc.Cost != f.RelocationCost(reloc.Distance()) {
+		t.Fatalf("relocation %+v leaves %v cash, want 1100 less its cost", reloc, f.Cash())
+	}
+}
+
+func Test_Factory_Move_weberPoint(t *testing.T) {
+	f := New("Plates", "Recipe_Plates_C", point.Point{X: 0, Y: 0}, 0,
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		production.Products{production.Production{Name: "IronPlate", Rate: 2}},
+		100000)
+	f.Building = recipes.Constructor
+	// Three equal partners at the corners of a triangle: the Weber point
+	// is inside it, not at the centroid of the factory and partners.
+	f.RecordTrade(1, point.Point{X: 100000, Y: 0}, 5)
+	f.RecordTrade(1, point.Point{X: 100000, Y: 20000}, 5)
+	f.RecordTrade(1, point.Point{X: 120000, Y: 10000}, 5)
+	if err := f.Move(); err != nil {
+		t.Fatalf("Move error: %v", err)
+	}
+	if f.Loc.X < 100000 || f.Loc.X > 120000 || f.Loc.Y < 0 || f.Loc.Y > 20000 {
+		t.Fatalf("factory at %v, want it among its partners", f.Loc)
+	}
+	// Already at the optimum: a second Move stays put.
+	at := f.Loc
+	if err := f.Move(); err != nil {
+		t.Fatalf("Move error: %v", err)
+	}
+	if f.Loc != at || f.LastRelocation != nil {
+		t.Fatalf("factory moved on from its Weber point %v to %v", at, f.Loc)
+	}
+
+	// A Manufacturer's bigger footprint costs more to move.
+	big := *f
+	big.Building = recipes.Manufacturer
+	if big.RelocationCost(1000) <= f.RelocationCost(1000) {
+		t.Fatalf("Manufacturer relocation %v, want above a Constructor's %v", big.RelocationCost(1000), f.RelocationCost(1000))
+	}
+}
+
+func Test_Factory_BidLadder_splitsHunger(t *testing.T) {
+	f := New("Test", "Recipe_Test_C", point.Point{X: 0, Y: 0}, 0,
+		production.Products{{Name: "Ore", Rate: 1}},
+		production.Products{{Name: "Ingot", Rate: 1}}, 0)
+	f.SetBidPrice("Ore", 10)
+	f.InputStock.Add("Ore", 10) // target 60: urgent up to 20, hunger 50
+
+	ladder := f.BidLadder("Ore", 60)
+	want := []PriceLevel{{Rate: 10, UnitPrice: 10}, {Rate: 20, UnitPrice: 9}, {Rate: 20, UnitPrice: 8.1}}
+	if len(ladder) != len(want) {
+		t.Fatalf("ladder = %+v, want %+v", ladder, want)
+	}
+	for i := range want {
+		if ladder[i].Rate != want[i].Rate || ladder[i].UnitPrice < want[i].UnitPrice-1e-9 || ladder[i].UnitPrice > want[i].UnitPrice+1e-9 {
+			t.Fatalf("rung %d = %+v, want %+v", i, ladder[i], want[i])
+		}
+	}
+
+	f.InputStock.Add("Ore", 30) // above the urgent third: all restocking
+	if ladder := f.BidLadder("Ore", 60); ladder[0].Rate != 0 || ladder[1].Rate != 10 {
+		t.Fatalf("ladder = %+v, want no urgent rung and 10 per restocking rung", ladder)
+	}
+}
+
+func Test_Factory_RenewRestock(t *testing.T) {
+	f := New("Test", "Recipe_Test_C", point.Point{X: 0, Y: 0}, 0,
+		production.Products{{Name: "Ore", Rate: 1}},
+		production.Products{{Name: "Ingot", Rate: 1}}, 0)
+	expiry, renew := f.RenewRestock("Ore", 5)
+	if !renew || expiry != 5+ladderStandTicks-1 {
+		t.Fatalf("first RenewRestock = %d, %v; want a rung standing through %d", expiry, renew, 5+ladderStandTicks-1)
+	}
+	for tick := 6; tick <= expiry; tick++ {
+		if _, renew := f.RenewRestock("Ore", tick); renew {
+			t.Fatalf("tick %d: renewed a rung still standing", tick)
+		}
+	}
+	if _, renew := f.RenewRestock("Ore", expiry+1); !renew {
+		t.Fatal("the rung should renew once it lapses")
+	}
 }
@@ -0,0 +1,79 @@
+package factory
+
+// PriceLevel is one rung of a ladder bid: Rate units offered at
+// UnitPrice.
+type PriceLevel struct {
+	Rate      float64
+	UnitPrice float64
+}
+
+// ladderUrgentFraction is the share of the input-stock target below
+// which hunger is urgent and bid at the full standing price.
+const ladderUrgentFraction = 1.0 / 3.0
+
+// ladderRestockLevels is how many discounted rungs the rest of the
+// hunger (restocking up to the target) is spread over.
+const ladderRestockLevels = 2
+
+// ladderDiscountPct is how much cheaper each restocking rung bids than
+// the one above it.
+const ladderDiscountPct = 0.10
+
+// BidLadder splits the hunger for the named input across price levels,
+// best price first. The urgent rung bids the standing price for what
+// it takes to get stock, with what is in transit, back up to a third of
+// the target. The restocking beyond it is bid at successively
+// discounted prices -- the factory pays up for what it needs now and
+// only buys ahead cheaply. Levels may be zero-rate.
+func (f *Factory) BidLadder(name string, targetTicks float64) []PriceLevel {
+	hunger := f.Hunger(name, targetTicks)
+	price := f.BidPriceFor(name)
+	urgent := 0.0
+	for _, in := range f.Input {
+		if in.Name == name {
+			urgent = in.Rate*targetTicks*ladderUrgentFraction - f.InputStock.Get(name) - f.Incoming.Get(name)
+		}
+	}
+	urgent = min(hunger, max(0, urgent))
+
+	levels := make([]PriceLevel, 0, 1+ladderRestockLevels)
+	levels = append(levels, PriceLevel{Rate: urgent, UnitPrice: price})
+	restock := (hunger - urgent) / ladderRestockLevels
+	for i := 1; i <= ladderRestockLevels; i++ {
+		price *= 1 - ladderDiscountPct
+		levels = append(levels, PriceLevel{Rate: restock, UnitPrice: price})
+	}
+	return levels
+}
+
+// ladderStandTicks is how many ticks a ladder's deepest restocking rung
+// stands as a good-till-cancelled bid before the factory reprices it.
+const ladderStandTicks = 10
+
+// RenewRestock reports whether the named input's deepest restocking
+// rung is due to be posted afresh at tick and, if so, the tick it then
+// stands through. In between, the rung stands good-till-cancelled at
+// the price and quantity it was posted with, buying ahead without
+// chasing the market -- so it may buy a little past the stock target.
+func (f *Factory) RenewRestock(name string, tick int) (expiry int, renew bool) {
+	if f.RestockExpiry == nil {
+		f.RestockExpiry = make(map[string]int)
+	}
+	if expiry, ok := f.RestockExpiry[name]; ok && tick <= expiry {
+		return 0, false
+	}
+	expiry = tick + ladderStandTicks - 1
+	f.RestockExpiry[name] = expiry
+	return expiry, true
+}
+
+// BatchSize is the smallest shipment of the named output worth sending:
+// batchTicks of production. 0 for anything the factory does not make.
+func (f *Factory) BatchSize(name string, batchTicks float64) float64 {
+	for _, out := range f.Output {
+		if out.Name == name {
+			return out.Rate * batchTicks
+		}
+	}
+	return 0
+}
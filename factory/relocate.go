@@ -0,0 +1,144 @@
+package factory
+
+import (
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+// Relocation cost, per square metre of the building's footprint: a fixed
+// charge to dismantle and rebuild, plus haulage per unit of distance. A
+// Constructor moved 10000 units pays 40 + 80. The fixed charge keeps a
+// factory from chasing every small drift in its partners' flows.
+const (
+	relocationFixedPerArea = 0.5
+	relocationCostPerArea  = 1.0 / 10000
+)
+
+// relocationPaybackTicks is how long a move may take to pay for itself in
+// freight saved at the factory's recent trade rate. A shorter horizon
+// keeps factories where they are; a longer one lets them chase every
+// shift in their suppliers.
+const relocationPaybackTicks = 2000
+
+// weberIterations bounds the Weiszfeld descent; weberTolerance is the
+// step, in distance, below which it has converged.
+const (
+	weberIterations = 100
+	weberTolerance  = 0.5
+)
+
+// Relocation is one move of a factory to a new site and what it cost.
+type Relocation struct {
+	From, To point.Point
+	Cost     float64
+}
+
+// Distance is how far the factory moved.
+func (r Relocation) Distance() float64 {
+	return r.From.Distance(r.To)
+}
+
+// RelocationCost is what moving the factory distance units costs, in
+// proportion to the building's footprint.
+func (f *Factory) RelocationCost(distance float64) float64 {
+	return f.Building.Footprint() * (relocationFixedPerArea + distance*relocationCostPerArea)
+}
+
+// Move relocates the factory towards the site that minimises its
+// freight to the trade partners it remembers -- their Weber point,
+// weighted by the quantities traded -- or the nearest site to it that
+// Place allows. It moves when the freight saved over
+// relocationPaybackTicks would pay for the move and the factory can
+// afford it, and records the move in LastRelocation.
+func (f *Factory) Move() error {
+	f.LastRelocation = nil
+	if len(f.RecentTrades) == 0 {
+		// No trades means no partners to move towards.
+		return nil
+	}
+
+	// Not worth moving to the optimum means not worth moving anywhere.
+	site := f.weberPoint()
+	if !f.worthMoving(site) {
+		return nil
+	}
+	if f.Place != nil {
+		var ok bool
+		if site, ok = f.Place(site); !ok || !f.worthMoving(site) {
+			return nil
+		}
+	}
+
+	cost := f.RelocationCost(f.Loc.Distance(site))
+	f.Wallet.Adjust(-cost)
+	f.LastRelocation = &Relocation{From: f.Loc, To: site, Cost: cost}
+	f.Loc = site
+	return nil
+}
+
+// UndoRelocation takes back the move Move just made, refunding its cost:
+// the site turned out to be taken by the time the move was settled.
+func (f *Factory) UndoRelocation() {
+	if f.LastRelocation == nil {
+		return
+	}
+	f.Wallet.Adjust(f.LastRelocation.Cost)
+	f.Loc = f.LastRelocation.From
+	f.LastRelocation = nil
+}
+
+// worthMoving reports whether moving to site would repay its cost in
+// freight saved within relocationPaybackTicks, and the factory can
+// afford it.
+func (f *Factory) worthMoving(site point.Point) bool {
+	distance := f.Loc.Distance(site)
+	if distance < 1 {
+		return false
+	}
+	cost := f.RelocationCost(distance)
+	saved := (f.transportCostsAt(f.Loc) - f.transportCostsAt(site)) / float64(f.tradeSpan()) * relocationPaybackTicks
+	return saved > cost && cost <= f.Cash()
+}
+
+// weberPoint is the site minimising the quantity-weighted distance to
+// the remembered trade partners, found by Weiszfeld's descent from the
+// factory's current location.
+func (f *Factory) weberPoint() point.Point {
+	x, y := float64(f.Loc.X), float64(f.Loc.Y)
+	for iter := 0; iter < weberIterations; iter++ {
+		sumX, sumY, sumW := 0.0, 0.0, 0.0
+		for _, tr := range f.RecentTrades {
+			d := math.Hypot(x-float64(tr.Other.X), y-float64(tr.Other.Y))
+			if d < weberTolerance {
+				// Sitting on a partner: the descent is undefined here,
+				// and the partner is the optimum it is converging on.
+				sumX, sumY, sumW = float64(tr.Other.X), float64(tr.Other.Y), 1
+				break
+			}
+			sumX += tr.Qty * float64(tr.Other.X) / d
+			sumY += tr.Qty * float64(tr.Other.Y) / d
+			sumW += tr.Qty / d
+		}
+		if sumW == 0 {
+			break
+		}
+		nextX, nextY := sumX/sumW, sumY/sumW
+		step := math.Hypot(nextX-x, nextY-y)
+		x, y = nextX, nextY
+		if step < weberTolerance {
+			break
+		}
+	}
+
+	return point.Point{X: int(math.Round(x)), Y: int(math.Round(y))}
+}
+
+// tradeSpan is how many ticks the remembered trades cover.
+func (f *Factory) tradeSpan() int {
+	first, last := f.RecentTrades[0].Tick, f.RecentTrades[0].Tick
+	for _, tr := range f.RecentTrades {
+		first, last = min(first, tr.Tick), max(last, tr.Tick)
+	}
+	return last - first + 1
+}
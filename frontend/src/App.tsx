@@ -34,6 +34,10 @@ function App() {
           resources={state.resources}
           sinks={state.sinks}
           transports={state.transports}
+          shipments={state.shipments}
+          lines={state.lines}
+          hubs={state.hubs}
+          hubEdges={state.hubEdges}
           factories={state.factories}
         />
       </div>
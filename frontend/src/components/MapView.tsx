@@ -1,7 +1,7 @@
 import { useEffect, useRef } from 'react';
 import { select } from 'd3-selection';
 import { zoom, zoomIdentity, type D3ZoomEvent } from 'd3-zoom';
-import type { Bounds, Factory, Resource, Sink, Transport } from '../types';
+import type { Bounds, Factory, Hub, HubEdge, Line, Resource, Shipment, Sink, Transport } from '../types';
 import { toSvgY } from '../coords';
 import { cashColorScale } from '../colorScale';
 
@@ -17,10 +17,14 @@ interface MapViewProps {
   resources: Resource[];
   sinks: Sink[];
   transports: Transport[];
+  shipments: Shipment[];
+  lines: Line[];
+  hubs: Hub[];
+  hubEdges: HubEdge[];
   factories: Factory[];
 }
 
-export default function MapView({ bounds, resources, sinks, transports, factories }: MapViewProps) {
+export default function MapView({ bounds, resources, sinks, transports, shipments, lines, hubs, hubEdges, factories }: MapViewProps) {
   const svgRef = useRef<SVGSVGElement | null>(null);
   const zoomGroupRef = useRef<SVGGElement | null>(null);
 
@@ -105,6 +109,44 @@ export default function MapView({ bounds, resources, sinks, transports, factorie
               />
             ))}
         </g>
+        <g>
+          {lines.map((l, i) => (
+            <line
+              key={`line-${i}`}
+              x1={l.a.x}
+              y1={toSvgY(bounds, l.a.y)}
+              x2={l.b.x}
+              y2={toSvgY(bounds, l.b.y)}
+              stroke="saddlebrown"
+              strokeWidth={600}
+              strokeDasharray="1200 400"
+            />
+          ))}
+        </g>
+        <g>
+          {hubEdges.map((e, i) => (
+            <line
+              key={`hub-edge-${i}`}
+              x1={e.from.x}
+              y1={toSvgY(bounds, e.from.y)}
+              x2={e.to.x}
+              y2={toSvgY(bounds, e.to.y)}
+              stroke={e.rail ? 'dimgray' : 'tan'}
+              strokeWidth={300}
+              strokeOpacity={0.6}
+            />
+          ))}
+          {hubs.map((h, i) => (
+            <rect
+              key={`hub-${i}`}
+              x={h.location.x - 500}
+              y={toSvgY(bounds, h.location.y) - 500}
+              width={1000}
+              height={1000}
+              fill={h.kind === 'station' ? 'dimgray' : 'tan'}
+            />
+          ))}
+        </g>
         <g>
           {transports.map((t, i) => (
             <line
@@ -118,6 +160,17 @@ export default function MapView({ bounds, resources, sinks, transports, factorie
             />
           ))}
         </g>
+        <g>
+          {shipments.map((s, i) => (
+            <circle
+              key={`shipment-${i}`}
+              cx={s.origin.x + (s.destination.x - s.origin.x) * s.progress}
+              cy={toSvgY(bounds, s.origin.y + (s.destination.y - s.origin.y) * s.progress)}
+              r={120}
+              fill="yellow"
+            />
+          ))}
+        </g>
         <g>
           {sinks.map((s, i) => (
             <text
@@ -1,6 +1,7 @@
 export interface Location {
   x: number;
   y: number;
+  region: string;
 }
 
 export interface Bounds {
@@ -16,6 +17,10 @@ export interface Resource {
   product: string;
   profitability: number;
   active: boolean;
+  locked: boolean;
+  extractor: string;
+  owner: string;
+  cash: number;
 }
 
 export interface Factory {
@@ -31,12 +36,55 @@ export interface Sink {
   label: string;
 }
 
+export interface DealerQuote {
+  product: string;
+  bid: number;
+  ask: number;
+  inventory: number;
+}
+
+export interface MarketMaker {
+  location: Location;
+  label: string;
+  cash: number;
+  quotes: DealerQuote[];
+}
+
 export interface Transport {
   origin: Location;
   destination: Location;
   rate: number;
 }
 
+export interface Line {
+  a: Location;
+  b: Location;
+  builtTick: number;
+  buildCost: number;
+  carried: number;
+  tollRevenue: number;
+}
+
+export interface Hub {
+  name: string;
+  kind: string;
+  location: Location;
+}
+
+export interface HubEdge {
+  from: Location;
+  to: Location;
+  rail: boolean;
+}
+
+export interface Shipment {
+  origin: Location;
+  destination: Location;
+  product: string;
+  qty: number;
+  progress: number;
+}
+
 export interface Shortage {
   product: string;
   amount: number;
@@ -47,7 +95,12 @@ export interface State {
   resources: Resource[];
   factories: Factory[];
   sinks: Sink[];
+  marketMakers: MarketMaker[];
   transports: Transport[];
+  shipments: Shipment[];
+  lines: Line[];
+  hubs: Hub[];
+  hubEdges: HubEdge[];
   shortages: Shortage[];
   tick: number;
   running: boolean;
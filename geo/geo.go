@@ -0,0 +1,78 @@
+// Package geo converts between the three coordinate systems locations
+// arrive in -- the lat/lng of the SCIM interactive map that the node
+// lists come from, the game's own Unreal world coordinates, and the
+// simulation's point grid -- and tags locations with the map region
+// they fall in.
+package geo
+
+import "github.com/paul-freeman/satisfactory-story/point"
+
+// The world's edges in Unreal units (centimetres), as the SCIM map
+// draws them. Unreal Y grows southwards.
+const (
+	westBoundary  = -324698.832031
+	eastBoundary  = 425301.832031
+	northBoundary = -375000.0
+	southBoundary = 375000.0
+)
+
+// SCIM lays the world out over mapUnits of lat/lng, inset by mapMargin
+// on every side, with lat running negative from the north edge: its
+// 32768px background plus a 4096px margin, at the map's top zoom of 2^8.
+const (
+	mapUnits  = 32768.0 / 256
+	mapMargin = 4096.0 / 256
+)
+
+// pointScale is how many point-grid units make one unit of lat/lng.
+const pointScale = 1000
+
+// MetresPerPoint is the ground distance one point-grid unit spans.
+const MetresPerPoint = (southBoundary - northBoundary) / 100 / mapUnits / pointScale
+
+// LatLng is a position on the SCIM map.
+type LatLng struct {
+	Lat, Lng float64
+}
+
+// World is a position in the game's Unreal world coordinates.
+type World struct {
+	X, Y float64
+}
+
+// LatLngToWorld converts a SCIM map position to world coordinates.
+func LatLngToWorld(ll LatLng) World {
+	return World{
+		X: westBoundary + (ll.Lng-mapMargin)/mapUnits*(eastBoundary-westBoundary),
+		Y: northBoundary + (-ll.Lat-mapMargin)/mapUnits*(southBoundary-northBoundary),
+	}
+}
+
+// WorldToLatLng converts world coordinates to a SCIM map position.
+func WorldToLatLng(w World) LatLng {
+	return LatLng{
+		Lat: -(mapMargin + (w.Y-northBoundary)/(southBoundary-northBoundary)*mapUnits),
+		Lng: mapMargin + (w.X-westBoundary)/(eastBoundary-westBoundary)*mapUnits,
+	}
+}
+
+// LatLngToPoint converts a SCIM map position to the point grid: east is
+// +X and north +Y, truncated to whole units.
+func LatLngToPoint(ll LatLng) point.Point {
+	return point.Point{X: int(ll.Lng * pointScale), Y: int(ll.Lat * pointScale)}
+}
+
+// PointToLatLng converts a point back to a SCIM map position.
+func PointToLatLng(p point.Point) LatLng {
+	return LatLng{Lat: float64(p.Y) / pointScale, Lng: float64(p.X) / pointScale}
+}
+
+// WorldToPoint converts world coordinates to the point grid.
+func WorldToPoint(w World) point.Point {
+	return LatLngToPoint(WorldToLatLng(w))
+}
+
+// PointToWorld converts a point to world coordinates.
+func PointToWorld(p point.Point) World {
+	return LatLngToWorld(PointToLatLng(p))
+}
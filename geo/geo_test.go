@@ -0,0 +1,40 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+func Test_LatLngToWorld_roundTrip(t *testing.T) {
+	// The map's north-west corner sits mapMargin in from SCIM's origin.
+	if w := LatLngToWorld(LatLng{Lat: -mapMargin, Lng: mapMargin}); w != (World{X: westBoundary, Y: northBoundary}) {
+		t.Fatalf("north-west corner = %+v, want (%v, %v)", w, westBoundary, northBoundary)
+	}
+	se := LatLngToWorld(LatLng{Lat: -(mapMargin + mapUnits), Lng: mapMargin + mapUnits})
+	if math.Abs(se.X-eastBoundary) > 1e-6 || math.Abs(se.Y-southBoundary) > 1e-6 {
+		t.Fatalf("south-east corner = %+v, want (%v, %v)", se, eastBoundary, southBoundary)
+	}
+
+	ll := LatLng{Lat: -118.919, Lng: 60.742}
+	back := WorldToLatLng(LatLngToWorld(ll))
+	if math.Abs(back.Lat-ll.Lat) > 1e-9 || math.Abs(back.Lng-ll.Lng) > 1e-9 {
+		t.Fatalf("round trip = %+v, want %+v", back, ll)
+	}
+}
+
+func Test_LatLngToPoint(t *testing.T) {
+	ll := LatLng{Lat: -118.91926933333338, Lng: 60.74228505108894}
+	p := LatLngToPoint(ll)
+	if p != (point.Point{X: 60742, Y: -118919}) {
+		t.Fatalf("LatLngToPoint = %v, want (60742, -118919)", p)
+	}
+	w, viaPoint := LatLngToWorld(ll), PointToWorld(p)
+	if math.Hypot(w.X-viaPoint.X, w.Y-viaPoint.Y) > 10 {
+		t.Fatalf("PointToWorld = %+v, want within truncation of %+v", viaPoint, w)
+	}
+	if got := WorldToPoint(viaPoint); got.Distance(p) > 1.5 {
+		t.Fatalf("WorldToPoint = %v, want %v back to within a unit", got, p)
+	}
+}
@@ -0,0 +1,104 @@
+package geo
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+// regionsJson outlines the map's regions with approximate placeholder
+// rectangles, one per biome over roughly its extent -- enough to
+// aggregate the economy by region, not a survey of the in-game borders;
+// every one is marked Approximate. LoadRegions reads real outlines.
+//
+//go:embed regions.json
+var regionsJson []byte
+
+// Region is a named area of the map, outlined by a polygon of world
+// coordinates ([x, y] vertices, in order).
+type Region struct {
+	Name    string       `json:"name"`
+	Polygon [][2]float64 `json:"polygon"`
+	// Approximate marks a placeholder outline that only roughly follows
+	// the in-game border.
+	Approximate bool `json:"approximate,omitempty"`
+}
+
+// contains reports whether w lies inside the region (even-odd rule).
+func (r Region) contains(w World) bool {
+	inside := false
+	for i, j := 0, len(r.Polygon)-1; i < len(r.Polygon); j, i = i, i+1 {
+		a, b := r.Polygon[i], r.Polygon[j]
+		if (a[1] > w.Y) != (b[1] > w.Y) &&
+			w.X < (b[0]-a[0])*(w.Y-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Regions tags locations with the region they fall in.
+type Regions struct {
+	regions []Region
+}
+
+// DefaultRegions returns the built-in placeholder region outlines (see
+// regionsJson).
+func DefaultRegions() (*Regions, error) {
+	var regions []Region
+	if err := json.Unmarshal(regionsJson, &regions); err != nil {
+		return nil, fmt.Errorf("failed to decode regions: %w", err)
+	}
+	return NewRegions(regions)
+}
+
+// LoadRegions reads region outlines from JSON of the form
+//
+//	[{"name": "Grass Fields", "polygon": [[x, y], [x, y], [x, y], ...]}, ...]
+//
+// in world coordinates.
+func LoadRegions(r io.Reader) (*Regions, error) {
+	var regions []Region
+	if err := json.NewDecoder(r).Decode(&regions); err != nil {
+		return nil, fmt.Errorf("failed to decode regions: %w", err)
+	}
+	return NewRegions(regions)
+}
+
+// NewRegions returns a tagger over regions; where they overlap, the one
+// listed first wins.
+func NewRegions(regions []Region) (*Regions, error) {
+	for _, r := range regions {
+		if r.Name == "" {
+			return nil, fmt.Errorf("region with no name")
+		}
+		if len(r.Polygon) < 3 {
+			return nil, fmt.Errorf("region %q has %d vertices, want at least 3", r.Name, len(r.Polygon))
+		}
+	}
+	return &Regions{regions: regions}, nil
+}
+
+// Names lists the regions in order.
+func (rs *Regions) Names() []string {
+	names := make([]string, len(rs.regions))
+	for i, r := range rs.regions {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// Tag returns the name of the region p lies in, or "" when it lies in
+// none.
+func (rs *Regions) Tag(p point.Point) string {
+	w := PointToWorld(p)
+	for _, r := range rs.regions {
+		if r.contains(w) {
+			return r.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Regions_Tag(t *testing.T) {
+	rs, err := LoadRegions(strings.NewReader(`[
+		{"name": "West", "polygon": [[-400000, -400000], [0, -400000], [0, 400000], [-400000, 400000]]},
+		{"name": "Overlap", "polygon": [[-100000, -100000], [100000, -100000], [100000, 100000], [-100000, 100000]]}
+	]`))
+	if err != nil {
+		t.Fatalf("LoadRegions: %v", err)
+	}
+	for _, c := range []struct {
+		w    World
+		want string
+	}{
+		{World{X: -200000, Y: 0}, "West"},
+		{World{X: -50000, Y: 0}, "West"}, // listed first wins
+		{World{X: 50000, Y: 0}, "Overlap"},
+		{World{X: 300000, Y: 0}, ""},
+	} {
+		if got := rs.Tag(WorldToPoint(c.w)); got != c.want {
+			t.Errorf("Tag(%+v) = %q, want %q", c.w, got, c.want)
+		}
+	}
+
+	for _, bad := range []string{
+		`[{"name": "", "polygon": [[0, 0], [1, 0], [0, 1]]}]`,
+		`[{"name": "Line", "polygon": [[0, 0], [1, 0]]}]`,
+		`{`,
+	} {
+		if _, err := LoadRegions(strings.NewReader(bad)); err == nil {
+			t.Errorf("LoadRegions(%s) succeeded, want an error", bad)
+		}
+	}
+}
+
+func Test_DefaultRegions_coverTheMap(t *testing.T) {
+	rs, err := DefaultRegions()
+	if err != nil {
+		t.Fatalf("DefaultRegions: %v", err)
+	}
+	for x := westBoundary + 1000; x < eastBoundary; x += 25000 {
+		for y := northBoundary + 1000; y < southBoundary; y += 25000 {
+			if tag := rs.Tag(WorldToPoint(World{X: x, Y: y})); tag == "" {
+				t.Fatalf("(%v, %v) is in no region", x, y)
+			}
+		}
+	}
+	for _, r := range rs.regions {
+		if !r.Approximate {
+			t.Errorf("built-in region %q is not marked approximate", r.Name)
+		}
+	}
+}
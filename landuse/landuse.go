@@ -0,0 +1,196 @@
+// Package landuse decides where buildings may stand. Every building
+// occupies a square plot sized by its footprint, plots may not overlap,
+// and resource nodes keep a tile reserved for the extractor that claims
+// them. Placement finds the free site nearest where a building wants to
+// be.
+package landuse
+
+import (
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/geo"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// Footprints, in square metres, of what stands on the map besides the
+// factories (whose footprints follow their buildings).
+const (
+	// NodeTileArea is the tile reserved around a resource node for its
+	// extractor.
+	NodeTileArea = 10 * 10
+	// SinkArea is a goal sink: the base's Space Elevator.
+	SinkArea = 54 * 54
+	// DepotArea is a market maker's storage yard.
+	DepotArea = 20 * 20
+)
+
+// siteSearchRings bounds how many rings of plots around the wanted site
+// SiteNear tries before giving up.
+const siteSearchRings = 16
+
+// Plot is the square of land a building stands on, centred on its
+// location.
+type Plot struct {
+	Center point.Point
+	// Half is half the plot's side, in point-grid units.
+	Half int
+}
+
+// PlotFor returns the plot a building of footprint area (square metres)
+// occupies when it stands at center.
+func PlotFor(center point.Point, area float64) Plot {
+	side := math.Sqrt(area) / geo.MetresPerPoint
+	return Plot{Center: center, Half: max(1, int(math.Ceil(side/2)))}
+}
+
+// Overlaps reports whether two plots share any land; plots that only
+// touch along an edge do not.
+func (p Plot) Overlaps(q Plot) bool {
+	return abs(p.Center.X-q.Center.X) < p.Half+q.Half &&
+		abs(p.Center.Y-q.Center.Y) < p.Half+q.Half
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// cellSize is the side, in point-grid units, of the square cells Map
+// buckets plots into by centre (about 120 m): a query visits only the
+// cells its reach covers.
+const cellSize = 2048
+
+// Map is the land taken, plot by plot, and by whom.
+type Map struct {
+	cells map[cell][]holding
+	// maxHalf is the largest Half of any plot added, bounding how far
+	// from a plot's centre the plots it overlaps can be centred.
+	maxHalf int
+}
+
+type cell struct{ x, y int }
+
+type holding struct {
+	plot  Plot
+	owner production.Producer
+}
+
+// NewMap returns a map with no land taken.
+func NewMap() *Map {
+	return &Map{cells: make(map[cell][]holding)}
+}
+
+func cellOf(p point.Point) cell {
+	return cell{floorDiv(p.X, cellSize), floorDiv(p.Y, cellSize)}
+}
+
+func floorDiv(n, d int) int {
+	q := n / d
+	if n%d != 0 && n < 0 {
+		q--
+	}
+	return q
+}
+
+// Add records owner standing on plot.
+func (m *Map) Add(owner production.Producer, plot Plot) {
+	c := cellOf(plot.Center)
+	m.cells[c] = append(m.cells[c], holding{plot, owner})
+	m.maxHalf = max(m.maxHalf, plot.Half)
+}
+
+// Remove gives up every plot owner holds.
+func (m *Map) Remove(owner production.Producer) {
+	for c, held := range m.cells {
+		kept := held[:0]
+		for _, h := range held {
+			if h.owner != owner {
+				kept = append(kept, h)
+			}
+		}
+		clear(held[len(kept):])
+		if len(kept) == 0 {
+			delete(m.cells, c)
+		} else {
+			m.cells[c] = kept
+		}
+	}
+}
+
+// within calls visit for every plot centred within reach of center on
+// both axes, until visit returns false.
+func (m *Map) within(center point.Point, reach int, visit func(holding) bool) {
+	lo := cellOf(point.Point{X: center.X - reach, Y: center.Y - reach})
+	hi := cellOf(point.Point{X: center.X + reach, Y: center.Y + reach})
+	for y := lo.y; y <= hi.y; y++ {
+		for x := lo.x; x <= hi.x; x++ {
+			for _, h := range m.cells[cell{x, y}] {
+				if !visit(h) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Free reports whether plot overlaps no land held by anyone but ignore
+// (nil to check against everyone).
+func (m *Map) Free(plot Plot, ignore production.Producer) bool {
+	free := true
+	m.within(plot.Center, plot.Half+m.maxHalf, func(h holding) bool {
+		if h.owner != ignore && h.plot.Overlaps(plot) {
+			free = false
+		}
+		return free
+	})
+	return free
+}
+
+// Neighbours counts the plots, other than ignore's, whose centres lie
+// within radius of plot's.
+func (m *Map) Neighbours(plot Plot, radius float64, ignore production.Producer) int {
+	n := 0
+	m.within(plot.Center, int(math.Ceil(radius)), func(h holding) bool {
+		if h.owner != ignore && h.plot.Center.Distance(plot.Center) <= radius {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// SiteNear returns the free site nearest want for a building of
+// footprint area that ignore (the building itself, when it is moving)
+// may hold, and that allowed accepts (nil accepts anywhere). It searches
+// outwards ring by ring in steps of the plot's side, and fails when
+// siteSearchRings rings hold no site.
+func (m *Map) SiteNear(want point.Point, area float64, ignore production.Producer, allowed func(point.Point) bool) (point.Point, bool) {
+	step := 2 * PlotFor(want, area).Half
+	ok := func(p point.Point) bool {
+		return (allowed == nil || allowed(p)) && m.Free(PlotFor(p, area), ignore)
+	}
+	if ok(want) {
+		return want, true
+	}
+	for ring := 1; ring <= siteSearchRings; ring++ {
+		best, bestDistance := want, math.Inf(1)
+		for dy := -ring; dy <= ring; dy++ {
+			for dx := -ring; dx <= ring; dx++ {
+				if abs(dx) != ring && abs(dy) != ring {
+					continue // inside the ring, already tried
+				}
+				p := point.Point{X: want.X + dx*step, Y: want.Y + dy*step}
+				if d := p.Distance(want); d < bestDistance && ok(p) {
+					best, bestDistance = p, d
+				}
+			}
+		}
+		if !math.IsInf(bestDistance, 1) {
+			return best, true
+		}
+	}
+	return want, false
+}
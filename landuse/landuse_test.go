@@ -0,0 +1,123 @@
+package landuse
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
+)
+
+func node(x, y int) *resources.Resource {
+	return &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: x, Y: y},
+	}
+}
+
+func Test_Plot_Overlaps(t *testing.T) {
+	a := Plot{Center: point.Point{X: 0, Y: 0}, Half: 10}
+	for _, c := range []struct {
+		b    Plot
+		want bool
+	}{
+		{Plot{Center: point.Point{X: 15, Y: 5}, Half: 10}, true},
+		{Plot{Center: point.Point{X: 20, Y: 0}, Half: 10}, false}, // edge to edge
+		{Plot{Center: point.Point{X: 0, Y: 25}, Half: 10}, false},
+		{Plot{Center: point.Point{X: 3, Y: 3}, Half: 1}, true}, // inside
+	} {
+		if got := a.Overlaps(c.b); got != c.want {
+			t.Errorf("Overlaps(%+v) = %v, want %v", c.b, got, c.want)
+		}
+	}
+	if p := PlotFor(point.Point{}, 80); p.Half < 70 || p.Half > 80 {
+		t.Errorf("PlotFor(80 m²).Half = %d, want ~77", p.Half)
+	}
+}
+
+func Test_Map_FreeAndSiteNear(t *testing.T) {
+	m := NewMap()
+	held := node(0, 0)
+	m.Add(held, PlotFor(held.Loc, NodeTileArea))
+
+	want := point.Point{X: 10, Y: 0}
+	if m.Free(PlotFor(want, 80), nil) {
+		t.Fatal("a plot on the node's tile should not be free")
+	}
+	if !m.Free(PlotFor(held.Loc, NodeTileArea), held) {
+		t.Fatal("land should be free to the building holding it")
+	}
+
+	site, ok := m.SiteNear(want, 80, nil, nil)
+	if !ok || !m.Free(PlotFor(site, 80), nil) {
+		t.Fatalf("SiteNear = %v, %v, want a free site", site, ok)
+	}
+	if d, limit := site.Distance(want), 2*float64(PlotFor(want, 80).Half)*1.5; d > limit {
+		t.Fatalf("SiteNear = %v, %v from %v, want within the first ring", site, d, want)
+	}
+
+	east := func(p point.Point) bool { return p.X > 0 }
+	if site, ok := m.SiteNear(want, 80, nil, east); !ok || site.X <= 0 {
+		t.Fatalf("SiteNear(east only) = %v, %v, want a site east of the node", site, ok)
+	}
+	if _, ok := m.SiteNear(want, 80, nil, func(point.Point) bool { return false }); ok {
+		t.Fatal("SiteNear should fail when nowhere is allowed")
+	}
+
+	m.Remove(held)
+	if !m.Free(PlotFor(want, 80), nil) {
+		t.Fatal("land should be free once its holder is removed")
+	}
+}
+
+func Test_Map_Neighbours(t *testing.T) {
+	m := NewMap()
+	a, b, c := node(0, 0), node(500, 0), node(5000, 0)
+	for _, r := range []*resources.Resource{a, b, c} {
+		m.Add(r, PlotFor(r.Loc, NodeTileArea))
+	}
+	if got := m.Neighbours(PlotFor(a.Loc, NodeTileArea), 1000, a); got != 1 {
+		t.Fatalf("Neighbours = %d, want 1", got)
+	}
+}
+
+func Test_Map_gridMatchesScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	m := NewMap()
+	var plots []Plot
+	var owners []*resources.Resource
+	for i := 0; i < 400; i++ {
+		owner := node(r.Intn(40000)-20000, r.Intn(40000)-20000)
+		plot := Plot{Center: owner.Loc, Half: 1 + r.Intn(600)}
+		m.Add(owner, plot)
+		plots, owners = append(plots, plot), append(owners, owner)
+	}
+	for _, gone := range owners[:50] {
+		m.Remove(gone)
+	}
+	plots, owners = plots[50:], owners[50:]
+
+	for i := 0; i < 400; i++ {
+		q := Plot{Center: point.Point{X: r.Intn(44000) - 22000, Y: r.Intn(44000) - 22000}, Half: 1 + r.Intn(600)}
+		ignore := owners[r.Intn(len(owners))]
+		free, near := true, 0
+		for j, p := range plots {
+			if owners[j] == ignore {
+				continue
+			}
+			if p.Overlaps(q) {
+				free = false
+			}
+			if p.Center.Distance(q.Center) <= 3000 {
+				near++
+			}
+		}
+		if got := m.Free(q, ignore); got != free {
+			t.Fatalf("Free(%+v) = %v, want %v", q, got, free)
+		}
+		if got := m.Neighbours(q, 3000, ignore); got != near {
+			t.Fatalf("Neighbours(%+v) = %d, want %d", q, got, near)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package logistics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+// clusterIterations is how many Lloyd refinement passes ClusterHubs runs;
+// resource nodes settle into their fields well within it.
+const clusterIterations = 20
+
+// hubNeighbours is how many nearest hubs each generated hub is joined to,
+// on top of the spanning tree that keeps the graph connected.
+const hubNeighbours = 2
+
+// ClusterHubs generates a hub graph from the locations of resource
+// nodes: a station at the centre of each of k clusters of nodes (k-means,
+// seeded with the farthest-point spread so the result is deterministic),
+// joined by a minimum spanning tree plus each station's nearest
+// neighbours. A world with fewer than k nodes gets a station per node.
+func ClusterHubs(nodes []point.Point, k int) (*HubGraph, error) {
+	if k < 2 {
+		return nil, fmt.Errorf("need at least 2 hubs, got %d", k)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes to cluster into hubs")
+	}
+	k = min(k, len(nodes))
+	centres := farthestPoints(nodes, k)
+	for iter := 0; iter < clusterIterations; iter++ {
+		sumX, sumY, count := make([]float64, k), make([]float64, k), make([]int, k)
+		for _, p := range nodes {
+			c := closest(centres, p)
+			sumX[c] += float64(p.X)
+			sumY[c] += float64(p.Y)
+			count[c]++
+		}
+		for c := range centres {
+			if count[c] > 0 {
+				centres[c] = point.Point{
+					X: int(math.Round(sumX[c] / float64(count[c]))),
+					Y: int(math.Round(sumY[c] / float64(count[c]))),
+				}
+			}
+		}
+	}
+
+	hubs := make([]Hub, k)
+	for i, c := range centres {
+		hubs[i] = Hub{Name: fmt.Sprintf("Station %d", i+1), Kind: Station, X: c.X, Y: c.Y}
+	}
+	return NewHubGraph(hubs, proximityEdges(hubs))
+}
+
+// farthestPoints picks k spread-out seeds: the first node, then each time
+// the node farthest from every seed so far.
+func farthestPoints(nodes []point.Point, k int) []point.Point {
+	seeds := []point.Point{nodes[0]}
+	gap := make([]float64, len(nodes))
+	for i, p := range nodes {
+		gap[i] = p.Distance(nodes[0])
+	}
+	for len(seeds) < k {
+		far := 0
+		for i := range nodes {
+			if gap[i] > gap[far] {
+				far = i
+			}
+		}
+		seeds = append(seeds, nodes[far])
+		for i, p := range nodes {
+			gap[i] = math.Min(gap[i], p.Distance(nodes[far]))
+		}
+	}
+	return seeds
+}
+
+// closest returns the index of the centre nearest p.
+func closest(centres []point.Point, p point.Point) int {
+	best := 0
+	for i := range centres {
+		if p.Distance(centres[i]) < p.Distance(centres[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// proximityEdges joins hubs by a minimum spanning tree (Prim's), so every
+// hub is reachable, plus each hub's hubNeighbours nearest.
+func proximityEdges(hubs []Hub) []HubEdge {
+	type pair struct{ a, b int }
+	seen := make(map[pair]bool)
+	edges := make([]HubEdge, 0)
+	join := func(a, b int) {
+		if a > b {
+			a, b = b, a
+		}
+		if a == b || seen[pair{a, b}] {
+			return
+		}
+		seen[pair{a, b}] = true
+		edges = append(edges, HubEdge{From: hubs[a].Name, To: hubs[b].Name})
+	}
+	dist := func(a, b int) float64 { return hubs[a].Location().Distance(hubs[b].Location()) }
+
+	inTree := make([]bool, len(hubs))
+	inTree[0] = true
+	for added := 1; added < len(hubs); added++ {
+		from, to := -1, -1
+		for a := range hubs {
+			if !inTree[a] {
+				continue
+			}
+			for b := range hubs {
+				if !inTree[b] && (to < 0 || dist(a, b) < dist(from, to)) {
+					from, to = a, b
+				}
+			}
+		}
+		inTree[to] = true
+		join(from, to)
+	}
+
+	for a := range hubs {
+		others := make([]int, 0, len(hubs)-1)
+		for b := range hubs {
+			if b != a {
+				others = append(others, b)
+			}
+		}
+		sort.SliceStable(others, func(i, j int) bool { return dist(a, others[i]) < dist(a, others[j]) })
+		for _, b := range others[:min(hubNeighbours, len(others))] {
+			join(a, b)
+		}
+	}
+	return edges
+}
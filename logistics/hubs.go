@@ -0,0 +1,190 @@
+package logistics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+// HubKind is the kind of interchange a hub is.
+type HubKind string
+
+const (
+	// Station: a train station. Two stations are joined by rail.
+	Station HubKind = "station"
+	// Depot: a truck depot. Any leg to or from one goes by road.
+	Depot HubKind = "depot"
+)
+
+// Hub legs: every leg pays handling at its hubs, then hauls by rail
+// between two stations and by road otherwise. Rail matches a built
+// Line; road is a truck's rate.
+const (
+	hubHandlingPerUnit = 0.05
+	railPerDistance    = linePerDistance
+	roadPerDistance    = 1.0 / 20000
+)
+
+// hubAccessCandidates is how many of the nearest hubs a trade considers
+// boarding and leaving the hub graph at.
+const hubAccessCandidates = 3
+
+// Hub is an interchange of the hub graph.
+type Hub struct {
+	Name string  `json:"name"`
+	Kind HubKind `json:"kind"`
+	X    int     `json:"x"`
+	Y    int     `json:"y"`
+}
+
+// Location is where the hub stands.
+func (h Hub) Location() point.Point {
+	return point.Point{X: h.X, Y: h.Y}
+}
+
+// HubEdge joins two hubs, by name.
+type HubEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// HubGraph routes long-distance trades through hubs: a shipment goes by
+// road to a hub near its origin, along the cheapest chain of hub legs,
+// and by road from a hub near its destination. Leg costs between every
+// pair of hubs are solved once, when the graph is built.
+type HubGraph struct {
+	hubs  []Hub
+	edges []HubEdge
+	// cost[i][j] is the per-unit cost of the cheapest path from hub i to
+	// hub j; +Inf when they are not connected.
+	cost [][]float64
+}
+
+// NewHubGraph builds a graph over hubs joined by edges, which run both
+// ways. It fails on an edge naming an unknown hub.
+func NewHubGraph(hubs []Hub, edges []HubEdge) (*HubGraph, error) {
+	index := make(map[string]int, len(hubs))
+	for i, h := range hubs {
+		if _, dup := index[h.Name]; dup {
+			return nil, fmt.Errorf("duplicate hub %q", h.Name)
+		}
+		if h.Kind != Station && h.Kind != Depot {
+			return nil, fmt.Errorf("hub %q has unknown kind %q", h.Name, h.Kind)
+		}
+		index[h.Name] = i
+	}
+	n := len(hubs)
+	cost := make([][]float64, n)
+	for i := range cost {
+		cost[i] = make([]float64, n)
+		for j := range cost[i] {
+			if i != j {
+				cost[i][j] = math.Inf(1)
+			}
+		}
+	}
+	for _, e := range edges {
+		i, ok := index[e.From]
+		if !ok {
+			return nil, fmt.Errorf("edge from unknown hub %q", e.From)
+		}
+		j, ok := index[e.To]
+		if !ok {
+			return nil, fmt.Errorf("edge to unknown hub %q", e.To)
+		}
+		leg := legCost(hubs[i], hubs[j])
+		cost[i][j] = math.Min(cost[i][j], leg)
+		cost[j][i] = math.Min(cost[j][i], leg)
+	}
+	// Floyd-Warshall: hub graphs are small and built once.
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				if via := cost[i][k] + cost[k][j]; via < cost[i][j] {
+					cost[i][j] = via
+				}
+			}
+		}
+	}
+	return &HubGraph{hubs: hubs, edges: edges, cost: cost}, nil
+}
+
+// hubFile is the on-disk form of a hub graph.
+type hubFile struct {
+	Hubs  []Hub     `json:"hubs"`
+	Edges []HubEdge `json:"edges"`
+}
+
+// LoadHubGraph reads a hub graph from JSON of the form
+//
+//	{"hubs": [{"name": "North", "kind": "station", "x": 0, "y": 0}, ...],
+//	 "edges": [{"from": "North", "to": "South"}, ...]}
+func LoadHubGraph(r io.Reader) (*HubGraph, error) {
+	var f hubFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to decode hub graph: %w", err)
+	}
+	return NewHubGraph(f.Hubs, f.Edges)
+}
+
+// legCost is the per-unit cost of one leg between adjacent hubs.
+func legCost(a, b Hub) float64 {
+	perDistance := roadPerDistance
+	if a.Kind == Station && b.Kind == Station {
+		perDistance = railPerDistance
+	}
+	return hubHandlingPerUnit + a.Location().Distance(b.Location())*perDistance
+}
+
+// Hubs returns the graph's hubs.
+func (g *HubGraph) Hubs() []Hub {
+	return g.hubs
+}
+
+// Edges returns the graph's edges.
+func (g *HubGraph) Edges() []HubEdge {
+	return g.edges
+}
+
+// nearest returns the indices of the k hubs closest to p, closest first.
+func (g *HubGraph) nearest(p point.Point, k int) []int {
+	idx := make([]int, len(g.hubs))
+	dist := make([]float64, len(g.hubs))
+	for i, h := range g.hubs {
+		idx[i], dist[i] = i, p.Distance(h.Location())
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return dist[idx[a]] < dist[idx[b]] })
+	return idx[:min(k, len(idx))]
+}
+
+// Via is the per-unit cost of the cheapest path from origin to
+// destination through the hub graph, boarding and leaving at one of the
+// nearest hubs to each end, with access giving the cost of the road legs
+// to and from them; +Inf when no hubs connect the two.
+func (g *HubGraph) Via(origin, destination point.Point, access func(point.Point, point.Point) float64) float64 {
+	best := math.Inf(1)
+	to := g.nearest(destination, hubAccessCandidates)
+	for _, i := range g.nearest(origin, hubAccessCandidates) {
+		board := access(origin, g.hubs[i].Location())
+		for _, j := range to {
+			if i == j {
+				continue
+			}
+			if cost := board + g.cost[i][j] + access(g.hubs[j].Location(), destination); cost < best {
+				best = cost
+			}
+		}
+	}
+	return best
+}
+
+// Floor is the least Via cost over any two points distance apart, for
+// access legs costing at least accessFloor by distance. It assumes access
+// freight is no cheaper per unit of distance than rail.
+func (g *HubGraph) Floor(distance float64, accessFloor func(float64) float64) float64 {
+	return 2*accessFloor(0) + hubHandlingPerUnit + distance*railPerDistance
+}
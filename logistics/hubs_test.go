@@ -0,0 +1,128 @@
+package logistics
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+func Test_HubGraph_Via(t *testing.T) {
+	// A rail chain west to east, with a depot off to the north joined by
+	// road: west-east trades ride the chain, several legs long.
+	g, err := NewHubGraph([]Hub{
+		{Name: "West", Kind: Station, X: 0, Y: 0},
+		{Name: "Middle", Kind: Station, X: 100000, Y: 0},
+		{Name: "East", Kind: Station, X: 200000, Y: 0},
+		{Name: "North", Kind: Depot, X: 100000, Y: 100000},
+	}, []HubEdge{
+		{From: "West", To: "Middle"},
+		{From: "Middle", To: "East"},
+		{From: "Middle", To: "North"},
+	})
+	if err != nil {
+		t.Fatalf("NewHubGraph: %v", err)
+	}
+	origin, destination := point.Point{X: -100, Y: 0}, point.Point{X: 200000, Y: 200}
+
+	via := g.Via(origin, destination, road)
+	if direct := road(origin, destination); via >= direct {
+		t.Fatalf("Via = %v, want it under the direct road's %v on a long haul", via, direct)
+	}
+	want := road(origin, point.Point{X: 0, Y: 0}) + 2*hubHandlingPerUnit +
+		200000*railPerDistance + road(point.Point{X: 200000, Y: 0}, destination)
+	if math.Abs(via-want) > 1e-9 {
+		t.Fatalf("Via = %v, want %v over West-Middle-East", via, want)
+	}
+	if floor := g.Floor(origin.Distance(destination), roadFloor); floor > via {
+		t.Fatalf("Floor = %v, above the actual Via %v", floor, via)
+	}
+
+	// Hubs never joined give no path.
+	island, err := NewHubGraph([]Hub{
+		{Name: "A", Kind: Station, X: 0, Y: 0},
+		{Name: "B", Kind: Station, X: 200000, Y: 0},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewHubGraph: %v", err)
+	}
+	if via := island.Via(origin, destination, road); !math.IsInf(via, 1) {
+		t.Fatalf("Via = %v between unjoined hubs, want +Inf", via)
+	}
+}
+
+func Test_LoadHubGraph(t *testing.T) {
+	g, err := LoadHubGraph(strings.NewReader(`{
+		"hubs": [
+			{"name": "North", "kind": "station", "x": 0, "y": 0},
+			{"name": "South", "kind": "depot", "x": 0, "y": 50000}
+		],
+		"edges": [{"from": "North", "to": "South"}]
+	}`))
+	if err != nil {
+		t.Fatalf("LoadHubGraph: %v", err)
+	}
+	if len(g.Hubs()) != 2 || len(g.Edges()) != 1 {
+		t.Fatalf("loaded %d hubs and %d edges, want 2 and 1", len(g.Hubs()), len(g.Edges()))
+	}
+
+	for _, bad := range []string{
+		`{"hubs": [{"name": "A", "kind": "station"}], "edges": [{"from": "A", "to": "B"}]}`,
+		`{"hubs": [{"name": "A", "kind": "port"}]}`,
+		`{"hubs": [{"name": "A", "kind": "station"}, {"name": "A", "kind": "depot"}]}`,
+		`not json`,
+	} {
+		if _, err := LoadHubGraph(strings.NewReader(bad)); err == nil {
+			t.Errorf("LoadHubGraph(%s) succeeded, want an error", bad)
+		}
+	}
+}
+
+func Test_ClusterHubs(t *testing.T) {
+	// Three tight fields of nodes, far apart.
+	var nodes []point.Point
+	for _, field := range []point.Point{{X: 0, Y: 0}, {X: 100000, Y: 0}, {X: 0, Y: 100000}} {
+		for i := 0; i < 5; i++ {
+			nodes = append(nodes, point.Point{X: field.X + 100*i, Y: field.Y - 100*i})
+		}
+	}
+
+	g, err := ClusterHubs(nodes, 3)
+	if err != nil {
+		t.Fatalf("ClusterHubs: %v", err)
+	}
+	for _, h := range g.Hubs() {
+		near := false
+		for _, p := range nodes {
+			near = near || p.Distance(h.Location()) < 1000
+		}
+		if !near {
+			t.Errorf("hub %s at %v is at no field's centre", h.Name, h.Location())
+		}
+	}
+	// The spanning tree joins every hub.
+	for i := range g.Hubs() {
+		for j := range g.Hubs() {
+			if math.IsInf(g.cost[i][j], 1) {
+				t.Fatalf("hubs %d and %d are not connected", i, j)
+			}
+		}
+	}
+
+	again, _ := ClusterHubs(nodes, 3)
+	for i, h := range again.Hubs() {
+		if h != g.Hubs()[i] {
+			t.Fatalf("hub %d = %v on a second run, want %v", i, h, g.Hubs()[i])
+		}
+	}
+
+	// A small world gets a station per node.
+	small, err := ClusterHubs(nodes[:2], 3)
+	if err != nil || len(small.Hubs()) != 2 {
+		t.Fatalf("ClusterHubs on 2 nodes = %v, %v; want 2 hubs", small, err)
+	}
+	if _, err := ClusterHubs(nil, 3); err == nil {
+		t.Fatal("ClusterHubs clustered no nodes")
+	}
+}
@@ -0,0 +1,83 @@
+package logistics
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// Line freight: a rail line hauls for a fraction of road freight per
+// unit of distance, but each trip over it pays loading at both hubs and
+// the line's toll on top of getting to and from the hubs.
+const (
+	lineFixedPerUnit = 0.05
+	linePerDistance  = 1.0 / 100000
+)
+
+// Line is shared transport infrastructure: a rail line between two hubs,
+// built once and open to every trade along its corridor for a toll per
+// unit. It is a producer in its own right -- it sits on the map and
+// earns -- but makes no goods.
+type Line struct {
+	A, B      point.Point
+	BuiltTick int
+	BuildCost float64
+	// Toll is charged per unit shipped over the line.
+	Toll float64
+	// Carried is the units ever shipped over the line, TollRevenue the
+	// tolls they paid.
+	Carried     float64
+	TollRevenue float64
+}
+
+// NewLine returns a line between hubs a and b.
+func NewLine(a, b point.Point, tick int, buildCost, toll float64) *Line {
+	return &Line{A: a, B: b, BuiltTick: tick, BuildCost: buildCost, Toll: toll}
+}
+
+// Location implements producer: the line's midpoint.
+func (ln *Line) Location() point.Point {
+	return point.Point{X: (ln.A.X + ln.B.X) / 2, Y: (ln.A.Y + ln.B.Y) / 2}
+}
+
+// Products implements producer: a line makes nothing.
+func (ln *Line) Products() production.Products {
+	return nil
+}
+
+// String implements producer.
+func (ln *Line) String() string {
+	return fmt.Sprintf("Line %s-%s", ln.A, ln.B)
+}
+
+// HaulCost is the per-unit cost of the trip between the hubs, toll
+// included.
+func (ln *Line) HaulCost() float64 {
+	return lineFixedPerUnit + ln.A.Distance(ln.B)*linePerDistance + ln.Toll
+}
+
+// Via is the per-unit cost of shipping from origin to destination over
+// the line, in whichever direction is cheaper, with access giving the
+// cost of the legs to and from the hubs.
+func (ln *Line) Via(origin, destination point.Point, access func(point.Point, point.Point) float64) float64 {
+	forward := access(origin, ln.A) + access(ln.B, destination)
+	backward := access(origin, ln.B) + access(ln.A, destination)
+	return math.Min(forward, backward) + ln.HaulCost()
+}
+
+// Floor is the least Via cost over any two points distance apart, for
+// access legs costing at least accessFloor by distance. It assumes access
+// freight is no cheaper per unit of distance than the line's haul.
+func (ln *Line) Floor(distance float64, accessFloor func(float64) float64) float64 {
+	return 2*accessFloor(0) + lineFixedPerUnit + ln.Toll + distance*linePerDistance
+}
+
+// Ship records qty units carried over the line and the tolls they paid.
+func (ln *Line) Ship(qty, tolls float64) {
+	ln.Carried += qty
+	ln.TollRevenue += tolls
+}
+
+var _ production.Producer = (*Line)(nil)
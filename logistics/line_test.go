@@ -0,0 +1,39 @@
+package logistics
+
+import (
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+// road is flat freight: a handling charge plus distance.
+func road(a, b point.Point) float64 { return 0.1 + a.Distance(b)/10000 }
+
+func roadFloor(distance float64) float64 { return 0.1 + distance/10000 }
+
+func Test_Line_Via(t *testing.T) {
+	ln := NewLine(point.Point{X: 0, Y: 0}, point.Point{X: 100000, Y: 0}, 0, 3000, 0.05)
+	origin, destination := point.Point{X: -100, Y: 0}, point.Point{X: 100000, Y: 200}
+
+	via := ln.Via(origin, destination, road)
+	if direct := road(origin, destination); via >= direct {
+		t.Fatalf("Via = %v, want it under the direct road's %v on a long haul", via, direct)
+	}
+	if back := ln.Via(destination, origin, road); back != via {
+		t.Fatalf("Via back = %v, want %v: a line runs both ways", back, via)
+	}
+	if floor := ln.Floor(origin.Distance(destination), roadFloor); floor > via {
+		t.Fatalf("Floor = %v, above the actual Via %v", floor, via)
+	}
+
+	// Off the corridor, the access legs eat the saving.
+	far := point.Point{X: 50000, Y: 90000}
+	if via, direct := ln.Via(origin, far, road), road(origin, far); via < direct {
+		t.Fatalf("Via = %v off the corridor, want no better than the road's %v", via, direct)
+	}
+
+	ln.Ship(10, 0.5)
+	if ln.Carried != 10 || ln.TollRevenue != 0.5 {
+		t.Fatalf("after Ship: carried %v, tolls %v; want 10 and 0.5", ln.Carried, ln.TollRevenue)
+	}
+}
@@ -0,0 +1,79 @@
+// Package logistics models how goods move between producers: a network
+// of links, each built for one transport mode between two locations,
+// with a per-tick capacity and a per-unit freight cost. A shipment takes
+// the cheapest mode that still has room on its link, so busy routes
+// spill onto dearer modes and full ones stop trading until next tick.
+package logistics
+
+import (
+	"fmt"
+	"math"
+)
+
+// Mode is a way of carrying goods between two locations.
+type Mode string
+
+const (
+	Conveyor Mode = "conveyor"
+	Truck    Mode = "truck"
+	Train    Mode = "train"
+	Drone    Mode = "drone"
+)
+
+// Modes lists every transport mode, cheapest to build first.
+var Modes = []Mode{Conveyor, Truck, Drone, Train}
+
+// Spec is what a mode costs and carries.
+type Spec struct {
+	// Capacity is the units per tick one link can carry.
+	Capacity float64
+	// BuildCost is the one-off cost of building a link, paid by whoever
+	// builds it (see Network.Built); freight does not carry it.
+	BuildCost float64
+	// FixedPerUnit and PerDistance are the running freight cost of one
+	// unit: a handling charge plus a charge per unit of distance.
+	FixedPerUnit float64
+	PerDistance  float64
+	// Speed is the distance goods cover per tick.
+	Speed float64
+	// MaxDistance is the longest link the mode can build; 0 is
+	// unlimited.
+	MaxDistance float64
+}
+
+// specs trades capacity and range against cost: conveyors are the
+// cheapest way to move goods but slow and short, trucks go anywhere at a
+// middling rate, trains carry the most for the least over long hauls
+// once their track is paid for, and drones are fast but carry little at
+// a premium. Conveyors are cheapest up to ~12k distance and trains
+// beyond; when those are full, trucks take over, undercut by drones only
+// past ~22k.
+var specs = map[Mode]Spec{
+	Conveyor: {Capacity: 12, BuildCost: 50, FixedPerUnit: 0.02, PerDistance: 1.0 / 20000, Speed: 2000, MaxDistance: 20000},
+	Truck:    {Capacity: 8, BuildCost: 200, FixedPerUnit: 0.1, PerDistance: 1.0 / 15000, Speed: 6000},
+	Train:    {Capacity: 50, BuildCost: 5000, FixedPerUnit: 0.5, PerDistance: 1.0 / 100000, Speed: 20000},
+	Drone:    {Capacity: 2, BuildCost: 1000, FixedPerUnit: 1.0, PerDistance: 1.0 / 40000, Speed: 40000},
+}
+
+// SpecFor returns the spec of a mode.
+func SpecFor(m Mode) Spec {
+	return specs[m]
+}
+
+// ParseMode returns the mode with the given name.
+func ParseMode(name string) (Mode, error) {
+	if _, ok := specs[Mode(name)]; !ok {
+		return "", fmt.Errorf("unknown transport mode %q", name)
+	}
+	return Mode(name), nil
+}
+
+// UnitCost is the running cost of carrying one unit over distance by
+// this mode, or +Inf beyond the mode's range.
+func (m Mode) UnitCost(distance float64) float64 {
+	spec := specs[m]
+	if spec.MaxDistance > 0 && distance > spec.MaxDistance {
+		return math.Inf(1)
+	}
+	return spec.FixedPerUnit + distance*spec.PerDistance
+}
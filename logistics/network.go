@@ -0,0 +1,173 @@
+package logistics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+// capacityEpsilon is the smallest residual capacity treated as room.
+const capacityEpsilon = 1e-9
+
+// linkIdleTicks is how long a link may go unused before it is torn
+// down. Producers move and die, so links between places nobody trades
+// from any more would otherwise pile up forever.
+const linkIdleTicks = 1000
+
+// Link is a built connection carrying goods from Origin to Destination
+// by one mode.
+type Link struct {
+	Origin      point.Point
+	Destination point.Point
+	Mode        Mode
+	BuiltTick   int
+	// LastUsedTick is the last tick anything was shipped on the link.
+	LastUsedTick int
+	// Used is the units shipped on the link this tick, Carried the units
+	// ever shipped.
+	Used    float64
+	Carried float64
+}
+
+// Residual is the capacity left on the link this tick.
+func (l *Link) Residual() float64 {
+	return math.Max(0, specs[l.Mode].Capacity-l.Used)
+}
+
+type linkKey struct {
+	origin      point.Point
+	destination point.Point
+	mode        Mode
+}
+
+// Route is the mode a shipment would take between two locations right
+// now: its per-unit cost and the room left for it this tick.
+type Route struct {
+	Mode     Mode
+	UnitCost float64
+	Residual float64
+}
+
+// Network is the set of links in the world and this tick's use of them.
+// Links are built on first use and torn down after linkIdleTicks unused;
+// a pair of locations may be linked by several modes at once.
+type Network struct {
+	// CanBuild, when set, reports whether a link by mode can be built
+	// now; Route passes over unbuilt links it rejects. Nil builds any.
+	CanBuild func(mode Mode) bool
+
+	modes []Mode
+	links map[linkKey]*Link
+	tick  int
+}
+
+// NewNetwork returns an empty network offering the given modes.
+func NewNetwork(modes ...Mode) *Network {
+	return &Network{
+		modes: modes,
+		links: make(map[linkKey]*Link),
+	}
+}
+
+// BeginTick frees every link's capacity for a new tick and tears down
+// the links idle for more than linkIdleTicks.
+func (n *Network) BeginTick(tick int) {
+	n.tick = tick
+	for key, l := range n.links {
+		if tick-l.LastUsedTick > linkIdleTicks {
+			delete(n.links, key)
+			continue
+		}
+		l.Used = 0
+	}
+}
+
+// Route returns the cheapest mode with room left between origin and
+// destination, built already or buildable (see CanBuild), or false if
+// every mode is full, out of range or unaffordable.
+func (n *Network) Route(origin, destination point.Point) (Route, bool) {
+	d := origin.Distance(destination)
+	best, found := Route{UnitCost: math.Inf(1)}, false
+	for _, m := range n.modes {
+		cost := m.UnitCost(d)
+		if math.IsInf(cost, 1) || cost >= best.UnitCost {
+			continue
+		}
+		residual := specs[m].Capacity
+		if l, ok := n.links[linkKey{origin, destination, m}]; ok {
+			residual = l.Residual()
+		} else if n.CanBuild != nil && !n.CanBuild(m) {
+			continue
+		}
+		if residual <= capacityEpsilon {
+			continue
+		}
+		best, found = Route{Mode: m, UnitCost: cost, Residual: residual}, true
+	}
+	return best, found
+}
+
+// UnitCost is the per-unit freight of the cheapest route with room
+// between origin and destination; +Inf when there is none.
+func (n *Network) UnitCost(origin, destination point.Point) float64 {
+	r, ok := n.Route(origin, destination)
+	if !ok {
+		return math.Inf(1)
+	}
+	return r.UnitCost
+}
+
+// UnitFloor is the least UnitCost over any two points distance apart:
+// capacity only ever raises it.
+func (n *Network) UnitFloor(distance float64) float64 {
+	floor := math.Inf(1)
+	for _, m := range n.modes {
+		floor = math.Min(floor, m.UnitCost(distance))
+	}
+	return floor
+}
+
+// Built reports whether a link by mode already joins origin to
+// destination.
+func (n *Network) Built(origin, destination point.Point, mode Mode) bool {
+	_, ok := n.links[linkKey{origin, destination, mode}]
+	return ok
+}
+
+// Ship records qty units sent from origin to destination by mode,
+// building the link on first use. The caller pays the mode's BuildCost
+// for a link not yet Built.
+func (n *Network) Ship(origin, destination point.Point, mode Mode, qty float64) {
+	key := linkKey{origin, destination, mode}
+	l, ok := n.links[key]
+	if !ok {
+		l = &Link{Origin: origin, Destination: destination, Mode: mode, BuiltTick: n.tick}
+		n.links[key] = l
+	}
+	l.LastUsedTick = n.tick
+	l.Used += qty
+	l.Carried += qty
+}
+
+// Links returns every built link, oldest first.
+func (n *Network) Links() []*Link {
+	links := make([]*Link, 0, len(n.links))
+	for _, l := range n.links {
+		links = append(links, l)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		a, b := links[i], links[j]
+		if a.BuiltTick != b.BuiltTick {
+			return a.BuiltTick < b.BuiltTick
+		}
+		if a.Origin != b.Origin {
+			return a.Origin.X < b.Origin.X || (a.Origin.X == b.Origin.X && a.Origin.Y < b.Origin.Y)
+		}
+		if a.Destination != b.Destination {
+			return a.Destination.X < b.Destination.X || (a.Destination.X == b.Destination.X && a.Destination.Y < b.Destination.Y)
+		}
+		return a.Mode < b.Mode
+	})
+	return links
+}
@@ -0,0 +1,98 @@
+package logistics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+func Test_Network_Route_cheapestWithRoom(t *testing.T) {
+	n := NewNetwork(Modes...)
+	a, b := point.Point{X: 0, Y: 0}, point.Point{X: 5000, Y: 0}
+
+	r, ok := n.Route(a, b)
+	if !ok || r.Mode != Conveyor || r.Residual != 12 {
+		t.Fatalf("route = %+v (ok=%v), want a fresh conveyor", r, ok)
+	}
+
+	// Filling the conveyor spills onto the next cheapest mode.
+	n.Ship(a, b, Conveyor, 12)
+	r, ok = n.Route(a, b)
+	if !ok || r.Mode != Truck {
+		t.Fatalf("route = %+v (ok=%v), want the truck once the conveyor is full", r, ok)
+	}
+	if got := n.UnitCost(a, b); got != Truck.UnitCost(5000) {
+		t.Fatalf("UnitCost = %v, want the truck's %v", got, Truck.UnitCost(5000))
+	}
+
+	// A new tick frees the capacity; the link stays built.
+	n.BeginTick(2)
+	if r, _ := n.Route(a, b); r.Mode != Conveyor {
+		t.Fatalf("route = %+v after BeginTick, want the conveyor again", r)
+	}
+	if links := n.Links(); len(links) != 1 || links[0].Carried != 12 || links[0].Used != 0 {
+		t.Fatalf("links = %+v, want one conveyor that carried 12", links)
+	}
+}
+
+func Test_Network_Route_limits(t *testing.T) {
+	n := NewNetwork(Conveyor)
+	a := point.Point{X: 0, Y: 0}
+	if r, ok := n.Route(a, a); !ok || r.Mode != Conveyor {
+		t.Fatalf("route between neighbours = %+v, %v, want the conveyor", r, ok)
+	}
+	far := point.Point{X: 30000, Y: 0}
+	if _, ok := n.Route(a, far); ok {
+		t.Fatal("a conveyor should not reach past its range")
+	}
+	if got := n.UnitCost(a, far); !math.IsInf(got, 1) {
+		t.Fatalf("UnitCost out of range = %v, want +Inf", got)
+	}
+	near := point.Point{X: 100, Y: 0}
+	n.Ship(a, near, Conveyor, 12)
+	if _, ok := n.Route(a, near); ok {
+		t.Fatal("a full link should have no route")
+	}
+	if got, want := n.UnitFloor(100), Conveyor.UnitCost(100); got != want {
+		t.Fatalf("UnitFloor = %v, want %v regardless of use", got, want)
+	}
+}
+
+func Test_Network_Route_passesOverUnbuildableLinks(t *testing.T) {
+	n := NewNetwork(Conveyor, Truck)
+	n.CanBuild = func(m Mode) bool { return m == Truck }
+	a, b := point.Point{X: 0, Y: 0}, point.Point{X: 5000, Y: 0}
+	if r, ok := n.Route(a, b); !ok || r.Mode != Truck {
+		t.Fatalf("route = %+v (ok=%v), want the truck when a conveyor cannot be built", r, ok)
+	}
+	n.Ship(a, b, Conveyor, 1) // built before funds ran short
+	if r, ok := n.Route(a, b); !ok || r.Mode != Conveyor {
+		t.Fatalf("route = %+v (ok=%v), want the built conveyor", r, ok)
+	}
+	if got, want := Conveyor.UnitCost(5000), 0.02+5000.0/20000; got != want {
+		t.Fatalf("conveyor UnitCost = %v, want the running cost %v alone", got, want)
+	}
+}
+
+func Test_Network_BeginTick_tearsDownIdleLinks(t *testing.T) {
+	n := NewNetwork(Conveyor)
+	a, b, c := point.Point{X: 0, Y: 0}, point.Point{X: 100, Y: 0}, point.Point{X: 200, Y: 0}
+	n.BeginTick(1)
+	if n.Built(a, b, Conveyor) {
+		t.Fatal("no link should be built before the first shipment")
+	}
+	n.Ship(a, b, Conveyor, 1)
+	n.Ship(a, c, Conveyor, 1)
+	if !n.Built(a, b, Conveyor) {
+		t.Fatal("the first shipment should build the link")
+	}
+
+	// Only a->c keeps trading.
+	n.BeginTick(1 + linkIdleTicks)
+	n.Ship(a, c, Conveyor, 1)
+	n.BeginTick(2 + linkIdleTicks)
+	if n.Built(a, b, Conveyor) || !n.Built(a, c, Conveyor) {
+		t.Fatalf("links = %+v, want only the link still in use", n.Links())
+	}
+}
@@ -1,9 +1,10 @@
 // Package market implements the order book through which producers
 // discover each other: sellers post asks (unsold capacity at a price),
 // buyers post bids (unmet demand at a price), and a per-tick matching
-// pass crosses them into contracts. The book is rebuilt from live
-// producer state every tick -- only the prices carried by the producers
-// themselves persist between ticks.
+// pass crosses them into contracts. Producers republish every order from
+// live state each tick; the book keeps one order per producer, product
+// and side and updates it in place, so quantities are never stale and
+// only prices carried by the producers themselves persist between ticks.
 package market
 
 import (
@@ -19,6 +20,12 @@ type Ask struct {
 	Product   string
 	Remaining float64
 	UnitPrice float64
+	// Level is the order's rung in a ladder (see PostAskLadder); 0 for
+	// a plain order.
+	Level int
+	Terms OrderTerms
+
+	orderMeta
 }
 
 // Bid is a standing offer to buy Remaining units/sec of Product at up to
@@ -28,98 +35,355 @@ type Bid struct {
 	Product   string
 	Remaining float64
 	UnitPrice float64
+	// Level is the order's rung in a ladder (see PostBidLadder); 0 for
+	// a plain order.
+	Level int
+	Terms OrderTerms
+
+	orderMeta
+}
+
+// OrderTerms qualify how an order may trade and how long it stands. The
+// zero value is a plain order: any fill size, withdrawn at the next
+// Republish unless reposted.
+type OrderTerms struct {
+	// Expiry, when positive, makes the order good-till-cancelled: it
+	// stands through tick Expiry without being reposted, keeping its
+	// unfilled Remaining, until then or until cancelled.
+	Expiry int
+	// FillOrKill orders trade their whole Remaining in one match or not
+	// at all.
+	FillOrKill bool
+	// MinQty is the smallest fill the order accepts, for batch shipping.
+	MinQty float64
+}
+
+// Level is one rung of a ladder order.
+type Level struct {
+	Rate      float64
+	UnitPrice float64
 }
 
 // Book holds the standing asks and bids for every product. Orders are
-// kept in posting order; queries that need price order pick minima with
-// strict comparison, so ties resolve by posting order and results are
-// deterministic.
+// kept in posting order -- the order their producers were first seen --
+// and in price order for BestAsk/BestBid; price ties resolve by posting
+// order, so results are deterministic.
 type Book struct {
-	asks map[string][]*Ask
-	bids map[string][]*Bid
+	asks     map[string]*side[*Ask]
+	bids     map[string]*side[*Bid]
+	askIndex map[orderKey]*Ask
+	bidIndex map[orderKey]*Bid
+
+	// producers ranks every producer with an order by first sighting.
+	producers map[production.Producer]*producerEntry
+	nextSeq   int
+
+	// pass counts Republish calls; orders not posted in the current pass
+	// are withdrawn when the book next settles, unless good-till-cancelled
+	// through tick.
+	pass     int
+	tick     int
+	sweep    bool
+	dirty    bool
+	products []string
+
+	transportFloor func(distance float64) float64
+	matcher        Matcher
+}
+
+type orderKey struct {
+	producer production.Producer
+	product  string
+	level    int
+}
+
+type producerEntry struct {
+	seq  int
+	pass int
 }
 
 func NewBook() *Book {
-	return &Book{
-		asks: make(map[string][]*Ask),
-		bids: make(map[string][]*Bid),
-	}
+	b := &Book{}
+	b.Clear()
+	return b
 }
 
-// Clear drops every order. Called at the top of each tick before
-// producers republish from live state.
+// Clear drops every order.
 func (b *Book) Clear() {
-	b.asks = make(map[string][]*Ask)
-	b.bids = make(map[string][]*Bid)
+	b.asks = make(map[string]*side[*Ask])
+	b.bids = make(map[string]*side[*Bid])
+	b.askIndex = make(map[orderKey]*Ask)
+	b.bidIndex = make(map[orderKey]*Bid)
+	b.producers = make(map[production.Producer]*producerEntry)
+	b.sweep = false
+	b.dirty = true
 }
 
-func (b *Book) PostAsk(seller production.Producer, product string, rate, unitPrice float64) {
-	if rate <= production.RateEpsilon {
-		return
+// Republish starts the publishing pass for tick. Called at the top of
+// each tick before producers repost from live state: a reposted order is
+// updated in place, and any order not reposted is withdrawn unless it is
+// good-till-cancelled through tick. Orders rank by when their producer
+// was first seen, so producers must be posted in a stable order with
+// newcomers last.
+//
+// A producer keeps its rank only while some order of its stands. One
+// that skips a pass loses every order, and with them its rank: when it
+// posts again it ranks behind every standing producer, as a newcomer,
+// wherever it comes in the posting order. A book rebuilt from scratch
+// would rank it by its posting position instead.
+func (b *Book) Republish(tick int) {
+	// Withdraw the last pass's leftovers now, so a skipped producer
+	// loses its rank whether or not the book was queried in between.
+	if b.sweep {
+		b.settle()
 	}
-	b.asks[product] = append(b.asks[product],
-		&Ask{Seller: seller, Product: product, Remaining: rate, UnitPrice: unitPrice})
+	b.pass++
+	b.tick = tick
+	b.sweep = true
+	b.dirty = true
+}
+
+func (b *Book) PostAsk(seller production.Producer, product string, rate, unitPrice float64) {
+	b.postAsk(seller, product, 0, rate, unitPrice, OrderTerms{})
 }
 
 func (b *Book) PostBid(buyer production.Producer, product string, rate, unitPrice float64) {
-	if rate <= production.RateEpsilon {
-		return
-	}
-	b.bids[product] = append(b.bids[product],
-		&Bid{Buyer: buyer, Product: product, Remaining: rate, UnitPrice: unitPrice})
+	b.postBid(buyer, product, 0, rate, unitPrice, OrderTerms{})
 }
 
-// Asks returns the asks for product in posting order.
-func (b *Book) Asks(product string) []*Ask { return b.asks[product] }
+// PostAskTerms posts an ask with the given terms.
+func (b *Book) PostAskTerms(seller production.Producer, product string, rate, unitPrice float64, terms OrderTerms) {
+	b.postAsk(seller, product, 0, rate, unitPrice, terms)
+}
 
-// Bids returns the bids for product in posting order.
-func (b *Book) Bids(product string) []*Bid { return b.bids[product] }
+// PostBidTerms posts a bid with the given terms.
+func (b *Book) PostBidTerms(buyer production.Producer, product string, rate, unitPrice float64, terms OrderTerms) {
+	b.postBid(buyer, product, 0, rate, unitPrice, terms)
+}
 
-// BestAsk returns the unfilled ask with the lowest unit price.
-func (b *Book) BestAsk(product string) (*Ask, bool) {
-	var best *Ask
-	for _, ask := range b.asks[product] {
-		if ask.Remaining <= production.RateEpsilon {
+// PostAskLadder offers product at several price levels at once, one ask
+// per level, each with the given terms. Levels beyond the ladder's
+// length left over from an earlier, longer ladder are cancelled.
+func (b *Book) PostAskLadder(seller production.Producer, product string, levels []Level, terms OrderTerms) {
+	for i, level := range levels {
+		b.postAsk(seller, product, i, level.Rate, level.UnitPrice, terms)
+	}
+	for i := len(levels); ; i++ {
+		ask, ok := b.askIndex[orderKey{seller, product, i}]
+		if !ok {
+			break
+		}
+		ask.cancel()
+		b.sweep, b.dirty = true, true
+	}
+}
+
+// PostBidLadder splits demand for product across several price levels,
+// one bid per level, each with the given terms. Levels beyond the
+// ladder's length left over from an earlier, longer ladder are
+// cancelled, unless good-till-cancelled and still standing.
+func (b *Book) PostBidLadder(buyer production.Producer, product string, levels []Level, terms OrderTerms) {
+	for i, level := range levels {
+		b.postBid(buyer, product, i, level.Rate, level.UnitPrice, terms)
+	}
+	for i := len(levels); ; i++ {
+		bid, ok := b.bidIndex[orderKey{buyer, product, i}]
+		if !ok {
+			break
+		}
+		if bid.standsThrough() > 0 && bid.standsThrough() >= b.tick {
 			continue
 		}
-		if best == nil || ask.UnitPrice < best.UnitPrice {
-			best = ask
+		bid.cancel()
+		b.sweep, b.dirty = true, true
+	}
+}
+
+// PostBidLevel posts the one rung level of buyer's ladder for product,
+// with its own terms, leaving the ladder's other rungs as they stand.
+func (b *Book) PostBidLevel(buyer production.Producer, product string, level int, rate, unitPrice float64, terms OrderTerms) {
+	b.postBid(buyer, product, level, rate, unitPrice, terms)
+}
+
+// CancelAsks withdraws every ask level seller has for product,
+// good-till-cancelled or not.
+func (b *Book) CancelAsks(seller production.Producer, product string) {
+	for i := 0; ; i++ {
+		ask, ok := b.askIndex[orderKey{seller, product, i}]
+		if !ok {
+			return
 		}
+		ask.cancel()
+		b.sweep, b.dirty = true, true
 	}
-	return best, best != nil
 }
 
-// BestBid returns the unfilled bid with the highest unit price.
-func (b *Book) BestBid(product string) (*Bid, bool) {
-	var best *Bid
-	for _, bid := range b.bids[product] {
-		if bid.Remaining <= production.RateEpsilon {
-			continue
+// CancelBids withdraws every bid level buyer has for product,
+// good-till-cancelled or not.
+func (b *Book) CancelBids(buyer production.Producer, product string) {
+	for i := 0; ; i++ {
+		bid, ok := b.bidIndex[orderKey{buyer, product, i}]
+		if !ok {
+			return
 		}
-		if best == nil || bid.UnitPrice > best.UnitPrice {
-			best = bid
+		bid.cancel()
+		b.sweep, b.dirty = true, true
+	}
+}
+
+func (b *Book) postAsk(seller production.Producer, product string, level int, rate, unitPrice float64, terms OrderTerms) {
+	key := orderKey{seller, product, level}
+	ask, ok := b.askIndex[key]
+	if !ok {
+		ask = &Ask{Seller: seller, Product: product, Level: level}
+		ask.seq = b.seqOf(seller)
+		b.askIndex[key] = ask
+		s, ok := b.asks[product]
+		if !ok {
+			s = &side[*Ask]{}
+			b.asks[product] = s
 		}
+		s.insert(ask)
 	}
-	return best, best != nil
+	b.producers[seller].pass = b.pass
+	ask.Remaining, ask.UnitPrice, ask.Terms = rate, unitPrice, terms
+	ask.post(b.pass, rate)
+	b.dirty = true
 }
 
-// Products returns every product with at least one order, sorted, so
-// callers can iterate the book deterministically.
-func (b *Book) Products() []string {
-	seen := make(map[string]bool)
+func (b *Book) postBid(buyer production.Producer, product string, level int, rate, unitPrice float64, terms OrderTerms) {
+	key := orderKey{buyer, product, level}
+	bid, ok := b.bidIndex[key]
+	if !ok {
+		bid = &Bid{Buyer: buyer, Product: product, Level: level}
+		bid.seq = b.seqOf(buyer)
+		b.bidIndex[key] = bid
+		s, ok := b.bids[product]
+		if !ok {
+			s = &side[*Bid]{}
+			b.bids[product] = s
+		}
+		s.insert(bid)
+	}
+	b.producers[buyer].pass = b.pass
+	bid.Remaining, bid.UnitPrice, bid.Terms = rate, unitPrice, terms
+	bid.post(b.pass, rate)
+	b.dirty = true
+}
+
+// seqOf returns the producer's posting rank, ranking it last if new or
+// returning after a skipped pass.
+func (b *Book) seqOf(p production.Producer) int {
+	entry, ok := b.producers[p]
+	if !ok {
+		entry = &producerEntry{seq: b.nextSeq}
+		b.nextSeq++
+		b.producers[p] = entry
+	}
+	return entry.seq
+}
+
+// settle brings the posting and price orders up to date with the posts
+// made since the last query, withdrawing cancelled orders and orders a
+// Republish pass did not repost. Queries settle first; once settled, the
+// book's indexes are fixed until the next post, so MatchProduct may run
+// concurrently on distinct products. Queries of one product are not safe
+// concurrently: BestAsk and BestBid advance their side's cursor past
+// filled orders, and matching fills them.
+func (b *Book) settle() {
+	if !b.dirty {
+		return
+	}
+	for product, s := range b.asks {
+		for _, ask := range s.settle(b.pass, b.tick, b.sweep) {
+			delete(b.askIndex, orderKey{ask.Seller, product, ask.Level})
+		}
+		if len(s.all) == 0 {
+			delete(b.asks, product)
+		}
+	}
+	for product, s := range b.bids {
+		for _, bid := range s.settle(b.pass, b.tick, b.sweep) {
+			delete(b.bidIndex, orderKey{bid.Buyer, product, bid.Level})
+		}
+		if len(s.all) == 0 {
+			delete(b.bids, product)
+		}
+	}
+	if b.sweep {
+		// Producers keep their rank while any order of theirs stands.
+		for key := range b.askIndex {
+			b.producers[key.producer].pass = b.pass
+		}
+		for key := range b.bidIndex {
+			b.producers[key.producer].pass = b.pass
+		}
+		for p, entry := range b.producers {
+			if entry.pass != b.pass {
+				delete(b.producers, p)
+			}
+		}
+	}
+
 	products := make([]string, 0, len(b.asks)+len(b.bids))
-	for product := range b.asks {
-		if !seen[product] {
-			seen[product] = true
+	for product, s := range b.asks {
+		if len(s.live) > 0 {
 			products = append(products, product)
 		}
 	}
-	for product := range b.bids {
-		if !seen[product] {
-			seen[product] = true
-			products = append(products, product)
+	for product, s := range b.bids {
+		if len(s.live) > 0 {
+			if a, ok := b.asks[product]; !ok || len(a.live) == 0 {
+				products = append(products, product)
+			}
 		}
 	}
 	sort.Strings(products)
-	return products
+	b.products = products
+	b.sweep = false
+	b.dirty = false
+}
+
+// Asks returns the asks for product in posting order.
+func (b *Book) Asks(product string) []*Ask {
+	b.settle()
+	if s, ok := b.asks[product]; ok {
+		return s.live
+	}
+	return nil
+}
+
+// Bids returns the bids for product in posting order.
+func (b *Book) Bids(product string) []*Bid {
+	b.settle()
+	if s, ok := b.bids[product]; ok {
+		return s.live
+	}
+	return nil
+}
+
+// BestAsk returns the unfilled ask with the lowest unit price.
+func (b *Book) BestAsk(product string) (*Ask, bool) {
+	b.settle()
+	if s, ok := b.asks[product]; ok {
+		return s.best()
+	}
+	return nil, false
+}
+
+// BestBid returns the unfilled bid with the highest unit price.
+func (b *Book) BestBid(product string) (*Bid, bool) {
+	b.settle()
+	if s, ok := b.bids[product]; ok {
+		return s.best()
+	}
+	return nil, false
+}
+
+// Products returns every product with at least one order, sorted, so
+// callers can iterate the book deterministically. The slice is shared
+// and must not be modified.
+func (b *Book) Products() []string {
+	b.settle()
+	return b.products
 }
@@ -1,6 +1,7 @@
 package market
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/paul-freeman/satisfactory-story/factory"
@@ -80,3 +81,165 @@ func Test_Book_Products_sorted_and_Clear(t *testing.T) {
 		t.Error("expected an empty book after Clear")
 	}
 }
+
+// publishPass posts one tick's orders for every producer in slice order:
+// each sells "Ore" and buys "Ingot", with some quantities zero.
+func publishPass(b *Book, r *rand.Rand, producers []production.Producer) {
+	for _, p := range producers {
+		b.PostAsk(p, "Ore", float64(r.Intn(4)), float64(1+r.Intn(5)))
+		if r.Intn(3) > 0 {
+			b.PostBid(p, "Ingot", float64(r.Intn(4)), float64(1+r.Intn(5)))
+		}
+	}
+}
+
+func Test_Book_Republish_matchesFreshBook(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	persistent := NewBook()
+	producers := make([]production.Producer, 0)
+	var skipped production.Producer
+	for pass := 0; pass < 200; pass++ {
+		// Churn: a producer that skipped the last pass returns, ranked as
+		// a newcomer; drop a random producer, append newcomers, and skip
+		// one for this pass.
+		if skipped != nil {
+			producers = append(producers, skipped)
+			skipped = nil
+		}
+		if len(producers) > 0 && r.Intn(2) == 0 {
+			i := r.Intn(len(producers))
+			producers = append(producers[:i], producers[i+1:]...)
+		}
+		for n := r.Intn(3); n > 0; n-- {
+			producers = append(producers, testProducer(r.Intn(100), r.Intn(100)))
+		}
+		if len(producers) > 1 && r.Intn(3) == 0 {
+			i := r.Intn(len(producers) - 1)
+			skipped = producers[i]
+			producers = append(producers[:i], producers[i+1:]...)
+		}
+
+		seed := r.Int63()
+		fresh := NewBook()
+		publishPass(fresh, rand.New(rand.NewSource(seed)), producers)
+		persistent.Republish(pass)
+		publishPass(persistent, rand.New(rand.NewSource(seed)), producers)
+
+		if got, want := persistent.Products(), fresh.Products(); len(got) != len(want) {
+			t.Fatalf("pass %d: products %v, want %v", pass, got, want)
+		}
+		for _, product := range []string{"Ore", "Ingot"} {
+			gotAsks, wantAsks := persistent.Asks(product), fresh.Asks(product)
+			if len(gotAsks) != len(wantAsks) {
+				t.Fatalf("pass %d: %d %s asks, want %d", pass, len(gotAsks), product, len(wantAsks))
+			}
+			for i := range wantAsks {
+				if gotAsks[i].Seller != wantAsks[i].Seller || gotAsks[i].Remaining != wantAsks[i].Remaining {
+					t.Fatalf("pass %d: %s ask %d differs", pass, product, i)
+				}
+			}
+			gotBids, wantBids := persistent.Bids(product), fresh.Bids(product)
+			if len(gotBids) != len(wantBids) {
+				t.Fatalf("pass %d: %d %s bids, want %d", pass, len(gotBids), product, len(wantBids))
+			}
+			for i := range wantBids {
+				if gotBids[i].Buyer != wantBids[i].Buyer || gotBids[i].Remaining != wantBids[i].Remaining {
+					t.Fatalf("pass %d: %s bid %d differs", pass, product, i)
+				}
+			}
+		}
+
+		var gotMatches, wantMatches []Match
+		persistent.MatchAll(flatTransport, collectMatches(&gotMatches))
+		fresh.MatchAll(flatTransport, collectMatches(&wantMatches))
+		if len(gotMatches) != len(wantMatches) {
+			t.Fatalf("pass %d: %d matches, want %d", pass, len(gotMatches), len(wantMatches))
+		}
+		for i := range wantMatches {
+			if gotMatches[i] != wantMatches[i] {
+				t.Fatalf("pass %d: match %d = %+v, want %+v", pass, i, gotMatches[i], wantMatches[i])
+			}
+		}
+		gotAsk, gotOK := persistent.BestAsk("Ore")
+		wantAsk, wantOK := fresh.BestAsk("Ore")
+		if gotOK != wantOK || (wantOK && gotAsk.Seller != wantAsk.Seller) {
+			t.Fatalf("pass %d: best ask %+v, want %+v", pass, gotAsk, wantAsk)
+		}
+		gotBid, gotOK := persistent.BestBid("Ingot")
+		wantBid, wantOK := fresh.BestBid("Ingot")
+		if gotOK != wantOK || (wantOK && gotBid.Buyer != wantBid.Buyer) {
+			t.Fatalf("pass %d: best bid %+v, want %+v", pass, gotBid, wantBid)
+		}
+	}
+}
+
+func Test_Book_Republish_skippedProducerRanksAsNewcomer(t *testing.T) {
+	b := NewBook()
+	first, second := testProducer(0, 0), testProducer(1, 1)
+	b.PostAsk(first, "Ore", 5, 1.0)
+	b.PostAsk(second, "Ore", 5, 1.0)
+
+	b.Republish(1) // first skips, and nothing queries the book
+	b.PostAsk(second, "Ore", 5, 1.0)
+	b.Republish(2)
+	b.PostAsk(first, "Ore", 5, 1.0)
+	b.PostAsk(second, "Ore", 5, 1.0)
+
+	if asks := b.Asks("Ore"); len(asks) != 2 || asks[0].Seller != second {
+		t.Fatalf("asks = %+v, want the returning producer ranked last", asks)
+	}
+}
+
+func Test_Book_Republish_withdrawsUnpostedOrders(t *testing.T) {
+	b := NewBook()
+	gone, stays := testProducer(0, 0), testProducer(1, 1)
+	b.PostAsk(gone, "Ore", 5, 1.0)
+	b.PostAsk(stays, "Ore", 5, 2.0)
+
+	b.Republish(1)
+	b.PostAsk(stays, "Ore", 3, 2.5)
+
+	asks := b.Asks("Ore")
+	if len(asks) != 1 || asks[0].Seller != stays || asks[0].Remaining != 3 || asks[0].UnitPrice != 2.5 {
+		t.Fatalf("asks = %+v, want only the reposted ask, updated in place", asks)
+	}
+}
+
+// benchmarkPublish republishes a world of producers that each sell one
+// of a few products and buy another, then queries the best prices, as a
+// tick does. rebuild clears the book first instead of updating in place.
+func benchmarkPublish(b *testing.B, producers int, rebuild bool) {
+	r := rand.New(rand.NewSource(1))
+	ps := make([]production.Producer, producers)
+	prices := make([]float64, producers)
+	for i := range ps {
+		ps[i] = testProducer(r.Intn(100000), r.Intn(100000))
+		prices[i] = 1 + 10*r.Float64()
+	}
+	products := []string{"Ore", "Coal", "Ingot", "Plate", "Screw"}
+	book := NewBook()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if rebuild {
+			book.Clear()
+		} else {
+			book.Republish(n)
+		}
+		for i, p := range ps {
+			prices[i] *= 1 + 0.02*(r.Float64()-0.5) // drift, as adjustPrices does
+			book.PostAsk(p, products[i%len(products)], float64(i%7), prices[i])
+			book.PostBid(p, products[(i+1)%len(products)], float64(i%5), prices[i])
+		}
+		for _, product := range book.Products() {
+			for q := 0; q < 20; q++ {
+				book.BestAsk(product)
+				book.BestBid(product)
+			}
+		}
+	}
+}
+
+func BenchmarkBook_rebuild_10k(b *testing.B)     { benchmarkPublish(b, 10000, true) }
+func BenchmarkBook_incremental_10k(b *testing.B) { benchmarkPublish(b, 10000, false) }
+func BenchmarkBook_rebuild_50k(b *testing.B)     { benchmarkPublish(b, 50000, true) }
+func BenchmarkBook_incremental_50k(b *testing.B) { benchmarkPublish(b, 50000, false) }
@@ -2,65 +2,86 @@ package market
 
 import (
 	"math"
-	"sort"
 
 	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/production"
 )
 
 // Match is a crossed bid/ask pair ready to execute as a one-shot trade.
-// The trade executes at the ask's unit price; the buyer additionally
-// pays UnitTransport per unit of freight.
+// The trade executes at UnitPrice, set by the book's Matcher; the buyer
+// additionally pays UnitTransport per unit of freight.
 type Match struct {
 	Seller        production.Producer
 	Buyer         production.Producer
 	Order         production.Production // Rate = candidate quantity (units)
 	UnitPrice     float64
 	UnitTransport float64
+	// MinQty is the smallest execution both orders' terms accept; a
+	// smaller one must trade nothing instead.
+	MinQty float64
 }
 
-// MatchAll crosses bids and asks product by product and calls execute
-// for each match. Bids are served in descending price order (ties by
-// posting order); each bid takes the ask with the lowest per-unit
-// delivered cost (ask price plus per-unit transport) it can cross.
-// execute returns the quantity actually traded (the state layer clamps
-// by seller stock and buyer budget): 0 or an error skips that ask for
-// this bid; a partial execution ends this bid's shopping (its budget is
-// exhausted).
+// MatchAll crosses bids and asks product by product with the book's
+// Matcher (see SetMatcher) and calls execute for each match. execute
+// returns the quantity actually traded (the state layer clamps by seller
+// stock and buyer budget): 0 or an error skips that ask for this bid; a
+// partial execution ends this bid's shopping (its budget is exhausted).
 func (b *Book) MatchAll(unitTransport func(origin, destination point.Point) float64, execute func(Match) (float64, error)) {
 	for _, product := range b.Products() {
-		bids := make([]*Bid, len(b.bids[product]))
-		copy(bids, b.bids[product])
-		sort.SliceStable(bids, func(i, j int) bool {
-			return bids[i].UnitPrice > bids[j].UnitPrice
-		})
-		for _, bid := range bids {
-			skipped := make(map[*Ask]bool)
-			for bid.Remaining > production.RateEpsilon {
-				ask, qty, unitCost := b.bestDeliveredAsk(product, bid, skipped, unitTransport)
-				if ask == nil || bid.UnitPrice < unitCost {
-					break
-				}
-				m := Match{
-					Seller:        ask.Seller,
-					Buyer:         bid.Buyer,
-					Order:         production.Production{Name: product, Rate: qty},
-					UnitPrice:     ask.UnitPrice,
-					UnitTransport: unitTransport(ask.Seller.Location(), bid.Buyer.Location()),
-				}
-				executed, err := execute(m)
-				if err != nil || executed <= production.RateEpsilon {
-					skipped[ask] = true
-					continue
-				}
-				ask.Remaining -= executed
-				bid.Remaining -= executed
-				if executed < qty-production.RateEpsilon {
-					// Partial fill: the buyer ran out of money; further
-					// asks are unaffordable too this tick.
-					break
-				}
-			}
+		b.MatchProduct(product, unitTransport, execute)
+	}
+}
+
+// MatchProduct crosses the bids and asks of one product, as MatchAll
+// does for each. It touches only that product's orders, so distinct
+// products may be matched concurrently when their execute callbacks do
+// not share state.
+func (b *Book) MatchProduct(product string, unitTransport func(origin, destination point.Point) float64, execute func(Match) (float64, error)) {
+	b.settle()
+	matcher := b.matcher
+	if matcher == nil {
+		matcher = DefaultMatcher
+	}
+	matcher.Match(b, product, unitTransport, execute)
+}
+
+// serveBid lets one bid shop: find returns the ask it should buy from
+// next (nil when none remains), the candidate quantity and the per-unit
+// delivered cost the bid must cover; price sets the unit price of the
+// trade given the per-unit transport.
+func serveBid(
+	bid *Bid,
+	find func(skipped map[*Ask]bool) (*Ask, float64, float64),
+	price func(ask *Ask, unitTransport float64) float64,
+	unitTransport func(point.Point, point.Point) float64,
+	execute func(Match) (float64, error),
+) {
+	skipped := make(map[*Ask]bool)
+	for bid.Remaining > production.RateEpsilon {
+		ask, qty, unitCost := find(skipped)
+		if ask == nil || bid.UnitPrice < unitCost {
+			return
+		}
+		transport := unitTransport(ask.Seller.Location(), bid.Buyer.Location())
+		m := Match{
+			Seller:        ask.Seller,
+			Buyer:         bid.Buyer,
+			Order:         production.Production{Name: bid.Product, Rate: qty},
+			UnitPrice:     price(ask, transport),
+			UnitTransport: transport,
+			MinQty:        minFill(bid, ask),
+		}
+		executed, err := execute(m)
+		if err != nil || executed <= production.RateEpsilon {
+			skipped[ask] = true
+			continue
+		}
+		ask.Remaining -= executed
+		bid.Remaining -= executed
+		if executed < qty-production.RateEpsilon {
+			// Partial fill: the buyer ran out of money; further
+			// asks are unaffordable too this tick.
+			return
 		}
 	}
 }
@@ -76,8 +97,8 @@ func (b *Book) bestDeliveredAsk(
 ) (*Ask, float64, float64) {
 	var best *Ask
 	var bestQty, bestCost float64
-	for _, ask := range b.asks[product] {
-		if skipped[ask] || ask.Remaining <= production.RateEpsilon || ask.Seller == bid.Buyer {
+	for _, ask := range b.Asks(product) {
+		if skipped[ask] || ask.Remaining <= production.RateEpsilon || ask.Seller == bid.Buyer || !crossable(bid, ask) {
 			continue
 		}
 		qty := math.Min(bid.Remaining, ask.Remaining)
@@ -88,3 +109,21 @@ func (b *Book) bestDeliveredAsk(
 	}
 	return best, bestQty, bestCost
 }
+
+// minFill is the smallest fill the terms of both orders accept.
+func minFill(bid *Bid, ask *Ask) float64 {
+	need := math.Max(bid.Terms.MinQty, ask.Terms.MinQty)
+	if bid.Terms.FillOrKill {
+		need = math.Max(need, bid.Remaining)
+	}
+	if ask.Terms.FillOrKill {
+		need = math.Max(need, ask.Remaining)
+	}
+	return need
+}
+
+// crossable reports whether the pair's available quantity meets both
+// orders' terms.
+func crossable(bid *Bid, ask *Ask) bool {
+	return math.Min(bid.Remaining, ask.Remaining) >= minFill(bid, ask)-production.RateEpsilon
+}
@@ -0,0 +1,173 @@
+package market
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// Matcher is a market mechanism: it crosses one product's bids and asks
+// and prices each match. Implementations serve bids through execute with
+// the semantics documented on MatchAll.
+type Matcher interface {
+	Match(b *Book, product string, unitTransport func(origin, destination point.Point) float64, execute func(Match) (float64, error))
+}
+
+// MatcherKind names a built-in Matcher, for configuration.
+type MatcherKind string
+
+const (
+	// MatchAskPrice: continuous matching at the ask price (a k-double
+	// auction with k = 0). The default.
+	MatchAskPrice MatcherKind = "askPrice"
+	// MatchKDouble: continuous matching with the price splitting the
+	// pair's surplus by k.
+	MatchKDouble MatcherKind = "kDouble"
+	// MatchCallAuction: a uniform-price call auction per product.
+	MatchCallAuction MatcherKind = "callAuction"
+)
+
+// DefaultMatcher is the mechanism a book uses until SetMatcher.
+var DefaultMatcher Matcher = KDoubleAuction{K: 0}
+
+// NewMatcher returns the built-in Matcher of the given kind. k is the
+// surplus split of MatchKDouble and is ignored otherwise.
+func NewMatcher(kind MatcherKind, k float64) (Matcher, error) {
+	switch kind {
+	case MatchAskPrice, "":
+		return KDoubleAuction{K: 0}, nil
+	case MatchKDouble:
+		if k < 0 || k > 1 {
+			return nil, fmt.Errorf("k-double auction k = %v, want within [0, 1]", k)
+		}
+		return KDoubleAuction{K: k}, nil
+	case MatchCallAuction:
+		return CallAuction{}, nil
+	default:
+		return nil, fmt.Errorf("unknown matcher %q", kind)
+	}
+}
+
+// SetMatcher selects the mechanism MatchAll and MatchProduct use.
+func (b *Book) SetMatcher(m Matcher) {
+	b.matcher = m
+}
+
+// KDoubleAuction matches continuously: bids are served in descending
+// price order (ties by posting order), each taking the ask with the
+// lowest per-unit delivered cost (ask price plus per-unit transport) it
+// can cross. Each trade is priced inside the pair's surplus: the ask
+// price plus K times what the bid would pay beyond ask and freight. K = 0
+// executes at the ask, K = 1 at the bid net of freight. With a transport
+// floor set (see SetTransportFloor), large ask sets are searched through
+// a spatial index.
+type KDoubleAuction struct {
+	K float64
+}
+
+func (m KDoubleAuction) Match(b *Book, product string, unitTransport func(origin, destination point.Point) float64, execute func(Match) (float64, error)) {
+	bids, ok := b.bids[product]
+	if !ok {
+		return
+	}
+	asks := b.Asks(product)
+	var grid *askGrid
+	if b.transportFloor != nil && len(asks) >= spatialIndexMinAsks {
+		grid = newAskGrid(asks, b.transportFloor)
+	}
+	for _, bid := range bids.byPrice {
+		find := func(skipped map[*Ask]bool) (*Ask, float64, float64) {
+			if grid != nil {
+				return grid.bestDeliveredAsk(bid, skipped, unitTransport)
+			}
+			return b.bestDeliveredAsk(product, bid, skipped, unitTransport)
+		}
+		price := func(ask *Ask, transport float64) float64 {
+			return ask.UnitPrice + m.K*(bid.UnitPrice-transport-ask.UnitPrice)
+		}
+		serveBid(bid, find, price, unitTransport, execute)
+	}
+}
+
+// CallAuction clears each product at one uniform price. Quoted bids and
+// asks are crossed best-first, ignoring freight, until they no longer
+// meet; the clearing price is the midpoint of the last crossing pair.
+// Bids at or above that price are then served in descending price order,
+// each buying from the asks at or below it with the cheapest freight,
+// for as long as the clearing price plus freight stays within the bid.
+// Every trade executes at the clearing price.
+type CallAuction struct{}
+
+func (CallAuction) Match(b *Book, product string, unitTransport func(origin, destination point.Point) float64, execute func(Match) (float64, error)) {
+	bids, ok := b.bids[product]
+	if !ok {
+		return
+	}
+	asks, ok := b.asks[product]
+	if !ok {
+		return
+	}
+	clearing, ok := clearingPrice(bids.byPrice, asks.byPrice)
+	if !ok {
+		return
+	}
+	for _, bid := range bids.byPrice {
+		if bid.UnitPrice < clearing {
+			break
+		}
+		find := func(skipped map[*Ask]bool) (*Ask, float64, float64) {
+			var best *Ask
+			var bestTransport float64
+			for _, ask := range asks.live {
+				if ask.UnitPrice > clearing || skipped[ask] ||
+					ask.Remaining <= production.RateEpsilon || ask.Seller == bid.Buyer || !crossable(bid, ask) {
+					continue
+				}
+				transport := unitTransport(ask.Seller.Location(), bid.Buyer.Location())
+				if best == nil || transport < bestTransport {
+					best, bestTransport = ask, transport
+				}
+			}
+			if best == nil {
+				return nil, 0, 0
+			}
+			return best, math.Min(bid.Remaining, best.Remaining), clearing + bestTransport
+		}
+		price := func(*Ask, float64) float64 { return clearing }
+		serveBid(bid, find, price, unitTransport, execute)
+	}
+}
+
+// clearingPrice walks bids (best first) against asks (best first),
+// crossing remaining quantities while the bid price covers the ask
+// price, and returns the midpoint of the last pair crossed.
+func clearingPrice(bids []*Bid, asks []*Ask) (float64, bool) {
+	i, j := 0, 0
+	var bidLeft, askLeft float64
+	crossed := false
+	var lastBid, lastAsk float64
+	for {
+		for bidLeft <= production.RateEpsilon && i < len(bids) {
+			bidLeft = bids[i].Remaining
+			i++
+		}
+		for askLeft <= production.RateEpsilon && j < len(asks) {
+			askLeft = asks[j].Remaining
+			j++
+		}
+		if bidLeft <= production.RateEpsilon || askLeft <= production.RateEpsilon {
+			break
+		}
+		bid, ask := bids[i-1], asks[j-1]
+		if bid.UnitPrice < ask.UnitPrice {
+			break
+		}
+		crossed, lastBid, lastAsk = true, bid.UnitPrice, ask.UnitPrice
+		q := math.Min(bidLeft, askLeft)
+		bidLeft -= q
+		askLeft -= q
+	}
+	return (lastBid + lastAsk) / 2, crossed
+}
@@ -0,0 +1,72 @@
+package market
+
+import "testing"
+
+func Test_KDoubleAuction_splitsSurplus(t *testing.T) {
+	b := NewBook()
+	b.SetMatcher(KDoubleAuction{K: 0.5})
+	b.PostAsk(testProducer(0, 0), "Ingot", 5, 2.0)
+	b.PostBid(testProducer(10, 10), "Ingot", 5, 3.2)
+
+	var matches []Match
+	b.MatchAll(flatTransport, collectMatches(&matches))
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+	// Surplus is 3.2 - 0.2 - 2.0 = 1.0; half of it goes to the seller.
+	if got := matches[0].UnitPrice; got < 2.5-1e-12 || got > 2.5+1e-12 {
+		t.Fatalf("unit price = %v, want 2.5", got)
+	}
+}
+
+func Test_CallAuction_clearsAtUniformPrice(t *testing.T) {
+	b := NewBook()
+	b.SetMatcher(CallAuction{})
+	cheap, dear := testProducer(0, 0), testProducer(0, 50)
+	eager, reluctant := testProducer(10, 10), testProducer(20, 20)
+	b.PostAsk(cheap, "Ingot", 10, 1.0)
+	b.PostAsk(dear, "Ingot", 10, 4.0)
+	b.PostBid(reluctant, "Ingot", 10, 3.0)
+	b.PostBid(eager, "Ingot", 10, 5.0)
+
+	var matches []Match
+	b.MatchAll(flatTransport, collectMatches(&matches))
+
+	// 5.0 crosses 1.0 for all 10 units; 3.0 does not cross 4.0, so the
+	// clearing price is (5 + 1) / 2. The 3.0 bid cannot cover 3 + 0.2
+	// freight, and the 4.0 ask is above the clearing price.
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %+v", matches)
+	}
+	m := matches[0]
+	if m.Seller != cheap || m.Buyer != eager || m.Order.Rate != 10 || m.UnitPrice != 3.0 {
+		t.Fatalf("match = %+v, want 10 units cheap->eager at 3.0", m)
+	}
+}
+
+func Test_CallAuction_noCrossNoTrade(t *testing.T) {
+	b := NewBook()
+	b.SetMatcher(CallAuction{})
+	b.PostAsk(testProducer(0, 0), "Ingot", 10, 4.0)
+	b.PostBid(testProducer(10, 10), "Ingot", 10, 3.0)
+
+	var matches []Match
+	b.MatchAll(flatTransport, collectMatches(&matches))
+	if len(matches) != 0 {
+		t.Fatalf("expected no matches, got %d", len(matches))
+	}
+}
+
+func Test_NewMatcher(t *testing.T) {
+	for _, kind := range []MatcherKind{MatchAskPrice, MatchKDouble, MatchCallAuction} {
+		if _, err := NewMatcher(kind, 0.5); err != nil {
+			t.Errorf("NewMatcher(%q) failed: %v", kind, err)
+		}
+	}
+	if _, err := NewMatcher(MatchKDouble, 1.5); err == nil {
+		t.Error("expected k outside [0, 1] to be rejected")
+	}
+	if _, err := NewMatcher("dutch", 0); err == nil {
+		t.Error("expected an unknown matcher to be rejected")
+	}
+}
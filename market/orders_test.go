@@ -0,0 +1,104 @@
+package market
+
+import "testing"
+
+func Test_Book_goodTillCancelled_standsUntilExpiry(t *testing.T) {
+	b := NewBook()
+	seller := testProducer(0, 0)
+	b.Republish(1)
+	b.PostAskTerms(seller, "Ore", 10, 1.0, OrderTerms{Expiry: 3})
+	b.Asks("Ore")[0].Remaining = 4 // partly filled on tick 1
+
+	for tick := 2; tick <= 3; tick++ {
+		b.Republish(tick) // not reposted
+		asks := b.Asks("Ore")
+		if len(asks) != 1 || asks[0].Remaining != 4 {
+			t.Fatalf("tick %d: asks = %+v, want the GTC ask standing with 4 left", tick, asks)
+		}
+	}
+	b.Republish(4)
+	if len(b.Asks("Ore")) != 0 {
+		t.Fatal("GTC ask should be withdrawn after its expiry tick")
+	}
+}
+
+func Test_Book_CancelBids_withdrawsGTC(t *testing.T) {
+	b := NewBook()
+	buyer := testProducer(0, 0)
+	b.Republish(1)
+	b.PostBidTerms(buyer, "Ore", 10, 1.0, OrderTerms{Expiry: 100})
+	b.CancelBids(buyer, "Ore")
+	if len(b.Bids("Ore")) != 0 || len(b.Products()) != 0 {
+		t.Fatal("cancelled bid should be withdrawn at once")
+	}
+}
+
+func Test_MatchAll_fillOrKill_skipsShortAsks(t *testing.T) {
+	b := NewBook()
+	small, large := testProducer(0, 0), testProducer(0, 10)
+	buyer := testProducer(10, 10)
+	b.PostAsk(small, "Ingot", 3, 1.0) // cheapest, but cannot fill 5
+	b.PostAsk(large, "Ingot", 8, 1.5)
+	b.PostBidTerms(buyer, "Ingot", 5, 3.0, OrderTerms{FillOrKill: true})
+
+	var matches []Match
+	b.MatchAll(flatTransport, collectMatches(&matches))
+	if len(matches) != 1 || matches[0].Seller != large || matches[0].Order.Rate != 5 || matches[0].MinQty != 5 {
+		t.Fatalf("matches = %+v, want all 5 from the large ask in one fill", matches)
+	}
+}
+
+func Test_MatchAll_minQty_requiresBatches(t *testing.T) {
+	b := NewBook()
+	seller := testProducer(0, 0)
+	b.PostAskTerms(seller, "Ingot", 20, 1.0, OrderTerms{MinQty: 6})
+	b.PostBid(testProducer(10, 10), "Ingot", 5, 3.0) // too small a batch
+	b.PostBid(testProducer(20, 20), "Ingot", 7, 2.0)
+
+	var matches []Match
+	b.MatchAll(flatTransport, collectMatches(&matches))
+	if len(matches) != 1 || matches[0].Order.Rate != 7 || matches[0].MinQty != 6 {
+		t.Fatalf("matches = %+v, want only the 7-unit bid served, at a 6-unit minimum", matches)
+	}
+}
+
+func Test_Book_PostBidLadder_levelsRankByPrice(t *testing.T) {
+	b := NewBook()
+	buyer, other := testProducer(0, 0), testProducer(5, 5)
+	b.PostBidLadder(buyer, "Ore", []Level{{Rate: 2, UnitPrice: 5}, {Rate: 4, UnitPrice: 3}}, OrderTerms{})
+	b.PostBid(other, "Ore", 1, 4)
+
+	bids := b.Bids("Ore")
+	if len(bids) != 3 || bids[0].Level != 0 || bids[1].Level != 1 || bids[2].Buyer != other {
+		t.Fatalf("bids = %+v, want the ladder's rungs then the other bid, in posting order", bids)
+	}
+	best, _ := b.BestBid("Ore")
+	if best.UnitPrice != 5 {
+		t.Fatalf("best bid = %v, want the top rung at 5", best.UnitPrice)
+	}
+
+	// A shorter ladder cancels the leftover rung.
+	b.PostBidLadder(buyer, "Ore", []Level{{Rate: 6, UnitPrice: 4.5}}, OrderTerms{})
+	if bids := b.Bids("Ore"); len(bids) != 2 || bids[0].Remaining != 6 {
+		t.Fatalf("bids = %+v, want one rung of 6 and the other bid", bids)
+	}
+}
+
+func Test_Book_PostBidLadder_keepsStandingRung(t *testing.T) {
+	b := NewBook()
+	buyer := testProducer(0, 0)
+	b.Republish(1)
+	b.PostBidLadder(buyer, "Ore", []Level{{Rate: 2, UnitPrice: 5}}, OrderTerms{})
+	b.PostBidLevel(buyer, "Ore", 1, 4, 3, OrderTerms{Expiry: 2})
+
+	b.Republish(2)
+	b.PostBidLadder(buyer, "Ore", []Level{{Rate: 3, UnitPrice: 5}}, OrderTerms{})
+	if bids := b.Bids("Ore"); len(bids) != 2 || bids[1].Level != 1 || bids[1].Remaining != 4 {
+		t.Fatalf("bids = %+v, want the standing rung kept beside the reposted one", bids)
+	}
+	b.Republish(3)
+	b.PostBidLadder(buyer, "Ore", []Level{{Rate: 3, UnitPrice: 5}}, OrderTerms{})
+	if bids := b.Bids("Ore"); len(bids) != 1 {
+		t.Fatalf("bids = %+v, want the rung gone after its expiry", bids)
+	}
+}
@@ -0,0 +1,169 @@
+package market
+
+import (
+	"sort"
+
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// orderMeta is the book's bookkeeping on a standing order.
+type orderMeta struct {
+	// seq is the posting rank of the order's producer.
+	seq int
+	// pass is the publishing pass the order was last posted in; posted
+	// says whether that post had a positive quantity.
+	pass      int
+	posted    bool
+	cancelled bool
+	// indexed says whether the order is in its side's price order.
+	indexed bool
+}
+
+func (m *orderMeta) post(pass int, rate float64) {
+	m.pass, m.posted, m.cancelled = pass, rate > production.RateEpsilon, false
+}
+
+func (m *orderMeta) cancel() {
+	m.posted, m.cancelled = false, true
+}
+
+// order is what a side needs from an Ask or a Bid.
+type order interface {
+	meta() *orderMeta
+	// rank orders by price, best first: lower ranks are better.
+	rank() float64
+	remaining() float64
+	level() int
+	// standsThrough is the good-till-cancelled expiry tick, 0 if none.
+	standsThrough() int
+}
+
+func (a *Ask) meta() *orderMeta   { return &a.orderMeta }
+func (a *Ask) rank() float64      { return a.UnitPrice }
+func (a *Ask) remaining() float64 { return a.Remaining }
+func (a *Ask) level() int         { return a.Level }
+func (a *Ask) standsThrough() int { return a.Terms.Expiry }
+func (b *Bid) meta() *orderMeta   { return &b.orderMeta }
+func (b *Bid) rank() float64      { return -b.UnitPrice }
+func (b *Bid) remaining() float64 { return b.Remaining }
+func (b *Bid) level() int         { return b.Level }
+func (b *Bid) standsThrough() int { return b.Terms.Expiry }
+
+// side is one product's asks or bids.
+type side[O order] struct {
+	// all holds every order, posted or not, by seq.
+	all []O
+	// live holds the orders posted with a positive quantity, by seq.
+	live []O
+	// byPrice holds the live orders best price first, ties by seq.
+	byPrice []O
+	// next indexes the first order in byPrice that may be unfilled.
+	// Fills only shrink Remaining between settles, so it only advances.
+	next int
+}
+
+// insert adds a new order at its posting position: by seq, then level.
+func (s *side[O]) insert(o O) {
+	i := sort.Search(len(s.all), func(i int) bool { return postedBefore(o, s.all[i]) })
+	var zero O
+	s.all = append(s.all, zero)
+	copy(s.all[i+1:], s.all[i:])
+	s.all[i] = o
+}
+
+// settle rebuilds live and byPrice, first dropping cancelled orders
+// and orders neither posted in pass nor good-till-cancelled through tick
+// when sweep is set. It returns the dropped orders.
+func (s *side[O]) settle(pass, tick int, sweep bool) []O {
+	var dropped []O
+	if sweep {
+		kept := s.all[:0]
+		for _, o := range s.all {
+			m := o.meta()
+			standing := o.standsThrough() > 0 && o.standsThrough() >= tick
+			if !m.cancelled && (m.pass == pass || standing) {
+				kept = append(kept, o)
+			} else {
+				m.posted = false
+				dropped = append(dropped, o)
+			}
+		}
+		clear(s.all[len(kept):])
+		s.all = kept
+	}
+
+	s.live = s.live[:0]
+	for _, o := range s.all {
+		if o.meta().posted {
+			s.live = append(s.live, o)
+		}
+	}
+
+	// Prices drift a few percent per tick, so last settle's price order
+	// is nearly right: keep it, append newcomers and insertion-sort.
+	indexed := s.byPrice[:0]
+	for _, o := range s.byPrice {
+		if m := o.meta(); m.posted {
+			indexed = append(indexed, o)
+		} else {
+			m.indexed = false
+		}
+	}
+	clear(s.byPrice[len(indexed):])
+	for _, o := range s.live {
+		if m := o.meta(); !m.indexed {
+			m.indexed = true
+			indexed = append(indexed, o)
+		}
+	}
+	s.byPrice = indexed
+	sortByPrice(s.byPrice)
+	s.next = 0
+	return dropped
+}
+
+// best returns the best-priced unfilled order, advancing next past the
+// filled ones, so it is not safe for concurrent use.
+func (s *side[O]) best() (O, bool) {
+	for s.next < len(s.byPrice) && s.byPrice[s.next].remaining() <= production.RateEpsilon {
+		s.next++
+	}
+	if s.next == len(s.byPrice) {
+		var zero O
+		return zero, false
+	}
+	return s.byPrice[s.next], true
+}
+
+// postedBefore orders by posting: producer rank, then ladder level.
+func postedBefore[O order](a, b O) bool {
+	if sa, sb := a.meta().seq, b.meta().seq; sa != sb {
+		return sa < sb
+	}
+	return a.level() < b.level()
+}
+
+func priceLess[O order](a, b O) bool {
+	if ra, rb := a.rank(), b.rank(); ra != rb {
+		return ra < rb
+	}
+	return postedBefore(a, b)
+}
+
+// sortByPrice insertion-sorts nearly ordered orders, falling back to a
+// full sort once the shifting shows they are far from ordered. Ranks
+// with the posting tie-break are a strict order, so both give one
+// result.
+func sortByPrice[O order](orders []O) {
+	budget := 8 * len(orders)
+	for i := 1; i < len(orders); i++ {
+		for j := i; j > 0 && priceLess(orders[j], orders[j-1]); j-- {
+			orders[j], orders[j-1] = orders[j-1], orders[j]
+			budget--
+		}
+		if budget < 0 {
+			sort.Slice(orders, func(i, j int) bool { return priceLess(orders[i], orders[j]) })
+			return
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package market
+
+import (
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// spatialIndexMinAsks is the smallest ask count for which MatchAll builds
+// a spatial index; below it a linear scan is cheaper than the build.
+const spatialIndexMinAsks = 64
+
+// spatialTargetPerCell is the average number of asks per grid cell the
+// index is sized for.
+const spatialTargetPerCell = 4
+
+// SetTransportFloor lets MatchAll find each bid's cheapest delivered ask
+// through a spatial index instead of scanning every ask. floor must
+// return, for a distance d, a lower bound on the unit transport cost
+// over any two points d apart, and must not decrease as d grows. A nil
+// floor (the default) keeps the linear scan. Results are identical
+// either way; the floor only lets the index prune cells that cannot
+// beat the best ask found so far.
+func (b *Book) SetTransportFloor(floor func(distance float64) float64) {
+	b.transportFloor = floor
+}
+
+// askGrid buckets one product's asks into square cells over their
+// bounding box. Each cell keeps its asks in posting order, along with
+// the lowest unit price among them.
+type askGrid struct {
+	asks     []*Ask
+	minX     int
+	minY     int
+	cellSize int
+	nx, ny   int
+	cells    [][]int // indices into asks
+	minPrice []float64
+	floor    func(float64) float64
+	lowest   float64
+}
+
+func newAskGrid(asks []*Ask, floor func(float64) float64) *askGrid {
+	g := &askGrid{asks: asks, floor: floor, lowest: math.Inf(1)}
+	minX, minY := math.MaxInt, math.MaxInt
+	maxX, maxY := math.MinInt, math.MinInt
+	for _, ask := range asks {
+		loc := ask.Seller.Location()
+		minX, maxX = min(minX, loc.X), max(maxX, loc.X)
+		minY, maxY = min(minY, loc.Y), max(maxY, loc.Y)
+		g.lowest = math.Min(g.lowest, ask.UnitPrice)
+	}
+	width, height := maxX-minX+1, maxY-minY+1
+	cells := float64(len(asks)) / spatialTargetPerCell
+	g.cellSize = max(1, int(math.Ceil(math.Sqrt(float64(width)*float64(height)/cells))))
+	g.minX, g.minY = minX, minY
+	g.nx = (width + g.cellSize - 1) / g.cellSize
+	g.ny = (height + g.cellSize - 1) / g.cellSize
+	g.cells = make([][]int, g.nx*g.ny)
+	g.minPrice = make([]float64, g.nx*g.ny)
+	for i := range g.minPrice {
+		g.minPrice[i] = math.Inf(1)
+	}
+	for i, ask := range asks {
+		loc := ask.Seller.Location()
+		c := (loc.Y-minY)/g.cellSize*g.nx + (loc.X-minX)/g.cellSize
+		g.cells[c] = append(g.cells[c], i)
+		g.minPrice[c] = math.Min(g.minPrice[c], ask.UnitPrice)
+	}
+	return g
+}
+
+// cellDistance is the distance from q to the nearest point of cell
+// (cx, cy). Cell bounds are integral, so the nearest point is a lattice
+// point and the distance is computed exactly as for any ask inside.
+func (g *askGrid) cellDistance(q point.Point, cx, cy int) float64 {
+	x0 := g.minX + cx*g.cellSize
+	y0 := g.minY + cy*g.cellSize
+	nearest := point.Point{
+		X: min(max(q.X, x0), x0+g.cellSize-1),
+		Y: min(max(q.Y, y0), y0+g.cellSize-1),
+	}
+	return q.Distance(nearest)
+}
+
+// bestDeliveredAsk is the indexed equivalent of Book.bestDeliveredAsk.
+// Cells are visited in rings of growing Chebyshev distance around the
+// cell nearest the buyer; the search stops once no further ring can
+// reach the best delivered cost found. Ties on cost go to the earlier
+// posted ask, as in the linear scan.
+func (g *askGrid) bestDeliveredAsk(
+	bid *Bid,
+	skipped map[*Ask]bool,
+	unitTransport func(point.Point, point.Point) float64,
+) (*Ask, float64, float64) {
+	q := bid.Buyer.Location()
+	cx := min(max((q.X-g.minX)/g.cellSize, 0), g.nx-1)
+	cy := min(max((q.Y-g.minY)/g.cellSize, 0), g.ny-1)
+	rings := max(cx, g.nx-1-cx, cy, g.ny-1-cy)
+
+	best := -1
+	var bestQty, bestCost float64
+	visit := func(x, y int) {
+		c := y*g.nx + x
+		if len(g.cells[c]) == 0 {
+			return
+		}
+		if best >= 0 && g.minPrice[c]+g.floor(g.cellDistance(q, x, y)) > bestCost {
+			return
+		}
+		for _, i := range g.cells[c] {
+			ask := g.asks[i]
+			if skipped[ask] || ask.Remaining <= production.RateEpsilon || ask.Seller == bid.Buyer || !crossable(bid, ask) {
+				continue
+			}
+			unitCost := ask.UnitPrice + unitTransport(ask.Seller.Location(), q)
+			if best < 0 || unitCost < bestCost || (unitCost == bestCost && i < best) {
+				best, bestQty, bestCost = i, math.Min(bid.Remaining, ask.Remaining), unitCost
+			}
+		}
+	}
+	for r := 0; r <= rings; r++ {
+		// Every cell in ring r is at least r-1 whole cells from the
+		// buyer's (clamped) position.
+		if best >= 0 && r > 0 && g.lowest+g.floor(float64((r-1)*g.cellSize)) > bestCost {
+			break
+		}
+		for x := cx - r; x <= cx+r; x++ {
+			if x < 0 || x >= g.nx {
+				continue
+			}
+			if cy-r >= 0 {
+				visit(x, cy-r)
+			}
+			if r > 0 && cy+r < g.ny {
+				visit(x, cy+r)
+			}
+		}
+		for y := cy - r + 1; y <= cy+r-1; y++ {
+			if y < 0 || y >= g.ny {
+				continue
+			}
+			if cx-r >= 0 {
+				visit(cx-r, y)
+			}
+			if r > 0 && cx+r < g.nx {
+				visit(cx+r, y)
+			}
+		}
+	}
+	if best < 0 {
+		return nil, 0, 0
+	}
+	return g.asks[best], bestQty, bestCost
+}
@@ -0,0 +1,113 @@
+package market
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// distanceTransport mirrors the shape of recipes.UnitTransportCost: a
+// fixed charge plus a per-distance charge.
+func distanceTransport(origin, destination point.Point) float64 {
+	return distanceFloor(origin.Distance(destination))
+}
+
+func distanceFloor(d float64) float64 { return 0.1 + d/10000 }
+
+// populate posts the same random world into every book: clustered asks
+// with shared locations and coarse prices (so delivered-cost ties are
+// common), bids inside and outside the asks' bounding box, and some
+// producers both buying and selling.
+func populate(seed int64, asks, bids int, books ...*Book) {
+	r := rand.New(rand.NewSource(seed))
+	prices := []float64{1, 1.5, 2, 2.5}
+	sellers := make([]production.Producer, 0, asks)
+	for i := 0; i < asks; i++ {
+		var seller production.Producer
+		if i > 0 && r.Intn(10) == 0 {
+			loc := sellers[r.Intn(len(sellers))].Location()
+			seller = testProducer(loc.X, loc.Y)
+		} else {
+			seller = testProducer(r.Intn(200000)-100000, r.Intn(200000)-100000)
+		}
+		sellers = append(sellers, seller)
+		rate, price := float64(1+r.Intn(20)), prices[r.Intn(len(prices))]
+		for _, b := range books {
+			b.PostAsk(seller, "Ore", rate, price)
+		}
+	}
+	for i := 0; i < bids; i++ {
+		var buyer production.Producer
+		switch r.Intn(5) {
+		case 0:
+			buyer = sellers[r.Intn(len(sellers))]
+		case 1:
+			buyer = testProducer(r.Intn(1000000)-500000, r.Intn(1000000)-500000)
+		default:
+			buyer = testProducer(r.Intn(200000)-100000, r.Intn(200000)-100000)
+		}
+		rate, price := float64(1+r.Intn(60)), 1+r.Float64()*40
+		for _, b := range books {
+			b.PostBid(buyer, "Ore", rate, price)
+		}
+	}
+}
+
+// fussyExecute fills most matches in full, refuses some and partially
+// fills others, deterministically by call count.
+func fussyExecute(matches *[]Match) func(Match) (float64, error) {
+	calls := 0
+	return func(m Match) (float64, error) {
+		calls++
+		*matches = append(*matches, m)
+		switch calls % 11 {
+		case 3:
+			return 0, errors.New("refused")
+		case 7:
+			return m.Order.Rate / 2, nil
+		}
+		return m.Order.Rate, nil
+	}
+}
+
+func Test_MatchAll_spatialIndexMatchesLinearScan(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		linear, indexed := NewBook(), NewBook()
+		indexed.SetTransportFloor(distanceFloor)
+		populate(seed, 300, 120, linear, indexed)
+
+		var want, got []Match
+		linear.MatchAll(distanceTransport, fussyExecute(&want))
+		indexed.MatchAll(distanceTransport, fussyExecute(&got))
+
+		if len(got) != len(want) {
+			t.Fatalf("seed %d: %d matches indexed, %d linear", seed, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("seed %d: match %d = %+v indexed, %+v linear", seed, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func benchmarkMatchAll(b *testing.B, asks, bids int, indexed bool) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		book := NewBook()
+		if indexed {
+			book.SetTransportFloor(distanceFloor)
+		}
+		populate(1, asks, bids, book)
+		b.StartTimer()
+		book.MatchAll(distanceTransport, func(m Match) (float64, error) { return m.Order.Rate, nil })
+	}
+}
+
+func BenchmarkMatchAll_linear_2000asks(b *testing.B)  { benchmarkMatchAll(b, 2000, 500, false) }
+func BenchmarkMatchAll_indexed_2000asks(b *testing.B) { benchmarkMatchAll(b, 2000, 500, true) }
+func BenchmarkMatchAll_linear_200asks(b *testing.B)   { benchmarkMatchAll(b, 200, 50, false) }
+func BenchmarkMatchAll_indexed_200asks(b *testing.B)  { benchmarkMatchAll(b, 200, 50, true) }
@@ -0,0 +1,142 @@
+package marketmaker
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// inventorySkewPct is how far a dealer moves a product's mid price per
+// tick when its inventory sits a full target away from the target: up
+// while it is short (bid harder to attract sellers), down while it is
+// long (ask lower to move stock). Matches the factories' bid escalation.
+const inventorySkewPct = 0.02
+
+// MarketMaker is a dealer at a central depot. For each product it
+// stocks it quotes both sides around a mid price -- buying below it
+// towards a target inventory, selling what it holds above it -- and
+// earns the spread. It gives thin products a standing counterparty, so
+// a buyer need not wait for a producer-to-producer match.
+type MarketMaker struct {
+	Name string
+	Loc  point.Point
+	// Stocked lists the products it makes a market in; Rate is the
+	// target inventory in units.
+	Stocked   production.Products
+	Inventory production.Inventory
+	// Incoming holds goods bought and still in transit.
+	Incoming production.Inventory
+	// SpreadPct is the full bid/ask spread as a share of the mid.
+	SpreadPct float64
+	// Mids is the standing mid price per product.
+	Mids map[string]float64
+	// MaxMid caps every mid: Skew never raises one above it, however
+	// long the dealer stays short. Zero means no cap.
+	MaxMid float64
+
+	production.Wallet
+}
+
+func New(
+	name string,
+	loc point.Point,
+	stocked production.Products,
+	spreadPct float64,
+	seed float64,
+) *MarketMaker {
+	return &MarketMaker{
+		Name:      name,
+		Loc:       loc,
+		Stocked:   stocked,
+		Inventory: make(production.Inventory),
+		Incoming:  make(production.Inventory),
+		SpreadPct: spreadPct,
+		Mids:      make(map[string]float64),
+		Wallet:    production.NewWallet(seed),
+	}
+}
+
+// Location implements producer.
+func (mm *MarketMaker) Location() point.Point {
+	return mm.Loc
+}
+
+// Products implements producer.
+func (mm *MarketMaker) Products() production.Products {
+	return mm.Stocked
+}
+
+// String implements producer.
+func (mm *MarketMaker) String() string {
+	return fmt.Sprintf("%s [%s]", mm.Name, mm.Stocked.Key())
+}
+
+// Mid returns the standing mid price for the named product, defaulting
+// on first quote.
+func (mm *MarketMaker) Mid(name string) float64 {
+	mid, ok := mm.Mids[name]
+	if !ok {
+		mid = production.DefaultUnitPrice
+		mm.Mids[name] = mid
+	}
+	return mid
+}
+
+// AskPrice is the price the dealer sells at: half the spread above the
+// mid.
+func (mm *MarketMaker) AskPrice(name string) float64 {
+	return mm.Mid(name) * (1 + mm.SpreadPct/2)
+}
+
+// BidPrice is the price the dealer buys at: half the spread below the
+// mid.
+func (mm *MarketMaker) BidPrice(name string) float64 {
+	return mm.Mid(name) * (1 - mm.SpreadPct/2)
+}
+
+// Shortfall is how many units the dealer wants to buy to reach its
+// target inventory of the named product, counting what is in transit.
+func (mm *MarketMaker) Shortfall(name string) float64 {
+	for _, p := range mm.Stocked {
+		if p.Name == name {
+			return math.Max(0, p.Rate-mm.Inventory.Get(name)-mm.Incoming.Get(name))
+		}
+	}
+	return 0
+}
+
+// Expect records qty units of the named product as bought and in
+// transit.
+func (mm *MarketMaker) Expect(name string, qty float64) {
+	mm.Incoming.Add(name, qty)
+}
+
+// Receive moves qty units of the named product from in transit into
+// inventory.
+func (mm *MarketMaker) Receive(name string, qty float64) {
+	mm.Incoming.Take(name, qty)
+	mm.Inventory.Add(name, qty)
+}
+
+// Skew moves each product's mid against its inventory imbalance, goods
+// in transit included: up in proportion to a shortfall, down in
+// proportion to an excess, floored at production.MinUnitPrice and
+// capped at MaxMid.
+func (mm *MarketMaker) Skew() {
+	for _, p := range mm.Stocked {
+		if p.Rate <= production.RateEpsilon {
+			continue
+		}
+		imbalance := (p.Rate - mm.Inventory.Get(p.Name) - mm.Incoming.Get(p.Name)) / p.Rate
+		imbalance = math.Max(-1, math.Min(1, imbalance))
+		mid := math.Max(production.MinUnitPrice, mm.Mid(p.Name)*(1+inventorySkewPct*imbalance))
+		if mm.MaxMid > 0 {
+			mid = math.Min(mid, mm.MaxMid)
+		}
+		mm.Mids[p.Name] = mid
+	}
+}
+
+var _ production.Producer = (*MarketMaker)(nil)
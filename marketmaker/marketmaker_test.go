@@ -0,0 +1,52 @@
+package marketmaker
+
+import (
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+func Test_MarketMaker_quotesAndSkew(t *testing.T) {
+	mm := New("Depot", point.Point{X: 0, Y: 0}, production.Products{
+		{Name: "ModularFrameHeavy", Rate: 10},
+	}, 0.2, 1000)
+	if bid, ask := mm.BidPrice("ModularFrameHeavy"), mm.AskPrice("ModularFrameHeavy"); bid != 0.9 || ask != 1.1 {
+		t.Fatalf("quotes = %v/%v, want 0.9/1.1 around the default mid", bid, ask)
+	}
+	if got := mm.Shortfall("ModularFrameHeavy"); got != 10 {
+		t.Fatalf("empty Shortfall = %v, want the whole target", got)
+	}
+
+	// Short: the mid climbs to attract sellers.
+	mm.Skew()
+	if mid := mm.Mid("ModularFrameHeavy"); mid <= 1 {
+		t.Fatalf("mid = %v after skewing short, want above 1", mid)
+	}
+
+	// Long: the mid falls to move stock.
+	mm.Inventory.Add("ModularFrameHeavy", 30)
+	before := mm.Mid("ModularFrameHeavy")
+	mm.Skew()
+	if mid := mm.Mid("ModularFrameHeavy"); mid >= before {
+		t.Fatalf("mid = %v after skewing long, want below %v", mid, before)
+	}
+	if got := mm.Shortfall("ModularFrameHeavy"); got != 0 {
+		t.Fatalf("long Shortfall = %v, want 0", got)
+	}
+}
+
+func Test_MarketMaker_Skew_capsAtMaxMid(t *testing.T) {
+	mm := New("Depot", point.Point{X: 0, Y: 0}, production.Products{
+		{Name: "ModularFrameHeavy", Rate: 10},
+	}, 0.2, 1000)
+	mm.MaxMid = 1.5
+
+	// Short forever: the mid climbs only as far as the cap.
+	for i := 0; i < 1000; i++ {
+		mm.Skew()
+	}
+	if mid := mm.Mid("ModularFrameHeavy"); mid != 1.5 {
+		t.Fatalf("mid = %v after skewing short for 1000 ticks, want the 1.5 cap", mid)
+	}
+}
@@ -6,8 +6,8 @@ import (
 )
 
 type Point struct {
-	X int
-	Y int
+	X int `json:"x"`
+	Y int `json:"y"`
 }
 
 func (p Point) String() string {
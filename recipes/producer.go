@@ -30,6 +30,32 @@ func (p Producer) String() string {
 	return string(p)
 }
 
+// footprints are the buildings' floor areas in square metres, from their
+// in-game dimensions.
+var footprints = map[Producer]float64{
+	Smelter:      6 * 9,
+	Constructor:  8 * 10,
+	Assembler:    10 * 15,
+	Foundry:      10 * 9,
+	Refinery:     10 * 20,
+	Packager:     8 * 8,
+	Manufacturer: 18 * 20,
+	Blender:      18 * 16,
+	Collider:     38 * 24,
+}
+
+// defaultFootprint stands in for a building with no floor plan of its
+// own (hand-crafted recipes, or none recorded): a Constructor's.
+const defaultFootprint = 8 * 10
+
+// Footprint is the building's floor area in square metres.
+func (p Producer) Footprint() float64 {
+	if area, ok := footprints[p]; ok {
+		return area
+	}
+	return defaultFootprint
+}
+
 func (p *Producer) UnmarshalJSON(b []byte) error {
 	if p == nil {
 		return fmt.Errorf("cannot unmarshal into nil pointer")
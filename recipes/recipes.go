@@ -172,12 +172,16 @@ const transportFixedPerUnit = 0.1
 const transportPerDistance = 1.0 / 10000.0
 
 // UnitTransportCost returns the cost of moving ONE unit of product from
-// origin to destination. Distances <= 1 keep the prohibitive collision
-// guard from TransportCost: producers must not stack on one location.
+// origin to destination. Producers never share a site -- land use keeps
+// their plots apart (see package landuse) -- so no distance needs a
+// special case.
 func UnitTransportCost(origin point.Point, destination point.Point) float64 {
-	d := origin.Distance(destination)
-	if d <= 1 {
-		return 1e12
-	}
-	return transportFixedPerUnit + d*transportPerDistance
+	return UnitTransportFloor(origin.Distance(destination))
+}
+
+// UnitTransportFloor is the UnitTransportCost of any two points distance
+// apart; routes that can only cost more (terrain, networks) use it as
+// their lower bound.
+func UnitTransportFloor(distance float64) float64 {
+	return transportFixedPerUnit + distance*transportPerDistance
 }
@@ -8,9 +8,10 @@ import (
 
 func Test_UnitTransportCost(t *testing.T) {
 	a := point.Point{X: 0, Y: 0}
-	// Same-spot collision guard survives from TransportCost.
-	if got := UnitTransportCost(a, point.Point{X: 1, Y: 0}); got != 1e12 {
-		t.Fatalf("collision guard = %v, want 1e12", got)
+	// Neighbours pay the fixed charge: land use, not freight, keeps
+	// producers apart.
+	if got := UnitTransportCost(a, point.Point{X: 1, Y: 0}); got < 0.1 || got > 0.1002 {
+		t.Fatalf("UnitTransportCost(1) = %v, want ~0.1", got)
 	}
 	// 10000 units of distance costs 0.1 fixed + 1.0 distance.
 	got := UnitTransportCost(a, point.Point{X: 10000, Y: 0})
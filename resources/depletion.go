@@ -0,0 +1,65 @@
+package resources
+
+import (
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// Depletion configures the finite-node economy. The zero value leaves
+// every node infinite, which is the baseline economy.
+type Depletion struct {
+	Enabled bool `json:"enabled"`
+	// ReserveTicks sizes each node's starting reserve as this many ticks
+	// of its reference rate, so richer nodes hold proportionally more.
+	ReserveTicks float64 `json:"reserveTicks"`
+	// DecayFloor is the fraction of its full rate a node still extracts
+	// as its reserve approaches empty; the rate falls linearly from full
+	// to this floor as the reserve drains.
+	DecayFloor float64 `json:"decayFloor"`
+}
+
+// Apply gives the node its starting reserve. It does nothing when
+// depletion is disabled.
+func (d Depletion) Apply(r *Resource) {
+	if !d.Enabled {
+		return
+	}
+	r.FullReserve = r.Production.Rate * d.ReserveTicks
+	r.Reserve = r.FullReserve
+	r.DecayFloor = d.DecayFloor
+}
+
+// Finite reports whether the node has a reserve that extraction draws
+// down.
+func (r *Resource) Finite() bool {
+	return r.FullReserve > 0
+}
+
+// Exhausted reports whether a finite node's reserve is used up. An
+// exhausted node extracts nothing and posts no asks.
+func (r *Resource) Exhausted() bool {
+	return r.Finite() && r.Reserve <= production.RateEpsilon
+}
+
+// depletionFactor scales the extraction rate by how full the reserve
+// still is: 1 for infinite nodes, falling linearly from 1 to DecayFloor
+// as a finite reserve empties.
+func (r *Resource) depletionFactor() float64 {
+	if !r.Finite() {
+		return 1
+	}
+	full := math.Max(0, math.Min(1, r.Reserve/r.FullReserve))
+	return r.DecayFloor + (1-r.DecayFloor)*full
+}
+
+// drawReserve removes up to qty units from a finite node's reserve and
+// returns how much was available.
+func (r *Resource) drawReserve(qty float64) float64 {
+	if !r.Finite() {
+		return qty
+	}
+	qty = math.Min(qty, r.Reserve)
+	r.Reserve -= qty
+	return qty
+}
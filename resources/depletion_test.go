@@ -0,0 +1,49 @@
+package resources
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+func Test_Depletion_drawsDownAndDecays(t *testing.T) {
+	r := &Resource{Production: production.Production{Name: "OreIron", Rate: 1}}
+	Depletion{Enabled: true, ReserveTicks: 4, DecayFloor: 0.5}.Apply(r)
+	if err := r.Claim(MinerMk1, OwnerCompany, 1, 0); err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	if r.Reserve != 4 || r.ExtractionRate() != 1 {
+		t.Fatalf("fresh node reserve/rate = %v/%v, want 4/1", r.Reserve, r.ExtractionRate())
+	}
+
+	r.ProduceTick(100)
+	if r.Stock != 1 || r.Reserve != 3 {
+		t.Fatalf("after 1 tick stock/reserve = %v/%v, want 1/3", r.Stock, r.Reserve)
+	}
+	// 3/4 full: 0.5 + 0.5*0.75 of the full rate.
+	if got := r.ExtractionRate(); math.Abs(got-0.875) > 1e-12 {
+		t.Fatalf("decayed rate = %v, want 0.875", got)
+	}
+
+	for i := 0; i < 20 && !r.Exhausted(); i++ {
+		r.ProduceTick(100)
+	}
+	if !r.Exhausted() {
+		t.Fatal("node should be exhausted once its reserve is drawn down")
+	}
+	if math.Abs(r.Stock-4) > 1e-9 {
+		t.Fatalf("total extracted = %v, want the full reserve of 4", r.Stock)
+	}
+	if r.ExtractionRate() != 0 {
+		t.Fatalf("exhausted rate = %v, want 0", r.ExtractionRate())
+	}
+}
+
+func Test_Depletion_disabledLeavesNodeInfinite(t *testing.T) {
+	r := &Resource{Production: production.Production{Name: "OreIron", Rate: 1}}
+	Depletion{ReserveTicks: 4, DecayFloor: 0.5}.Apply(r)
+	if r.Finite() || r.Exhausted() {
+		t.Fatal("a disabled Depletion must leave the node infinite")
+	}
+}
@@ -0,0 +1,166 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// ExtractorKind is a building that can be placed on a resource node to
+// extract its product. A node with no extractor produces nothing.
+type ExtractorKind string
+
+const (
+	MinerMk1       ExtractorKind = "MinerMk1"
+	MinerMk2       ExtractorKind = "MinerMk2"
+	MinerMk3       ExtractorKind = "MinerMk3"
+	OilExtractor   ExtractorKind = "OilExtractor"
+	WaterExtractor ExtractorKind = "WaterExtractor"
+	WellExtractor  ExtractorKind = "WellExtractor"
+)
+
+// MinClock and MaxClock bound an extractor's clock speed (1 = 100%),
+// matching the in-game overclocking range.
+const (
+	MinClock = 0.01
+	MaxClock = 2.5
+)
+
+type extractorSpec struct {
+	// tierMultiplier scales a node's reference rate (its purity rate for
+	// a Miner Mk1 at 100% clock, see New).
+	tierMultiplier float64
+	// buildCost is the one-off money cost of placing the building.
+	buildCost float64
+}
+
+// extractorSpecs follows the in-game rates relative to a Miner Mk1: Mk2
+// and Mk3 double and quadruple it, an Oil Extractor pumps 120/min from a
+// normal node (twice the 60/min reference), a Water Extractor pumps
+// 120/min, which is already the reference rate of the synthetic pure
+// water nodes, and a well extractor matches its satellite's purity rate.
+// The well extractor's cost includes its share of the pressurizer.
+var extractorSpecs = map[ExtractorKind]extractorSpec{
+	MinerMk1:       {tierMultiplier: 1, buildCost: 100},
+	MinerMk2:       {tierMultiplier: 2, buildCost: 250},
+	MinerMk3:       {tierMultiplier: 4, buildCost: 600},
+	OilExtractor:   {tierMultiplier: 2, buildCost: 300},
+	WaterExtractor: {tierMultiplier: 1, buildCost: 150},
+	WellExtractor:  {tierMultiplier: 1, buildCost: 400},
+}
+
+// BuildCost returns the one-off cost of placing this kind of extractor.
+func (k ExtractorKind) BuildCost() float64 {
+	return extractorSpecs[k].buildCost
+}
+
+// TierMultiplier returns how many times a node's reference rate this
+// kind of extractor produces at 100% clock.
+func (k ExtractorKind) TierMultiplier() float64 {
+	return extractorSpecs[k].tierMultiplier
+}
+
+// ExtractorsFor returns the extractor kinds that can be placed on a
+// surveyed (non-well) node of the named product, cheapest first.
+func ExtractorsFor(product string) []ExtractorKind {
+	switch product {
+	case "LiquidOil":
+		return []ExtractorKind{OilExtractor}
+	case "Water":
+		return []ExtractorKind{WaterExtractor}
+	default:
+		return []ExtractorKind{MinerMk1, MinerMk2, MinerMk3}
+	}
+}
+
+// ExtractorKinds returns the extractor kinds that can claim this node,
+// cheapest first.
+func (r *Resource) ExtractorKinds() []ExtractorKind {
+	if r.Well {
+		return []ExtractorKind{WellExtractor}
+	}
+	return ExtractorsFor(r.Production.Name)
+}
+
+// Owner says who holds a claimed node and so receives its sale
+// proceeds.
+type Owner string
+
+const (
+	// OwnerCompany: the extractor is its own company, with a wallet that
+	// takes the node's sales and pays upkeep.
+	OwnerCompany Owner = "company"
+	// OwnerTreasury: the node is state-held and its sales go straight to
+	// the treasury.
+	OwnerTreasury Owner = "treasury"
+)
+
+// ParseOwner returns the owner with the given name.
+func ParseOwner(name string) (Owner, error) {
+	switch owner := Owner(name); owner {
+	case OwnerCompany, OwnerTreasury:
+		return owner, nil
+	}
+	return "", fmt.Errorf("unknown node owner %q", name)
+}
+
+// Extractor is the building claiming a resource node.
+type Extractor struct {
+	Kind ExtractorKind
+	// Clock is the clock speed multiplier, 1 = 100%.
+	Clock     float64
+	BuiltTick int
+	Owner     Owner
+
+	// Wallet is the extractor company's cash. Unused when the treasury
+	// holds the node.
+	production.Wallet
+}
+
+// Claim places an extractor of the given kind, held by owner, on the
+// node. It fails if the node is locked or already claimed, the kind
+// cannot extract this node's product, or the clock is out of range.
+func (r *Resource) Claim(kind ExtractorKind, owner Owner, clock float64, tick int) error {
+	if r.Locked {
+		return fmt.Errorf("%s is locked", r.PrettyPrint())
+	}
+	if r.Extractor != nil {
+		return fmt.Errorf("%s is already claimed by a %s", r.PrettyPrint(), r.Extractor.Kind)
+	}
+	compatible := false
+	for _, k := range r.ExtractorKinds() {
+		if k == kind {
+			compatible = true
+			break
+		}
+	}
+	if !compatible {
+		return fmt.Errorf("a %s cannot extract %s", kind, r.Production.Name)
+	}
+	if clock < MinClock || clock > MaxClock {
+		return fmt.Errorf("clock %v out of range [%v, %v]", clock, MinClock, MaxClock)
+	}
+	r.Extractor = &Extractor{Kind: kind, Clock: clock, BuiltTick: tick, Owner: owner}
+	return nil
+}
+
+// Release removes the node's extractor, leaving it unclaimed. Stock
+// already extracted stays on the node.
+func (r *Resource) Release() {
+	r.Extractor = nil
+}
+
+// Claimed reports whether an extractor is placed on the node.
+func (r *Resource) Claimed() bool {
+	return r.Extractor != nil
+}
+
+// ExtractionRate is the units per tick the node currently produces:
+// reference (purity) rate x extractor tier x clock, decayed by a finite
+// node's remaining reserve, or 0 when unclaimed or exhausted.
+func (r *Resource) ExtractionRate() float64 {
+	if r.Extractor == nil || r.Exhausted() {
+		return 0
+	}
+	return r.Production.Rate * r.Extractor.Kind.TierMultiplier() * r.Extractor.Clock * r.depletionFactor()
+}
@@ -0,0 +1,46 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+func Test_Resource_ExtractionRate(t *testing.T) {
+	// Reference rate 1 unit/tick (a normal node for a Miner Mk1).
+	r := &Resource{Production: production.Production{Name: "OreIron", Rate: 1}}
+	if got := r.ExtractionRate(); got != 0 {
+		t.Fatalf("unclaimed rate = %v, want 0", got)
+	}
+	if err := r.Claim(MinerMk3, OwnerCompany, 1.5, 7); err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	// Mk3 is 4x the reference, overclocked to 150%.
+	if got := r.ExtractionRate(); got != 6 {
+		t.Fatalf("Mk3 @ 150%% rate = %v, want 6", got)
+	}
+	if r.Extractor.BuiltTick != 7 {
+		t.Fatalf("BuiltTick = %d, want 7", r.Extractor.BuiltTick)
+	}
+}
+
+func Test_Resource_Claim_rejects(t *testing.T) {
+	oil := &Resource{Production: production.Production{Name: "LiquidOil", Rate: 1}}
+	if err := oil.Claim(MinerMk1, OwnerCompany, 1, 0); err == nil {
+		t.Error("a miner must not be placeable on an oil node")
+	}
+	if err := oil.Claim(OilExtractor, OwnerCompany, MaxClock+0.1, 0); err == nil {
+		t.Error("a clock above MaxClock must be rejected")
+	}
+	oil.Locked = true
+	if err := oil.Claim(OilExtractor, OwnerCompany, 1, 0); err == nil {
+		t.Error("a locked node must not be claimable")
+	}
+	oil.Locked = false
+	if err := oil.Claim(OilExtractor, OwnerCompany, 1, 0); err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	if err := oil.Claim(OilExtractor, OwnerCompany, 1, 0); err == nil {
+		t.Error("claiming an already-claimed node must fail")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"strings"
 
+	"github.com/paul-freeman/satisfactory-story/geo"
 	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/production"
 )
@@ -29,9 +30,21 @@ type resourceData struct {
 }
 
 type Resource struct {
+	// Production.Rate is the node's reference rate: what a Miner Mk1 at
+	// 100% clock extracts at this purity. The actual rate depends on the
+	// extractor claiming the node (see ExtractionRate).
 	Production production.Production
 	Purity     purity
 	Loc        point.Point
+	// Well marks a resource-well satellite, which only a well extractor
+	// (fed by a pressurizer) can claim.
+	Well bool
+	// Locked marks a node outside the regions the playthrough has
+	// reached so far: no extractor may claim it until it is unlocked.
+	Locked bool
+	// Extractor is the building placed on the node; nil means the node
+	// is unclaimed and produces nothing.
+	Extractor *Extractor
 	// AskPrice is the persistent per-unit sale price for this node's
 	// product, adjusted by the market loop. Zero means "not yet quoted";
 	// it defaults on first use.
@@ -39,16 +52,55 @@ type Resource struct {
 	// Stock is the units of extracted product on hand, bounded by the
 	// production step's cap. Asks are backed by this.
 	Stock float64
+	// Reserve is how much product is left in the ground, out of
+	// FullReserve at the start; DecayFloor is the fraction of its rate
+	// the node keeps as it empties. All zero for infinite nodes (see
+	// Depletion).
+	Reserve     float64
+	FullReserve float64
+	DecayFloor  float64
 }
 
+// wellsJson places the nitrogen resource wells' satellites with
+// hand-made placeholder clusters, not surveyed positions: four wells,
+// each one pure satellite with two normal and two impure ones at the
+// same fixed offsets around it -- enough to give well extractors
+// something to claim, not the in-game layout.
+//
+//go:embed Wells.json
+var wellsJson []byte
+
+// geothermalName is the node ID prefix of geysers. They feed geothermal
+// generators -- power, which no recipe consumes -- so they are not
+// loaded as product nodes.
+const geothermalName = "geyser"
+
+// New loads the surveyed resource nodes and the placeholder
+// resource-well satellites (see wellsJson). Geysers are out of scope:
+// the economy does not model power, so there is no extractor kind for
+// them and they are dropped (see geothermalName).
 func New() ([]*Resource, error) {
-	var resourceData []resourceData
-	if err := json.Unmarshal(resourceJson, &resourceData); err != nil {
+	resources, err := decodeNodes(resourceJson, false)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode: %w", err)
 	}
+	wells, err := decodeNodes(wellsJson, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode wells: %w", err)
+	}
+	return append(resources, wells...), nil
+}
+
+// decodeNodes parses a SCIM-style node list. Well marks every node as a
+// resource-well satellite.
+func decodeNodes(b []byte, well bool) ([]*Resource, error) {
+	var resourceData []resourceData
+	if err := json.Unmarshal(b, &resourceData); err != nil {
+		return nil, err
+	}
 
-	resources := make([]*Resource, len(resourceData))
-	for i, data := range resourceData {
+	resources := make([]*Resource, 0, len(resourceData))
+	for _, data := range resourceData {
 		var purity purity
 		var amount float64
 		var name string
@@ -67,20 +119,42 @@ func New() ([]*Resource, error) {
 		} else {
 			return nil, fmt.Errorf("invalid resource ID: %s", data.ID)
 		}
+		if name == geothermalName {
+			continue
+		}
 		name = toCanonicalName(name)
 		const duration = 60.0 // 60 seconds
-		x := int(data.Longitude * 1000)
-		y := int(data.Latitude * 1000)
-		resources[i] = &Resource{
+		resources = append(resources, &Resource{
 			Production: production.New(name, amount/duration, 1),
 			Purity:     purity,
-			Loc:        point.Point{X: x, Y: y},
-		}
+			Loc:        geo.LatLngToPoint(geo.LatLng{Lat: data.Latitude, Lng: data.Longitude}),
+			Well:       well,
+		})
 	}
 
 	return resources, nil
 }
 
+// NewSite creates an unclaimed node of a placement-independent product
+// (see PlaceableAnywhere) at loc, at the pure reference rate of the
+// synthetic water nodes.
+func NewSite(product string, loc point.Point) *Resource {
+	const amount, duration = 120.0, 60.0
+	return &Resource{
+		Production: production.New(product, amount/duration, 1),
+		Purity:     pure,
+		Loc:        loc,
+	}
+}
+
+// PlaceableAnywhere reports whether an extractor for the product can be
+// built at any location rather than only on a surveyed node. Water
+// extractors sit on open water, which the map does not model, so water
+// sites may be opened wherever demand is.
+func PlaceableAnywhere(product string) bool {
+	return product == "Water"
+}
+
 // PrettyPrint returns a human-readable string representation of the resource.
 func (r *Resource) PrettyPrint() string {
 	return fmt.Sprintf("Resource %s (%s) @ %s", r.Production.Name, r.Purity, r.Loc.String())
@@ -117,10 +191,16 @@ func (r *Resource) SetAskPrice(name string, price float64) {
 }
 
 // ProduceTick extracts one tick's worth of product into stock, clamped
-// at outputCapTicks worth of production.
+// at outputCapTicks worth of production and, on a finite node, by what
+// is left of its reserve. Unclaimed and exhausted nodes produce nothing.
 func (r *Resource) ProduceTick(outputCapTicks float64) {
-	cap := r.Production.Rate * outputCapTicks
-	r.Stock = math.Min(cap, r.Stock+r.Production.Rate)
+	rate := r.ExtractionRate()
+	if rate <= production.RateEpsilon {
+		return
+	}
+	cap := rate * outputCapTicks
+	room := math.Max(0, cap-r.Stock)
+	r.Stock += r.drawReserve(math.Min(rate, room))
 }
 
 var _ production.Producer = (*Resource)(nil)
@@ -149,6 +229,10 @@ func toCanonicalName(name string) string {
 		return "OreUranium"
 	case "water":
 		return "Water"
+	case "nitrogen":
+		return "NitrogenGas"
+	case "sam":
+		return "SAM"
 	default:
 		return name
 	}
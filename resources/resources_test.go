@@ -28,6 +28,13 @@ func Test_Resource_ProduceTick(t *testing.T) {
 		Production: production.Production{Name: "OreIron", Rate: 2},
 		Loc:        point.Point{X: 0, Y: 0},
 	}
+	r.ProduceTick(3)
+	if r.Stock != 0 {
+		t.Fatalf("unclaimed node stock after 1 tick = %v, want 0", r.Stock)
+	}
+	if err := r.Claim(MinerMk1, OwnerCompany, 1, 0); err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
 	r.ProduceTick(3) // cap = 6 units
 	if r.Stock != 2 {
 		t.Fatalf("stock after 1 tick = %v, want 2", r.Stock)
@@ -116,3 +123,35 @@ func Test_Resource_water_nodes_load(t *testing.T) {
 		t.Errorf("missing water node at %s", loc.String())
 	}
 }
+
+func Test_Resource_wells_and_geysers(t *testing.T) {
+	rs, err := New()
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	wells, sam := 0, 0
+	for _, r := range rs {
+		switch r.Production.Name {
+		case "geyser":
+			t.Fatalf("geyser node at %s should not be loaded as a product node", r.Loc.String())
+		case "sam":
+			t.Fatalf("sam node at %s should load under its canonical name", r.Loc.String())
+		case "SAM":
+			sam++
+		case "NitrogenGas":
+			if !r.Well {
+				t.Errorf("nitrogen node at %s should be a well satellite", r.Loc.String())
+			}
+			if kinds := r.ExtractorKinds(); len(kinds) != 1 || kinds[0] != WellExtractor {
+				t.Errorf("nitrogen node at %s extractors = %v, want [%s]", r.Loc.String(), kinds, WellExtractor)
+			}
+			wells++
+		}
+	}
+	if wells != 20 {
+		t.Errorf("found %d nitrogen well satellites, want 20", wells)
+	}
+	if sam != 16 {
+		t.Errorf("found %d SAM nodes, want 16", sam)
+	}
+}
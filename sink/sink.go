@@ -15,6 +15,9 @@ type Sink struct {
 	// every product it wants. Goal sinks bid high -- their demand is the
 	// engine of the whole economy.
 	BidUnitPrice float64
+	// Rate is how many units per tick the sink bids for; zero leaves it
+	// to the engine's default.
+	Rate float64
 	// Delivered counts units actually received, by product. The
 	// space-elevator milestone is TotalDelivered() > 0 on a goal sink.
 	Delivered production.Inventory
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/logistics"
+	"github.com/paul-freeman/satisfactory-story/market"
 	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/production"
 	"github.com/paul-freeman/satisfactory-story/recipes"
@@ -33,10 +35,11 @@ func anyFactoryProducing(s *State) bool {
 	return false
 }
 
-// Test_cascade_single_tier: a goal sink bids for Ingot; only an Ore node
-// exists. A smelter must spawn, source Ore through the book, produce,
-// and deliver to the sink -- demand becomes supply with no tree-reading.
-func Test_cascade_single_tier(t *testing.T) {
+// newCascadeState builds the cascade world under cfg: a goal sink bids
+// for goal -- Ingot, which needs Ore, or Plate, which needs Ingot too --
+// and only an unclaimed Ore node exists.
+func newCascadeState(t *testing.T, cfg Config, goal string) *State {
+	t.Helper()
 	ore := &resources.Resource{
 		Production: production.Production{Name: "Ore", Rate: 100},
 		Loc:        point.Point{X: 400, Y: 400},
@@ -49,28 +52,68 @@ func Test_cascade_single_tier(t *testing.T) {
 			InputProducts:  production.Products{{Name: "Ore", Rate: 5}},
 			OutputProducts: production.Products{{Name: "Ingot", Rate: 5}},
 		},
+		{
+			ClassName:      "Recipe_Plate_C",
+			DisplayName:    "Roll Plate",
+			Active:         true,
+			InputProducts:  production.Products{{Name: "Ingot", Rate: 3}},
+			OutputProducts: production.Products{{Name: "Plate", Rate: 2}},
+		},
 	}
-	goal := sink.New("Ingot", point.Point{X: 600, Y: 600},
-		production.Products{{Name: "Ingot", Rate: 1}}, goalBidUnitPrice)
+	bidder := sink.New(goal, point.Point{X: 600, Y: 600},
+		production.Products{{Name: goal, Rate: 1}}, goalBidUnitPrice)
 
-	s := newTestStateWithProducers(rs, []production.Producer{ore, goal})
+	s := newTestStateWithProducers(rs, []production.Producer{ore, bidder})
+	s.config = cfg
+	matcher, err := market.NewMatcher(cfg.Matcher, cfg.MatcherK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.book.SetMatcher(matcher)
+	if len(cfg.TransportModes) > 0 {
+		s.network = logistics.NewNetwork(cfg.TransportModes...)
+		s.network.CanBuild = s.canBuildLink
+	}
+	return s
+}
 
-	const budget = 2000
-	sawProducing := false
+// runCascade ticks s until a goal sink takes a delivery, failing the
+// test if none comes within budget ticks or the money supply ever
+// moves beyond its recorded flows. each, when set, runs after every
+// tick. It returns the ticks taken.
+func runCascade(t *testing.T, s *State, budget int, each func()) int {
+	t.Helper()
 	i := 0
 	for ; i < budget && !delivered(s); i++ {
 		if err := s.Tick(testLogger()); err != nil {
 			t.Fatalf("tick %d failed: %v", i, err)
 		}
-		if anyFactoryProducing(s) {
-			sawProducing = true
+		if !s.money.balanced() {
+			t.Fatalf("tick %d: money out of balance by %v", i, s.money.last.discrepancy)
+		}
+		if each != nil {
+			each()
 		}
 	}
-
 	if !delivered(s) {
-		t.Fatalf("no Ingot delivered to the goal sink within %d ticks", budget)
+		t.Fatalf("nothing delivered to the goal sink within %d ticks", budget)
 	}
-	t.Logf("Ingot delivered after %d ticks", i)
+	return i
+}
+
+// Test_cascade_single_tier: a goal sink bids for Ingot; only an
+// unclaimed Ore node exists. A smelter must spawn, its Ore bid must get
+// an extractor placed on the node, and the smelter must source Ore
+// through the book, produce, and deliver to the sink -- demand becomes
+// supply with no tree-reading.
+func Test_cascade_single_tier(t *testing.T) {
+	s := newCascadeState(t, DefaultConfig(), "Ingot")
+
+	sawProducing := false
+	ticks := runCascade(t, s, 2000, func() {
+		sawProducing = sawProducing || anyFactoryProducing(s)
+	})
+	t.Logf("Ingot delivered after %d ticks", ticks)
 
 	if !sawProducing {
 		t.Fatal("no factory ever reported ProducedLastTick == true before delivery")
@@ -85,47 +128,13 @@ func Test_cascade_single_tier(t *testing.T) {
 // escalating Ingot bid must make smelting look profitable, a smelter
 // must spawn and connect to Ore, and the full chain must flow.
 func Test_cascade_two_tier(t *testing.T) {
-	ore := &resources.Resource{
-		Production: production.Production{Name: "Ore", Rate: 100},
-		Loc:        point.Point{X: 400, Y: 400},
-	}
-	rs := recipes.Recipes{
-		{
-			ClassName:      "Recipe_Smelt_C",
-			DisplayName:    "Smelt Ore",
-			Active:         true,
-			InputProducts:  production.Products{{Name: "Ore", Rate: 5}},
-			OutputProducts: production.Products{{Name: "Ingot", Rate: 5}},
-		},
-		{
-			ClassName:      "Recipe_Plate_C",
-			DisplayName:    "Roll Plate",
-			Active:         true,
-			InputProducts:  production.Products{{Name: "Ingot", Rate: 3}},
-			OutputProducts: production.Products{{Name: "Plate", Rate: 2}},
-		},
-	}
-	goal := sink.New("Plate", point.Point{X: 600, Y: 600},
-		production.Products{{Name: "Plate", Rate: 1}}, goalBidUnitPrice)
+	s := newCascadeState(t, DefaultConfig(), "Plate")
 
-	s := newTestStateWithProducers(rs, []production.Producer{ore, goal})
-
-	const budget = 5000
 	sawProducing := false
-	i := 0
-	for ; i < budget && !delivered(s); i++ {
-		if err := s.Tick(testLogger()); err != nil {
-			t.Fatalf("tick %d failed: %v", i, err)
-		}
-		if anyFactoryProducing(s) {
-			sawProducing = true
-		}
-	}
-
-	if !delivered(s) {
-		t.Fatalf("no Plate delivered to the goal sink within %d ticks", budget)
-	}
-	t.Logf("Plate delivered after %d ticks", i)
+	ticks := runCascade(t, s, 5000, func() {
+		sawProducing = sawProducing || anyFactoryProducing(s)
+	})
+	t.Logf("Plate delivered after %d ticks", ticks)
 
 	if !sawProducing {
 		t.Fatal("no factory ever reported ProducedLastTick == true before delivery")
@@ -134,3 +143,53 @@ func Test_cascade_two_tier(t *testing.T) {
 		t.Fatal("trade ledger is empty at delivery time; no trades were recorded")
 	}
 }
+
+// Test_cascade_everyMatcher: the single-tier cascade must still close
+// under each market mechanism -- only the pricing rule differs.
+func Test_cascade_everyMatcher(t *testing.T) {
+	for _, kind := range []market.MatcherKind{market.MatchKDouble, market.MatchCallAuction} {
+		t.Run(string(kind), func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Matcher = kind
+			runCascade(t, newCascadeState(t, cfg, "Ingot"), 2000, nil)
+		})
+	}
+}
+
+// Test_cascade_orderTypes: the two-tier cascade must still close with
+// ladder bids and batch-size asks.
+func Test_cascade_orderTypes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LadderBids = true
+	cfg.AskBatchTicks = 5
+	runCascade(t, newCascadeState(t, cfg, "Plate"), 5000, nil)
+}
+
+// Test_cascade_logistics: the two-tier cascade must still close when
+// goods ship over a capacity-limited logistics network, with the links
+// filling up along the way and the treasury paying for every one.
+func Test_cascade_logistics(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TransportModes = logistics.Modes
+	s := newCascadeState(t, cfg, "Plate")
+
+	sawFull := false
+	runCascade(t, s, 5000, func() {
+		for _, l := range s.network.Links() {
+			if l.Used > logistics.SpecFor(l.Mode).Capacity+production.RateEpsilon {
+				t.Fatalf("link %+v carried more than its capacity", l)
+			}
+			sawFull = sawFull || l.Residual() <= production.RateEpsilon
+		}
+	})
+	if !sawFull {
+		t.Fatal("no link ever filled: capacity never limited a shipment")
+	}
+	built := 0.0
+	for _, l := range s.network.Links() {
+		built += logistics.SpecFor(l.Mode).BuildCost
+	}
+	if got := -s.money.cumulative[channelConstruction]; got < built {
+		t.Fatalf("construction = %v, want at least the %v the standing links cost", got, built)
+	}
+}
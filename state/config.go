@@ -0,0 +1,164 @@
+package state
+
+import (
+	"github.com/paul-freeman/satisfactory-story/logistics"
+	"github.com/paul-freeman/satisfactory-story/market"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/terrain"
+)
+
+// Config selects between the engine's alternate economies. New runs
+// DefaultConfig, the calibrated baseline; NewWithConfig runs any other
+// for comparison studies. Reset keeps the config a State was built with.
+// The JSON keys are what a scenario's config block names (see
+// Scenario); runtime-only settings have none.
+type Config struct {
+	// Depletion makes resource nodes finite: extraction draws down a
+	// reserve, the rate decays as it empties, and exhausted nodes stop
+	// posting asks.
+	Depletion resources.Depletion `json:"depletion"`
+	// NodeOwner is who holds the nodes that new extractors claim, and so
+	// receives their sale proceeds.
+	NodeOwner resources.Owner `json:"nodeOwner"`
+	// RoyaltyPct is the share of every resource-node sale paid to the
+	// treasury before the rest reaches the node's owner.
+	RoyaltyPct float64 `json:"royaltyPct"`
+	// FeePct is the exchange fee: the share of every trade's value the
+	// seller pays to the treasury.
+	FeePct float64 `json:"feePct"`
+	// MarketMakerProducts, when non-empty, places a dealer at the map's
+	// centre that quotes both sides of each listed product, holding up to
+	// MarketMakerTarget units of each at a full spread of
+	// MarketMakerSpreadPct around its mid.
+	MarketMakerProducts  []string `json:"marketMakerProducts"`
+	MarketMakerTarget    float64  `json:"marketMakerTarget"`
+	MarketMakerSpreadPct float64  `json:"marketMakerSpreadPct"`
+	// TransportModes, when non-empty, replaces the flat freight formula
+	// with a logistics network of these modes: each trade ships by the
+	// cheapest mode with room left on its link, and full links stop
+	// trade until the next tick. Matching then runs serially, since
+	// products share links.
+	TransportModes []logistics.Mode `json:"transportModes"`
+	// Infrastructure lets the treasury build rail lines along busy trade
+	// corridors; trades that ship over one pay its toll instead of the
+	// road freight. It applies to flat freight only -- with
+	// TransportModes set, the network builds its own links.
+	Infrastructure bool `json:"infrastructure"`
+	// HubRouting sends flat-freight trades through a graph of train
+	// stations and truck depots when a multi-leg path beats the direct
+	// road. The graph is read from HubFile (see logistics.LoadHubGraph)
+	// or, when that is empty, generated with a station at the centre of
+	// each of HubClusters clusters of resource nodes.
+	HubRouting  bool   `json:"hubRouting"`
+	HubFile     string `json:"hubFile"`
+	HubClusters int    `json:"hubClusters"`
+	// TerrainFile, when set, lays a terrain cost raster (see
+	// terrain.Load) over the world: road freight follows the least-cost
+	// path across it, and factories neither spawn nor move onto
+	// impassable cells.
+	TerrainFile string `json:"terrainFile"`
+	// RegionFile names the region outlines locations are tagged with
+	// (see geo.LoadRegions); empty uses the built-in coarse outlines.
+	RegionFile string `json:"regionFile"`
+	// Bases are where the player's bases stand, each hosting a full set
+	// of goal sinks; empty puts one base at the centre of the world.
+	// Every base must lie within the world bounds. The market maker's
+	// depot stands at the first base only.
+	Bases []point.Point `json:"bases"`
+	// Bounds, when set, is the world rectangle spawns, terrain and the
+	// map are laid over, in place of the resource nodes' extent padded
+	// by borderPaddingPct.
+	Bounds *terrain.Bounds `json:"bounds"`
+	// StartRegions, when set, names the map regions (see geo.Regions)
+	// the playthrough begins in: resource nodes elsewhere start locked,
+	// out of reach of extractors. Every RegionUnlockTicks ticks (never,
+	// when 0) the locked region nearest a base opens.
+	StartRegions      []string `json:"startRegions"`
+	RegionUnlockTicks int      `json:"regionUnlockTicks"`
+	// LandRent, when positive, charges every factory this much per tick
+	// per square metre of its footprint for each other building within
+	// landRentRadius of it, paid to the treasury: crowded sites cost
+	// more to hold.
+	LandRent float64 `json:"landRent"`
+	// Transit puts traded goods on the road: the buyer pays at once but
+	// receives them after a distance-proportional number of ticks, and
+	// counts them towards its stock target meanwhile.
+	Transit bool `json:"transit"`
+	// Contracts lets pairs that keep trading on the spot market sign
+	// standing supply agreements, settled each tick before spot matching.
+	Contracts bool `json:"contracts"`
+	// LadderBids makes factories split their input hunger across price
+	// levels (factory.BidLadder) instead of bidding it all at one price.
+	LadderBids bool `json:"ladderBids"`
+	// AskBatchTicks, when positive, makes factories sell only in batches
+	// of at least this many ticks of output (minimum-quantity asks). It
+	// must stay below outputStockCapTicks or nothing ever ships.
+	AskBatchTicks float64 `json:"askBatchTicks"`
+	// Matcher is the market mechanism that crosses the book each tick;
+	// MatcherK is the k-double auction's surplus split.
+	Matcher  market.MatcherKind `json:"matcher"`
+	MatcherK float64            `json:"matcherK"`
+	// Workers is how many goroutines the producer-local tick phases and
+	// per-product matching fan out over; 0 or 1 runs the tick serially.
+	// Results are bit-for-bit identical for any value, so it is a
+	// runtime setting rather than part of a scenario.
+	Workers int `json:"-"`
+}
+
+// validate checks the settings a scenario names by value: the transport
+// modes, node owner and matcher must be ones the engine knows.
+func (c Config) validate() error {
+	for _, mode := range c.TransportModes {
+		if _, err := logistics.ParseMode(string(mode)); err != nil {
+			return err
+		}
+	}
+	if _, err := resources.ParseOwner(string(c.NodeOwner)); err != nil {
+		return err
+	}
+	if _, err := market.NewMatcher(c.Matcher, c.MatcherK); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DefaultConfig returns the baseline economy: infinite resource nodes
+// claimed by extractor companies, with no royalty, no exchange fee, no
+// market maker, and flat road freight delivered instantly over open
+// ground with no hub routing.
+// Alternate modes carry tuned defaults so enabling one is a single field.
+func DefaultConfig() Config {
+	return Config{
+		Depletion: resources.Depletion{
+			Enabled:      false,
+			ReserveTicks: 50000,
+			DecayFloor:   0.25,
+		},
+		NodeOwner:            resources.OwnerCompany,
+		RoyaltyPct:           0,
+		FeePct:               0,
+		MarketMakerProducts:  nil,
+		MarketMakerTarget:    50,
+		MarketMakerSpreadPct: 0.10,
+		TransportModes:       nil,
+		Infrastructure:       false,
+		HubRouting:           false,
+		HubFile:              "",
+		HubClusters:          12,
+		TerrainFile:          "",
+		RegionFile:           "",
+		Bases:                nil,
+		Bounds:               nil,
+		StartRegions:         nil,
+		RegionUnlockTicks:    0,
+		LandRent:             0,
+		Transit:              false,
+		Contracts:            false,
+		LadderBids:           false,
+		AskBatchTicks:        0,
+		Matcher:              market.MatchAskPrice,
+		MatcherK:             0.5,
+		Workers:              1,
+	}
+}
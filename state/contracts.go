@@ -0,0 +1,355 @@
+package state
+
+import (
+	"log/slog"
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/market"
+	"github.com/paul-freeman/satisfactory-story/marketmaker"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+)
+
+// contractFormationTicks is how many consecutive ticks a seller and a
+// factory buyer must trade a product on the spot market before they sign
+// a standing supply agreement for it.
+const contractFormationTicks = 20
+
+// contractDurationTicks is how long a signed contract runs.
+const contractDurationTicks = 1000
+
+// contractPenaltyPct is the share of the undelivered value (shortfall x
+// contract price) the breaching party pays its counterparty.
+const contractPenaltyPct = 0.5
+
+// contractMaxBreaches is how many consecutive breached ticks terminate a
+// contract.
+const contractMaxBreaches = 10
+
+// contractHistoryLimit caps how many ended contracts are kept for the
+// wire.
+const contractHistoryLimit = 200
+
+type contractStatus string
+
+const (
+	contractActive     contractStatus = "active"
+	contractExpired    contractStatus = "expired"
+	contractTerminated contractStatus = "terminated"
+	// contractVoid: a party left the world (bankrupt, or its recipe was
+	// disabled), or a node seller lost its extractor, before the
+	// contract ran out.
+	contractVoid contractStatus = "void"
+)
+
+// contract is a standing supply agreement: the seller delivers rate
+// units of product per tick to the buyer at a locked unit price (freight
+// on top, as for spot trades) until endTick.
+type contract struct {
+	id        int
+	seller    production.Producer
+	buyer     *factory.Factory
+	product   string
+	rate      float64
+	unitPrice float64
+	startTick int
+	endTick   int
+
+	delivered float64
+	shortfall float64
+	penalties float64
+	// breaches counts consecutive breached ticks; totalBreaches all.
+	breaches      int
+	totalBreaches int
+	status        contractStatus
+	endedTick     int
+}
+
+// contractPair identifies a seller, buyer and product.
+type contractPair struct {
+	seller  production.Producer
+	buyer   production.Producer
+	product string
+}
+
+// tradeStreak tracks a pair's unbroken run of spot trading.
+type tradeStreak struct {
+	lastTick  int
+	ticks     int
+	volume    float64
+	unitPrice float64
+}
+
+// contractBook holds the active contracts in signing order, recently
+// ended ones, and the spot-trading streaks that lead to new contracts.
+type contractBook struct {
+	nextID  int
+	active  []*contract
+	ended   []*contract
+	streaks map[contractPair]*tradeStreak
+}
+
+// settleContracts delivers every active contract before spot matching,
+// in signing order, through the same executeTrade as spot trades. A
+// shortfall is a breach when a party's own clamp bound: the seller's if
+// its stock ran short, the buyer's if its wallet did. The breaching
+// party pays the penalty to its counterparty; contractMaxBreaches in a
+// row terminate the contract. A shortfall neither caused -- no route,
+// a full link, a link the treasury cannot fund -- is recorded but not
+// penalised.
+func (s *State) settleContracts(l *slog.Logger) {
+	if !s.config.Contracts || len(s.contracts.active) == 0 {
+		return
+	}
+	live := make(map[production.Producer]bool, len(s.producers))
+	for _, p := range s.producers {
+		live[p] = true
+	}
+
+	running := s.contracts.active[:0]
+	for _, c := range s.contracts.active {
+		switch {
+		case !live[c.seller] || !live[c.buyer] || released(c.seller):
+			s.contracts.end(c, contractVoid, s.tick)
+			continue
+		case s.tick > c.endTick:
+			s.contracts.end(c, contractExpired, s.tick)
+			continue
+		}
+
+		// What each party's own clamp allows, before the trade moves it.
+		transport := s.unitTransport(c.seller.Location(), c.buyer.Location())
+		stock := sellerStock(c.seller, c.product)
+		affordable := math.Inf(1)
+		if !math.IsInf(transport, 1) && c.unitPrice+transport > 0 {
+			affordable = c.buyer.Cash() / (c.unitPrice + transport)
+		}
+		executed, err := s.executeTrade(l, market.Match{
+			Seller:        c.seller,
+			Buyer:         c.buyer,
+			Order:         production.Production{Name: c.product, Rate: c.rate},
+			UnitPrice:     c.unitPrice,
+			UnitTransport: transport,
+		}, nil)
+		if err != nil {
+			l.Error("failed to settle contract: " + err.Error())
+		}
+		c.delivered += executed
+
+		short := c.rate - executed
+		if short <= production.RateEpsilon {
+			c.breaches = 0
+			running = append(running, c)
+			continue
+		}
+		c.shortfall += short
+		// A clamp bound when the trade went as far as it allowed.
+		var breacher, counterparty production.Producer
+		switch {
+		case stock < c.rate-production.RateEpsilon && executed >= stock-production.RateEpsilon:
+			breacher, counterparty = c.seller, c.buyer
+		case affordable < c.rate-production.RateEpsilon && executed >= affordable-production.RateEpsilon:
+			breacher, counterparty = c.buyer, c.seller
+		default:
+			running = append(running, c)
+			continue
+		}
+		penalty := short * c.unitPrice * contractPenaltyPct
+		if s.paidByTreasury(breacher) {
+			penalty = min(penalty, max(0, s.treasury))
+		}
+		s.adjustAccount(breacher, -penalty)
+		s.adjustAccount(counterparty, penalty)
+		c.penalties += penalty
+		c.breaches++
+		c.totalBreaches++
+		if c.breaches >= contractMaxBreaches {
+			l.Debug("contract terminated",
+				slog.Int("contract", c.id),
+				slog.String("product", c.product))
+			s.contracts.end(c, contractTerminated, s.tick)
+			continue
+		}
+		running = append(running, c)
+	}
+	clear(s.contracts.active[len(running):])
+	s.contracts.active = running
+}
+
+// formContracts extends the streaks of every pair that traded this tick
+// and signs a contract for each pair whose streak reaches
+// contractFormationTicks, at its average rate over the streak and its
+// latest price. Only factory buyers sign; pairs already under contract
+// are skipped.
+func (s *State) formContracts(l *slog.Logger) {
+	if !s.config.Contracts {
+		return
+	}
+	cb := &s.contracts
+	if cb.streaks == nil {
+		cb.streaks = make(map[contractPair]*tradeStreak)
+	}
+	bound := make(map[contractPair]bool, len(cb.active))
+	for _, c := range cb.active {
+		bound[contractPair{c.seller, c.buyer, c.product}] = true
+	}
+
+	// This tick's trades are at the end of the ledger, in execution
+	// order; visit pairs in first-traded order.
+	trades := s.ledger.trades
+	first := len(trades)
+	for first > 0 && trades[first-1].tick == s.tick {
+		first--
+	}
+	pairs := make([]contractPair, 0)
+	for _, tr := range trades[first:] {
+		if _, ok := tr.buyer.(*factory.Factory); !ok {
+			continue
+		}
+		key := contractPair{tr.seller, tr.buyer, tr.product}
+		if bound[key] {
+			continue
+		}
+		streak, ok := cb.streaks[key]
+		if !ok {
+			streak = &tradeStreak{}
+			cb.streaks[key] = streak
+		}
+		if streak.lastTick != s.tick {
+			if streak.lastTick != s.tick-1 {
+				*streak = tradeStreak{}
+			}
+			streak.lastTick = s.tick
+			streak.ticks++
+			pairs = append(pairs, key)
+		}
+		streak.volume += tr.qty
+		streak.unitPrice = tr.unitPrice
+	}
+
+	for _, key := range pairs {
+		streak := cb.streaks[key]
+		if streak.ticks < contractFormationTicks {
+			continue
+		}
+		c := &contract{
+			id:        cb.nextID,
+			seller:    key.seller,
+			buyer:     key.buyer.(*factory.Factory),
+			product:   key.product,
+			rate:      streak.volume / float64(streak.ticks),
+			unitPrice: streak.unitPrice,
+			startTick: s.tick + 1,
+			endTick:   s.tick + contractDurationTicks,
+			status:    contractActive,
+		}
+		cb.nextID++
+		cb.active = append(cb.active, c)
+		delete(cb.streaks, key)
+		l.Debug("contract signed",
+			slog.Int("contract", c.id),
+			slog.String("product", c.product),
+			slog.Float64("rate", c.rate),
+			slog.Float64("unitPrice", c.unitPrice))
+	}
+
+	for key, streak := range cb.streaks {
+		if streak.lastTick < s.tick {
+			delete(cb.streaks, key)
+		}
+	}
+}
+
+// end retires a contract into the bounded history.
+func (cb *contractBook) end(c *contract, status contractStatus, tick int) {
+	c.status, c.endedTick = status, tick
+	cb.ended = append(cb.ended, c)
+	if over := len(cb.ended) - contractHistoryLimit; over > 0 {
+		clear(cb.ended[:over])
+		cb.ended = cb.ended[over:]
+	}
+}
+
+// sellerStock is what a seller has on hand to deliver.
+func sellerStock(p production.Producer, product string) float64 {
+	switch seller := p.(type) {
+	case *resources.Resource:
+		return seller.Stock
+	case *factory.Factory:
+		return seller.OutputStock.Get(product)
+	case *marketmaker.MarketMaker:
+		return seller.Inventory.Get(product)
+	}
+	return 0
+}
+
+// released reports whether p is a node whose extractor was released:
+// with no one extracting it, no one stands behind its contracts.
+func released(p production.Producer) bool {
+	r, ok := p.(*resources.Resource)
+	return ok && !r.Claimed()
+}
+
+// paidByTreasury reports whether adjustAccount settles p's account out
+// of the treasury.
+func (s *State) paidByTreasury(p production.Producer) bool {
+	r, ok := p.(*resources.Resource)
+	return ok && (r.Extractor == nil || r.Extractor.Owner != resources.OwnerCompany)
+}
+
+// adjustAccount moves money into (or out of) a producer's account: a
+// factory's or the market maker's wallet, an extractor company's wallet,
+// or the treasury for a state-held node.
+func (s *State) adjustAccount(p production.Producer, delta float64) {
+	switch producer := p.(type) {
+	case *factory.Factory:
+		producer.Wallet.Adjust(delta)
+	case *marketmaker.MarketMaker:
+		producer.Wallet.Adjust(delta)
+	case *resources.Resource:
+		if producer.Extractor != nil && producer.Extractor.Owner == resources.OwnerCompany {
+			producer.Extractor.Wallet.Adjust(delta)
+			return
+		}
+		s.treasury += delta
+	}
+}
+
+func (s *State) Contracts(_ *slog.Logger) statehttp.Contracts {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	out := statehttp.Contracts{
+		Tick:   s.tick,
+		Active: make([]statehttp.Contract, 0, len(s.contracts.active)),
+		Ended:  make([]statehttp.Contract, 0, len(s.contracts.ended)),
+	}
+	for _, c := range s.contracts.active {
+		out.Active = append(out.Active, s.contractForWire(c))
+	}
+	for _, c := range s.contracts.ended {
+		out.Ended = append(out.Ended, s.contractForWire(c))
+	}
+	return out
+}
+
+func (s *State) contractForWire(c *contract) statehttp.Contract {
+	return statehttp.Contract{
+		ID:        c.id,
+		Seller:    s.location(c.seller.Location()),
+		Buyer:     s.location(c.buyer.Location()),
+		Product:   c.product,
+		Rate:      c.rate,
+		UnitPrice: c.unitPrice,
+		StartTick: c.startTick,
+		EndTick:   c.endTick,
+		Delivered: c.delivered,
+		Shortfall: c.shortfall,
+		Penalties: c.penalties,
+		Breaches:  c.totalBreaches,
+		Status:    string(c.status),
+		EndedTick: c.endedTick,
+	}
+}
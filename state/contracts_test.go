@@ -0,0 +1,238 @@
+package state
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/logistics"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
+)
+
+// contractParties is a treasury-held ore node and a smelter 10,000 apart.
+func contractParties(stock, cash float64) (*resources.Resource, *factory.Factory) {
+	r := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+		Stock:      stock,
+	}
+	if err := r.Claim(resources.MinerMk1, resources.OwnerTreasury, 1, 0); err != nil {
+		panic(err)
+	}
+	f := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 10000, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		cash)
+	return r, f
+}
+
+func Test_formContracts_signsAfterUnbrokenStreak(t *testing.T) {
+	s := newTestState()
+	s.config.Contracts = true
+	r, f := contractParties(0, 100)
+	s.producers = []production.Producer{r, f}
+
+	for tick := 1; tick <= contractFormationTicks; tick++ {
+		s.tick = tick
+		if len(s.contracts.active) != 0 {
+			t.Fatalf("contract signed at tick %d, before the streak completed", tick)
+		}
+		s.ledger.record(tick, r, f, "OreIron", float64(tick%2+1), 2.0+float64(tick)/100)
+		s.formContracts(testLogger())
+	}
+
+	if len(s.contracts.active) != 1 {
+		t.Fatalf("active contracts = %d, want 1", len(s.contracts.active))
+	}
+	c := s.contracts.active[0]
+	if c.seller != r || c.buyer != f || c.product != "OreIron" {
+		t.Fatalf("contract = %+v, want the ore node supplying the smelter", c)
+	}
+	if c.rate != 1.5 || c.unitPrice != 2.2 {
+		t.Fatalf("rate/price = %v/%v, want the streak's average 1.5 at the last price 2.2", c.rate, c.unitPrice)
+	}
+	if c.endTick != contractFormationTicks+contractDurationTicks {
+		t.Fatalf("endTick = %d, want %d", c.endTick, contractFormationTicks+contractDurationTicks)
+	}
+}
+
+func Test_formContracts_brokenStreakStartsOver(t *testing.T) {
+	s := newTestState()
+	s.config.Contracts = true
+	r, f := contractParties(0, 100)
+	s.producers = []production.Producer{r, f}
+
+	for tick := 1; tick < 2*contractFormationTicks; tick++ {
+		s.tick = tick
+		if tick != contractFormationTicks/2 { // one quiet tick
+			s.ledger.record(tick, r, f, "OreIron", 1, 2)
+		}
+		s.formContracts(testLogger())
+	}
+	if len(s.contracts.active) != 1 || s.contracts.active[0].startTick != contractFormationTicks/2+contractFormationTicks+1 {
+		t.Fatalf("contracts = %+v, want one signed %d ticks after the gap", s.contracts.active, contractFormationTicks)
+	}
+}
+
+func Test_settleContracts_deliversAndPenalizesShortSeller(t *testing.T) {
+	s := newTestState()
+	s.config.Contracts = true
+	s.tick = 5
+	r, f := contractParties(3, 100)
+	s.producers = []production.Producer{r, f}
+	s.contracts.active = []*contract{{
+		seller: r, buyer: f, product: "OreIron",
+		rate: 4, unitPrice: 2, startTick: 1, endTick: 100, status: contractActive,
+	}}
+	treasury := s.treasury
+
+	s.settleContracts(testLogger())
+
+	c := s.contracts.active[0]
+	if c.delivered != 3 || c.shortfall != 1 || c.breaches != 1 {
+		t.Fatalf("contract = %+v, want 3 delivered, 1 short, 1 breach", c)
+	}
+	if got := f.InputStock.Get("OreIron"); got != 3 {
+		t.Fatalf("buyer stock = %v, want 3", got)
+	}
+	// The treasury holds the node: it takes the 3 x 2 sale and pays
+	// the 1 x 2 x 50% penalty to the buyer.
+	if got := s.treasury - treasury; math.Abs(got-5) > 1e-9 {
+		t.Fatalf("treasury change = %v, want +5", got)
+	}
+	freight := 3 * (2 + 0.1 + 10000.0/10000)
+	if got := f.Wallet.Cash(); math.Abs(got-(100-freight+1)) > 1e-9 {
+		t.Fatalf("buyer cash = %v, want %v", got, 100-freight+1)
+	}
+}
+
+func Test_settleContracts_endsContracts(t *testing.T) {
+	s := newTestState()
+	s.config.Contracts = true
+	s.tick = 50
+	r, f := contractParties(0, 100)
+	_, gone := contractParties(0, 100)
+	s.producers = []production.Producer{r, f}
+	s.contracts.active = []*contract{
+		{id: 0, seller: r, buyer: f, product: "OreIron", rate: 1, unitPrice: 1, endTick: 40, status: contractActive},
+		{id: 1, seller: r, buyer: gone, product: "OreIron", rate: 1, unitPrice: 1, endTick: 100, status: contractActive},
+		{id: 2, seller: r, buyer: f, product: "OreIron", rate: 1, unitPrice: 1, endTick: 100, status: contractActive,
+			breaches: contractMaxBreaches - 1},
+	}
+
+	s.settleContracts(testLogger())
+
+	if len(s.contracts.active) != 0 {
+		t.Fatalf("active = %d, want every contract ended", len(s.contracts.active))
+	}
+	want := []contractStatus{contractExpired, contractVoid, contractTerminated}
+	for i, c := range s.contracts.ended {
+		if c.status != want[i] || c.endedTick != 50 {
+			t.Fatalf("contract %d ended %s at %d, want %s at 50", c.id, c.status, c.endedTick, want[i])
+		}
+	}
+}
+
+func Test_settleContracts_blamesOnlyTheBindingClamp(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		stock, cash    float64
+		fullLink       bool
+		wantBuyerBlame bool
+	}{
+		{name: "full link", stock: 10, cash: 100, fullLink: true},
+		{name: "short wallet", stock: 10, cash: 6.2, wantBuyerBlame: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestState()
+			s.config.Contracts = true
+			s.tick = 5
+			r, f := contractParties(tc.stock, tc.cash)
+			s.producers = []production.Producer{r, f}
+			s.network = logistics.NewNetwork(logistics.Truck)
+			s.network.BeginTick(s.tick)
+			if tc.fullLink {
+				s.network.Ship(r.Location(), f.Location(), logistics.Truck, logistics.SpecFor(logistics.Truck).Capacity)
+			}
+			s.contracts.active = []*contract{{
+				seller: r, buyer: f, product: "OreIron",
+				rate: 4, unitPrice: 2, startTick: 1, endTick: 100, status: contractActive,
+			}}
+			cash := f.Wallet.Cash()
+
+			s.settleContracts(testLogger())
+
+			c := s.contracts.active[0]
+			if c.shortfall <= 0 {
+				t.Fatalf("contract = %+v, want a shortfall", c)
+			}
+			if got := c.breaches == 1; got != tc.wantBuyerBlame {
+				t.Fatalf("breaches = %d, want the buyer blamed: %v", c.breaches, tc.wantBuyerBlame)
+			}
+			if !tc.wantBuyerBlame && f.Wallet.Cash() != cash {
+				t.Fatalf("buyer cash = %v, want %v: a full link is no one's fault", f.Wallet.Cash(), cash)
+			}
+		})
+	}
+}
+
+func Test_settleContracts_voidsReleasedNode(t *testing.T) {
+	s := newTestState()
+	s.config.Contracts = true
+	s.tick = 5
+	r, f := contractParties(10, 100)
+	r.Release()
+	s.producers = []production.Producer{r, f}
+	s.contracts.active = []*contract{{
+		seller: r, buyer: f, product: "OreIron",
+		rate: 4, unitPrice: 2, startTick: 1, endTick: 100, status: contractActive,
+	}}
+
+	s.settleContracts(testLogger())
+
+	if len(s.contracts.active) != 0 || s.contracts.ended[0].status != contractVoid {
+		t.Fatalf("contracts = %+v, want the released node's contract void", s.contracts.ended)
+	}
+}
+
+func Test_settleContracts_treasuryPenaltyNeverOverdraws(t *testing.T) {
+	s := newTestState()
+	s.config.Contracts = true
+	s.tick = 5
+	s.treasury = 1
+	r, f := contractParties(0, 100)
+	s.producers = []production.Producer{r, f}
+	s.contracts.active = []*contract{{
+		seller: r, buyer: f, product: "OreIron",
+		rate: 4, unitPrice: 2, startTick: 1, endTick: 100, status: contractActive,
+	}}
+
+	s.settleContracts(testLogger())
+
+	if s.treasury != 0 || f.Wallet.Cash() != 101 {
+		t.Fatalf("treasury/buyer cash = %v/%v, want the penalty capped at the treasury's 1", s.treasury, f.Wallet.Cash())
+	}
+}
+
+// Test_contracts_formInCascade: with contracts on, the steady supply
+// routes of the steel world become contracts and money is still
+// conserved.
+func Test_contracts_formInCascade(t *testing.T) {
+	s := parallelFixture(1)
+	s.config.Contracts = true
+	for i := 0; i < 1500; i++ {
+		if err := s.Tick(testLogger()); err != nil {
+			t.Fatalf("tick %d failed: %v", i, err)
+		}
+		if !s.money.balanced() {
+			t.Fatalf("tick %d: money out of balance by %v", i, s.money.last.discrepancy)
+		}
+	}
+	if s.contracts.nextID == 0 {
+		t.Fatal("no contract was ever signed")
+	}
+	got := s.Contracts(testLogger())
+	t.Logf("%d contracts signed: %d active, %d ended", s.contracts.nextID, len(got.Active), len(got.Ended))
+}
@@ -0,0 +1,52 @@
+package state
+
+import (
+	"log/slog"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+)
+
+// Metrics snapshots engine counters, phase timings and the top of book
+// for the /metrics endpoint.
+func (s *State) Metrics(_ *slog.Logger) statehttp.Metrics {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	m := statehttp.Metrics{
+		Tick:          s.tick,
+		TimedTicks:    s.clock.ticks,
+		Phases:        make([]statehttp.PhaseSeconds, 0, len(tickPhases)),
+		Trades:        s.counters.trades,
+		Spawns:        s.counters.spawns,
+		SkippedSpawns: s.counters.skippedSpawns,
+		Bankruptcies:  s.counters.bankruptcies,
+		Extractors:    s.counters.extractors,
+		Relocations:   s.counters.relocations,
+		Treasury:      s.treasury,
+		Fees:          s.counters.fees,
+		Quotes:        make([]statehttp.Quote, 0),
+	}
+	for _, phase := range tickPhases {
+		m.Phases = append(m.Phases, statehttp.PhaseSeconds{
+			Phase:   string(phase),
+			Seconds: s.clock.totals[phase].Seconds(),
+		})
+	}
+	for _, p := range s.producers {
+		if _, ok := p.(*factory.Factory); ok {
+			m.Factories++
+		}
+	}
+	for _, product := range s.book.Products() {
+		q := statehttp.Quote{Product: product}
+		if bid, ok := s.book.BestBid(product); ok {
+			q.HasBid, q.BestBid = true, bid.UnitPrice
+		}
+		if ask, ok := s.book.BestAsk(product); ok {
+			q.HasAsk, q.BestAsk = true, ask.UnitPrice
+		}
+		m.Quotes = append(m.Quotes, q)
+	}
+	return m
+}
@@ -0,0 +1,50 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/sink"
+)
+
+func Test_Metrics_countsTicksAndQuotes(t *testing.T) {
+	ore := &resources.Resource{
+		Production: production.Production{Name: "Ore", Rate: 100},
+		Loc:        point.Point{X: 400, Y: 400},
+	}
+	goal := sink.New("Ore", point.Point{X: 600, Y: 600},
+		production.Products{{Name: "Ore", Rate: 1}}, goalBidUnitPrice)
+	s := newTestStateWithProducers(recipes.Recipes{}, []production.Producer{ore, goal})
+
+	const ticks = 5
+	for i := 0; i < ticks; i++ {
+		if err := s.Tick(testLogger()); err != nil {
+			t.Fatalf("tick %d failed: %v", i, err)
+		}
+	}
+
+	m := s.Metrics(testLogger())
+	if m.TimedTicks != ticks {
+		t.Fatalf("timed ticks = %d, want %d", m.TimedTicks, ticks)
+	}
+	if len(m.Phases) != len(tickPhases) {
+		t.Fatalf("phases = %d, want %d", len(m.Phases), len(tickPhases))
+	}
+	for i, phase := range m.Phases {
+		if phase.Phase != string(tickPhases[i]) {
+			t.Fatalf("phase %d = %q, want %q (Tick order)", i, phase.Phase, tickPhases[i])
+		}
+	}
+	found := false
+	for _, q := range m.Quotes {
+		if q.Product == "Ore" {
+			found = q.HasBid
+		}
+	}
+	if !found {
+		t.Fatalf("quotes = %+v, want the goal sink's Ore bid", m.Quotes)
+	}
+}
@@ -0,0 +1,123 @@
+package state
+
+import (
+	"log/slog"
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/landuse"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
+)
+
+// extractorSpawnProbabilityPerTick is the chance, per tick, that a new
+// extractor is placed on an unclaimed resource node.
+const extractorSpawnProbabilityPerTick = 0.05
+
+// defaultExtractorClock is the clock speed new extractors are built at.
+const defaultExtractorClock = 1.0
+
+// spawnExtractor claims one unclaimed resource node with an extractor,
+// paid for out of the treasury. Nodes are drawn weighted by how much the
+// best standing bid for their product would pay for their reference
+// output, net of shipping to that bidder -- so raw supply appears where
+// and when prices ask for it, instead of flowing from every node for
+// free. Placement-independent products (see resources.PlaceableAnywhere)
+// also compete with a fresh site on the free land nearest their best
+// bidder. The tier is the smallest extractor whose output covers the
+// product's residual demand over the input-stock horizon (the largest
+// otherwise); the build cost leaves the economy.
+func (s *State) spawnExtractor(l *slog.Logger) {
+	nodes := make([]*resources.Resource, 0)
+	weights := make([]float64, 0)
+	total := 0.0
+	consider := func(r *resources.Resource) {
+		bid, ok := s.book.BestBid(r.Production.Name)
+		if !ok {
+			return
+		}
+		margin := bid.UnitPrice - s.unitTransport(r.Location(), bid.Buyer.Location())
+		if margin <= 0 {
+			return
+		}
+		nodes = append(nodes, r)
+		weights = append(weights, margin*r.Production.Rate)
+		total += margin * r.Production.Rate
+	}
+	for _, p := range s.producers {
+		if r, ok := p.(*resources.Resource); ok && !r.Locked && !r.Claimed() && !r.Exhausted() {
+			consider(r)
+		}
+	}
+	fresh := make(map[*resources.Resource]bool)
+	s.land = s.landMap()
+	for _, product := range s.book.Products() {
+		if !resources.PlaceableAnywhere(product) {
+			continue
+		}
+		bid, ok := s.book.BestBid(product)
+		if !ok {
+			continue
+		}
+		loc, ok := s.siteNear(bid.Buyer.Location(), landuse.NodeTileArea, nil)
+		if !ok {
+			continue
+		}
+		site := resources.NewSite(product, loc)
+		fresh[site] = true
+		consider(site)
+	}
+	if len(nodes) == 0 {
+		return
+	}
+
+	pick := s.randSrc.Float64() * total
+	chosen := nodes[len(nodes)-1]
+	cumulative := 0.0
+	for i, weight := range weights {
+		cumulative += weight
+		if pick <= cumulative {
+			chosen = nodes[i]
+			break
+		}
+	}
+
+	kind := s.extractorKindFor(chosen)
+	cost := kind.BuildCost()
+	if s.treasury < cost {
+		l.Debug("extractor skipped: treasury short",
+			slog.Float64("treasury", s.treasury),
+			slog.Float64("buildCost", cost))
+		return
+	}
+	if err := chosen.Claim(kind, s.config.NodeOwner, defaultExtractorClock, s.tick); err != nil {
+		l.Error("failed to claim resource node: " + err.Error())
+		return
+	}
+	s.treasury -= cost
+	s.money.record(channelConstruction, -cost)
+	s.counters.extractors++
+	if fresh[chosen] {
+		s.producers = append(s.producers, chosen)
+	}
+	l.Debug("placed extractor",
+		slog.String("node", chosen.PrettyPrint()),
+		slog.String("extractor", string(kind)))
+}
+
+// extractorKindFor picks the smallest extractor tier that can serve the
+// node's product's residual bid volume within inputStockTargetTicks.
+func (s *State) extractorKindFor(r *resources.Resource) resources.ExtractorKind {
+	demand := 0.0
+	for _, bid := range s.book.Bids(r.Production.Name) {
+		demand += math.Max(0, bid.Remaining)
+	}
+	perTick := demand / inputStockTargetTicks
+
+	kinds := r.ExtractorKinds()
+	for _, kind := range kinds {
+		if r.Production.Rate*kind.TierMultiplier()*defaultExtractorClock >= perTick-production.RateEpsilon {
+			return kind
+		}
+	}
+	return kinds[len(kinds)-1]
+}
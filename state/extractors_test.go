@@ -0,0 +1,110 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
+)
+
+func Test_spawnExtractor_claimsNodeWithDemand(t *testing.T) {
+	wanted := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+	}
+	unwanted := &resources.Resource{
+		Production: production.Production{Name: "Coal", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 500},
+	}
+	smelter := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 100, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	s := newTestStateWithProducers(nil, []production.Producer{wanted, unwanted, smelter})
+	s.book.PostBid(smelter, "OreIron", 30, 5)
+
+	before := s.treasury
+	s.spawnExtractor(testLogger())
+
+	if !wanted.Claimed() {
+		t.Fatal("the node whose product has a standing bid should be claimed")
+	}
+	if unwanted.Claimed() {
+		t.Fatal("a node with no bid for its product must stay unclaimed")
+	}
+	// 30 units over the stock horizon is well under one Mk1's output.
+	if wanted.Extractor.Kind != resources.MinerMk1 {
+		t.Fatalf("extractor = %s, want %s", wanted.Extractor.Kind, resources.MinerMk1)
+	}
+	if got, want := s.treasury, before-resources.MinerMk1.BuildCost(); got != want {
+		t.Fatalf("treasury = %v, want %v (build cost withdrawn)", got, want)
+	}
+}
+
+func Test_spawnExtractor_skipsWhenTreasuryShort(t *testing.T) {
+	node := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+	}
+	smelter := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 100, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	s := newTestStateWithProducers(nil, []production.Producer{node, smelter})
+	s.book.PostBid(smelter, "OreIron", 30, 5)
+	s.treasury = resources.MinerMk1.BuildCost() - 1
+
+	s.spawnExtractor(testLogger())
+
+	if node.Claimed() {
+		t.Fatal("no extractor should be placed when the treasury cannot pay for it")
+	}
+}
+
+func Test_estimatedUnitCost_readsUnclaimedNodeQuote(t *testing.T) {
+	node := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+		AskPrice:   0.4,
+	}
+	s := newTestStateWithProducers(nil, []production.Producer{node})
+
+	if got := s.estimatedUnitCost("OreIron"); got != 0.4 {
+		t.Fatalf("estimatedUnitCost = %v, want the unclaimed node's quote 0.4", got)
+	}
+	if got := s.estimatedUnitCost("Coal"); got != unknownInputUnitCost {
+		t.Fatalf("estimatedUnitCost with no source = %v, want %v", got, unknownInputUnitCost)
+	}
+}
+
+func Test_spawnExtractor_opensWaterSiteNearBidder(t *testing.T) {
+	refinery := factory.New("Refinery", "Recipe_Alumina_C", point.Point{X: 300, Y: 300}, 0,
+		production.Products{production.Production{Name: "Water", Rate: 1}},
+		production.Products{production.Production{Name: "AluminaSolution", Rate: 1}},
+		1000)
+	s := newTestStateWithProducers(nil, []production.Producer{refinery})
+	s.book.PostBid(refinery, "Water", 30, 5)
+
+	s.spawnExtractor(testLogger())
+
+	if len(s.producers) != 2 {
+		t.Fatalf("producers = %d, want 2 (a new water site)", len(s.producers))
+	}
+	site, ok := s.producers[1].(*resources.Resource)
+	if !ok || site.Production.Name != "Water" {
+		t.Fatalf("new producer = %#v, want a Water node", s.producers[1])
+	}
+	if !site.Claimed() || site.Extractor.Kind != resources.WaterExtractor {
+		t.Fatalf("water site extractor = %+v, want a claimed %s", site.Extractor, resources.WaterExtractor)
+	}
+	refineryPlot, _ := plotFor(refinery)
+	sitePlot, _ := plotFor(site)
+	if sitePlot.Overlaps(refineryPlot) {
+		t.Fatalf("water site at %v overlaps its bidder's plot", site.Location())
+	}
+	if d := site.Location().Distance(refinery.Location()); d > 2*float64(refineryPlot.Half+sitePlot.Half) {
+		t.Fatalf("water site is %v away from its bidder, want just clear of it", d)
+	}
+}
@@ -0,0 +1,29 @@
+package http
+
+// Contracts lists the standing supply agreements: those running and the
+// most recently ended.
+type Contracts struct {
+	Tick   int        `json:"tick"`
+	Active []Contract `json:"active"`
+	Ended  []Contract `json:"ended"`
+}
+
+// Contract is one supply agreement and its delivery record so far.
+type Contract struct {
+	ID        int      `json:"id"`
+	Seller    Location `json:"seller"`
+	Buyer     Location `json:"buyer"`
+	Product   string   `json:"product"`
+	Rate      float64  `json:"rate"`
+	UnitPrice float64  `json:"unitPrice"`
+	StartTick int      `json:"startTick"`
+	EndTick   int      `json:"endTick"`
+	Delivered float64  `json:"delivered"`
+	Shortfall float64  `json:"shortfall"`
+	Penalties float64  `json:"penalties"`
+	Breaches  int      `json:"breaches"`
+	// Status is active, expired, terminated (too many breaches in a row)
+	// or void (a party left the world).
+	Status    string `json:"status"`
+	EndedTick int    `json:"endedTick,omitempty"`
+}
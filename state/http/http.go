@@ -9,14 +9,19 @@ import (
 )
 
 type State struct {
-	Resources  []Resource  `json:"resources"`
-	Factories  []Factory   `json:"factories"`
-	Sinks      []Sink      `json:"sinks"`
-	Transports []Transport `json:"transports"`
-	Shortages  []Shortage  `json:"shortages"`
-	Tick       int         `json:"tick"`
-	Running    bool        `json:"running"`
-	Bounds     Bounds      `json:"bounds"`
+	Resources    []Resource    `json:"resources"`
+	Factories    []Factory     `json:"factories"`
+	Sinks        []Sink        `json:"sinks"`
+	MarketMakers []MarketMaker `json:"marketMakers"`
+	Transports   []Transport   `json:"transports"`
+	Shipments    []Shipment    `json:"shipments"`
+	Lines        []Line        `json:"lines"`
+	Hubs         []Hub         `json:"hubs"`
+	HubEdges     []HubEdge     `json:"hubEdges"`
+	Shortages    []Shortage    `json:"shortages"`
+	Tick         int           `json:"tick"`
+	Running      bool          `json:"running"`
+	Bounds       Bounds        `json:"bounds"`
 }
 
 type Bounds struct {
@@ -38,6 +43,14 @@ type Resource struct {
 	Product       string   `json:"product"`
 	Profitability float64  `json:"profitability"`
 	Active        bool     `json:"active"`
+	// Locked is set while the node lies outside the regions the
+	// playthrough has reached.
+	Locked bool `json:"locked"`
+	// Extractor is the building claiming the node, empty when unclaimed;
+	// Owner who holds it, and Cash the extractor company's wallet.
+	Extractor string  `json:"extractor"`
+	Owner     string  `json:"owner"`
+	Cash      float64 `json:"cash"`
 }
 
 type Factory struct {
@@ -53,15 +66,69 @@ type Sink struct {
 	Label    string   `json:"label"`
 }
 
+// MarketMaker is a dealer's depot with its standing two-sided quotes.
+type MarketMaker struct {
+	Location Location      `json:"location"`
+	Label    string        `json:"label"`
+	Cash     float64       `json:"cash"`
+	Quotes   []DealerQuote `json:"quotes"`
+}
+
+// DealerQuote is a market maker's bid and ask for one product, and the
+// inventory it holds.
+type DealerQuote struct {
+	Product   string  `json:"product"`
+	Bid       float64 `json:"bid"`
+	Ask       float64 `json:"ask"`
+	Inventory float64 `json:"inventory"`
+}
+
+// Line is a built rail line between two hubs and what it has earned.
+type Line struct {
+	A           Location `json:"a"`
+	B           Location `json:"b"`
+	BuiltTick   int      `json:"builtTick"`
+	BuildCost   float64  `json:"buildCost"`
+	Carried     float64  `json:"carried"`
+	TollRevenue float64  `json:"tollRevenue"`
+}
+
+// Hub is a train station or truck depot of the hub graph.
+type Hub struct {
+	Name     string   `json:"name"`
+	Kind     string   `json:"kind"`
+	Location Location `json:"location"`
+}
+
+// HubEdge is a leg between two hubs; Rail when it joins two stations,
+// road otherwise.
+type HubEdge struct {
+	From Location `json:"from"`
+	To   Location `json:"to"`
+	Rail bool     `json:"rail"`
+}
+
+// Shipment is a lot of goods in transit; Progress runs from 0 at
+// dispatch to 1 on arrival.
+type Shipment struct {
+	Origin      Location `json:"origin"`
+	Destination Location `json:"destination"`
+	Product     string   `json:"product"`
+	Qty         float64  `json:"qty"`
+	Progress    float64  `json:"progress"`
+}
+
 type Transport struct {
 	Origin      Location `json:"origin"`
 	Destination Location `json:"destination"`
 	Rate        float64  `json:"rate"`
 }
 
+// Location is a point on the map and the region it falls in.
 type Location struct {
-	X int `json:"x"`
-	Y int `json:"y"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Region string `json:"region"`
 }
 
 type Server interface {
@@ -72,6 +139,15 @@ type Server interface {
 	Reset(*slog.Logger, *slog.Level)
 	Recipes(*slog.Logger) []Recipe
 	SetRecipe(*slog.Logger, string, bool) []Recipe
+	Money(*slog.Logger) Money
+	Indicators(*slog.Logger) []Indicators
+	Metrics(*slog.Logger) Metrics
+	Profile(*slog.Logger) Profile
+	Contracts(*slog.Logger) Contracts
+	Logistics(*slog.Logger) Logistics
+	Regions(*slog.Logger) Regions
+	Relocations(*slog.Logger) Relocations
+	Scenarios(*slog.Logger) []Scenario
 }
 
 func Serve(s Server, port string, l *slog.Logger, logLevel *slog.Level) {
@@ -83,6 +159,15 @@ func Serve(s Server, port string, l *slog.Logger, logLevel *slog.Level) {
 	http.HandleFunc("/reset", handleReset(s, l, logLevel))
 	http.HandleFunc("/recipes", handleRecipes(s, l))
 	http.HandleFunc("/recipe/", handleRecipe(s, l))
+	http.HandleFunc("/economy/money", handleMoney(s, l))
+	http.HandleFunc("/economy/indicators", handleIndicators(s, l))
+	http.HandleFunc("/metrics", handleMetrics(s, l))
+	http.HandleFunc("/profile", handleProfile(s, l))
+	http.HandleFunc("/contracts", handleContracts(s, l))
+	http.HandleFunc("/logistics", handleLogistics(s, l))
+	http.HandleFunc("/regions", handleRegions(s, l))
+	http.HandleFunc("/relocations", handleRelocations(s, l))
+	http.HandleFunc("/scenarios", handleScenarios(s, l))
 	http.Handle("/", http.FileServer(http.Dir("frontend/dist")))
 	fmt.Printf("Server running on %s\n", port)
 	if err := http.ListenAndServe(port, nil); err != nil {
@@ -200,6 +285,130 @@ func handleRecipe(s Server, l *slog.Logger) http.HandlerFunc {
 	}
 }
 
+// handleMoney is a closure over a Server that returns the latest
+// money-supply audit.
+func handleMoney(s Server, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(s.Money(l)); err != nil {
+			l.Error("failed to encode money audit: " + err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleIndicators is a closure over a Server that returns the
+// macroeconomic indicator time series, oldest tick first.
+func handleIndicators(s Server, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(s.Indicators(l)); err != nil {
+			l.Error("failed to encode indicators: " + err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleContracts is a closure over a Server that serves the supply
+// contracts.
+func handleContracts(s Server, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(s.Contracts(l)); err != nil {
+			l.Error("failed to encode contracts: " + err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleLogistics is a closure over a Server that serves the transport
+// links.
+func handleLogistics(s Server, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(s.Logistics(l)); err != nil {
+			l.Error("failed to encode logistics: " + err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleRegions is a closure over a Server that serves the per-region
+// aggregates.
+func handleRegions(s Server, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(s.Regions(l)); err != nil {
+			l.Error("failed to encode regions: " + err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleRelocations is a closure over a Server that serves the recent
+// factory relocations.
+func handleRelocations(s Server, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(s.Relocations(l)); err != nil {
+			l.Error("failed to encode relocations: " + err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleScenarios is a closure over a Server that lists the bundled
+// scenarios.
+func handleScenarios(s Server, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(s.Scenarios(l)); err != nil {
+			l.Error("failed to encode scenarios: " + err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleProfile is a closure over a Server that serves the rolling tick
+// profile.
+func handleProfile(s Server, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(s.Profile(l)); err != nil {
+			l.Error("failed to encode profile: " + err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleMetrics is a closure over a Server that serves engine metrics in
+// the Prometheus text format.
+func handleMetrics(s Server, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		if err := writePrometheus(w, s.Metrics(l)); err != nil {
+			l.Error("failed to write metrics: " + err.Error())
+		}
+	}
+}
+
 func setCORSHeaders(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Origin", "http://localhost:8000")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
@@ -0,0 +1,16 @@
+package http
+
+// Indicators are one tick's macroeconomic figures.
+type Indicators struct {
+	Tick         int     `json:"tick"`
+	GDP          float64 `json:"gdp"`
+	PriceIndex   float64 `json:"priceIndex"`
+	Velocity     float64 `json:"velocity"`
+	Gini         float64 `json:"gini"`
+	Population   int     `json:"population"`
+	Births       int     `json:"births"`
+	Deaths       int     `json:"deaths"`
+	BirthRate    float64 `json:"birthRate"`
+	DeathRate    float64 `json:"deathRate"`
+	MeanLifespan float64 `json:"meanLifespan"`
+}
@@ -0,0 +1,21 @@
+package http
+
+// Logistics lists the transport links built so far and how hard each
+// was used on the last tick. Enabled is false when the world runs the
+// flat freight formula instead of a network.
+type Logistics struct {
+	Tick    int    `json:"tick"`
+	Enabled bool   `json:"enabled"`
+	Links   []Link `json:"links"`
+}
+
+// Link is one built transport link.
+type Link struct {
+	Origin      Location `json:"origin"`
+	Destination Location `json:"destination"`
+	Mode        string   `json:"mode"`
+	Capacity    float64  `json:"capacity"`
+	Used        float64  `json:"used"`
+	Carried     float64  `json:"carried"`
+	BuiltTick   int      `json:"builtTick"`
+}
@@ -0,0 +1,24 @@
+package http
+
+// Money is the latest money-supply audit: where the supply sits, and
+// how each channel moved it on the last tick and since the start.
+type Money struct {
+	Tick     int     `json:"tick"`
+	Supply   float64 `json:"supply"`
+	Treasury float64 `json:"treasury"`
+	Wallets  float64 `json:"wallets"`
+	// Opening and Closing are the audited supply before and after the
+	// last tick; Discrepancy is the change no channel accounts for.
+	Opening     float64        `json:"opening"`
+	Closing     float64        `json:"closing"`
+	Discrepancy float64        `json:"discrepancy"`
+	Balanced    bool           `json:"balanced"`
+	Channels    []MoneyChannel `json:"channels"`
+}
+
+// MoneyChannel is one named inflow (positive) or outflow (negative).
+type MoneyChannel struct {
+	Name       string  `json:"name"`
+	LastTick   float64 `json:"lastTick"`
+	Cumulative float64 `json:"cumulative"`
+}
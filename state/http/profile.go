@@ -0,0 +1,25 @@
+package http
+
+// Profile is the rolling timing and allocation summary of recent ticks.
+type Profile struct {
+	// Window is how many ticks the summary covers.
+	Window int            `json:"window"`
+	Phases []PhaseProfile `json:"phases"`
+	// TickSeconds also covers end-of-tick bookkeeping outside the phases.
+	TickSeconds Quantiles `json:"tickSeconds"`
+	Allocs      Quantiles `json:"allocs"`
+	AllocBytes  Quantiles `json:"allocBytes"`
+}
+
+// PhaseProfile is the wall time spent in one tick phase.
+type PhaseProfile struct {
+	Phase   string    `json:"phase"`
+	Seconds Quantiles `json:"seconds"`
+}
+
+// Quantiles summarizes a per-tick measurement over the window.
+type Quantiles struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	Max float64 `json:"max"`
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Metrics is the engine snapshot behind the /metrics endpoint. Counts
+// are monotonic since the last reset.
+type Metrics struct {
+	Tick int
+	// TimedTicks is how many ticks Phases covers.
+	TimedTicks    int
+	Phases        []PhaseSeconds
+	Factories     int
+	Trades        int
+	Spawns        int
+	SkippedSpawns int
+	Bankruptcies  int
+	Extractors    int
+	Relocations   int
+	Treasury      float64
+	Fees          float64
+	Quotes        []Quote
+}
+
+// PhaseSeconds is the total wall time spent in one tick phase.
+type PhaseSeconds struct {
+	Phase   string
+	Seconds float64
+}
+
+// Quote is the post-matching top of book for one product.
+type Quote struct {
+	Product string
+	BestBid float64
+	HasBid  bool
+	BestAsk float64
+	HasAsk  bool
+}
+
+// writePrometheus renders m in the Prometheus text exposition format
+// (version 0.0.4).
+func writePrometheus(w io.Writer, m Metrics) error {
+	var b strings.Builder
+	family := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	family("story_tick", "gauge", "Current simulation tick.")
+	fmt.Fprintf(&b, "story_tick %d\n", m.Tick)
+	family("story_ticks_total", "counter", "Ticks simulated and timed.")
+	fmt.Fprintf(&b, "story_ticks_total %d\n", m.TimedTicks)
+	family("story_tick_phase_seconds_total", "counter", "Wall time spent in each tick phase.")
+	for _, phase := range m.Phases {
+		fmt.Fprintf(&b, "story_tick_phase_seconds_total{phase=\"%s\"} %g\n", escapeLabel(phase.Phase), phase.Seconds)
+	}
+	family("story_factories", "gauge", "Live factories.")
+	fmt.Fprintf(&b, "story_factories %d\n", m.Factories)
+	family("story_trades_total", "counter", "Executed trades.")
+	fmt.Fprintf(&b, "story_trades_total %d\n", m.Trades)
+	family("story_spawns_total", "counter", "Factories spawned.")
+	fmt.Fprintf(&b, "story_spawns_total %d\n", m.Spawns)
+	family("story_spawns_skipped_total", "counter", "Spawns skipped because the treasury could not fund the seed.")
+	fmt.Fprintf(&b, "story_spawns_skipped_total %d\n", m.SkippedSpawns)
+	family("story_bankruptcies_total", "counter", "Factories removed as bankrupt.")
+	fmt.Fprintf(&b, "story_bankruptcies_total %d\n", m.Bankruptcies)
+	family("story_extractors_built_total", "counter", "Extractors placed on resource nodes.")
+	fmt.Fprintf(&b, "story_extractors_built_total %d\n", m.Extractors)
+	family("story_relocations_total", "counter", "Factories relocated to a new site.")
+	fmt.Fprintf(&b, "story_relocations_total %d\n", m.Relocations)
+	family("story_treasury", "gauge", "Treasury balance.")
+	fmt.Fprintf(&b, "story_treasury %g\n", m.Treasury)
+	family("story_exchange_fees_total", "counter", "Exchange fees paid to the treasury.")
+	fmt.Fprintf(&b, "story_exchange_fees_total %g\n", m.Fees)
+	family("story_best_bid", "gauge", "Highest unfilled bid price per product after matching.")
+	for _, q := range m.Quotes {
+		if q.HasBid {
+			fmt.Fprintf(&b, "story_best_bid{product=\"%s\"} %g\n", escapeLabel(q.Product), q.BestBid)
+		}
+	}
+	family("story_best_ask", "gauge", "Lowest unfilled ask price per product after matching.")
+	for _, q := range m.Quotes {
+		if q.HasAsk {
+			fmt.Fprintf(&b, "story_best_ask{product=\"%s\"} %g\n", escapeLabel(q.Product), q.BestAsk)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeLabel escapes a label value per the exposition format.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_writePrometheus_namesAndValues(t *testing.T) {
+	m := Metrics{
+		Tick:          42,
+		TimedTicks:    40,
+		Phases:        []PhaseSeconds{{Phase: "match", Seconds: 1.5}},
+		Factories:     3,
+		Trades:        7,
+		Spawns:        4,
+		SkippedSpawns: 1,
+		Bankruptcies:  2,
+		Extractors:    5,
+		Relocations:   6,
+		Treasury:      1234.5,
+		Fees:          0.25,
+		Quotes: []Quote{
+			{Product: "IronPlate", HasBid: true, BestBid: 12, HasAsk: true, BestAsk: 9.5},
+			{Product: `Odd"Name`, HasBid: true, BestBid: 3},
+		},
+	}
+	var b strings.Builder
+	if err := writePrometheus(&b, m); err != nil {
+		t.Fatalf("writePrometheus: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"# TYPE story_tick gauge\nstory_tick 42\n",
+		"# TYPE story_ticks_total counter\nstory_ticks_total 40\n",
+		`story_tick_phase_seconds_total{phase="match"} 1.5` + "\n",
+		"story_factories 3\n",
+		"story_trades_total 7\n",
+		"story_spawns_total 4\n",
+		"story_spawns_skipped_total 1\n",
+		"story_bankruptcies_total 2\n",
+		"story_extractors_built_total 5\n",
+		"story_relocations_total 6\n",
+		"story_treasury 1234.5\n",
+		"story_exchange_fees_total 0.25\n",
+		`story_best_bid{product="IronPlate"} 12` + "\n",
+		`story_best_ask{product="IronPlate"} 9.5` + "\n",
+		`story_best_bid{product="Odd\"Name"} 3` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `story_best_ask{product="Odd\"Name"}`) {
+		t.Errorf("a product with no ask should have no best-ask sample:\n%s", out)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, "story_") || len(strings.Fields(line)) != 2 {
+			t.Errorf("malformed sample line %q", line)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package http
+
+// Regions aggregates the economy by map region. Exports, Imports and
+// Internal are units per tick over the recent trade window: sold out of
+// the region, bought into it, and traded within it.
+type Regions struct {
+	Tick    int      `json:"tick"`
+	Regions []Region `json:"regions"`
+}
+
+// Region is one map region's totals. Name is empty for locations that
+// fall in no region; Locked counts the resource nodes the playthrough
+// has not reached yet.
+type Region struct {
+	Name      string  `json:"name"`
+	Resources int     `json:"resources"`
+	Locked    int     `json:"locked"`
+	Claimed   int     `json:"claimed"`
+	Factories int     `json:"factories"`
+	Cash      float64 `json:"cash"`
+	Exports   float64 `json:"exports"`
+	Imports   float64 `json:"imports"`
+	Internal  float64 `json:"internal"`
+}
@@ -0,0 +1,17 @@
+package http
+
+// Relocations lists the most recent factory relocations, oldest first.
+type Relocations struct {
+	Tick   int          `json:"tick"`
+	Events []Relocation `json:"events"`
+}
+
+// Relocation is one factory's move to a new site and what it paid.
+type Relocation struct {
+	Tick     int      `json:"tick"`
+	Factory  string   `json:"factory"`
+	From     Location `json:"from"`
+	To       Location `json:"to"`
+	Distance float64  `json:"distance"`
+	Cost     float64  `json:"cost"`
+}
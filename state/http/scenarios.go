@@ -0,0 +1,9 @@
+package http
+
+// Scenario is a bundled starting economy, loadable by its File name
+// with cmd/story -scenario.
+type Scenario struct {
+	File        string `json:"file"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
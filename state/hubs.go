@@ -0,0 +1,52 @@
+package state
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/paul-freeman/satisfactory-story/logistics"
+	"github.com/paul-freeman/satisfactory-story/point"
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+)
+
+// newHubGraph builds the hub graph for Config.HubRouting: from
+// Config.HubFile when one is named, otherwise clustered from the
+// resource nodes at nodes.
+func (s *State) newHubGraph(nodes []point.Point) (*logistics.HubGraph, error) {
+	if s.config.HubFile == "" {
+		return logistics.ClusterHubs(nodes, s.config.HubClusters)
+	}
+	f, err := os.Open(s.config.HubFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hub file: %w", err)
+	}
+	defer f.Close()
+	return logistics.LoadHubGraph(f)
+}
+
+// hubsForWire lists the hub graph's hubs and the legs joining them.
+func (s *State) hubsForWire() ([]statehttp.Hub, []statehttp.HubEdge) {
+	hubs := make([]statehttp.Hub, 0)
+	edges := make([]statehttp.HubEdge, 0)
+	if s.hubs == nil {
+		return hubs, edges
+	}
+	at := make(map[string]logistics.Hub)
+	for _, h := range s.hubs.Hubs() {
+		at[h.Name] = h
+		hubs = append(hubs, statehttp.Hub{
+			Name:     h.Name,
+			Kind:     string(h.Kind),
+			Location: s.location(h.Location()),
+		})
+	}
+	for _, e := range s.hubs.Edges() {
+		from, to := at[e.From], at[e.To]
+		edges = append(edges, statehttp.HubEdge{
+			From: s.location(from.Location()),
+			To:   s.location(to.Location()),
+			Rail: from.Kind == logistics.Station && to.Kind == logistics.Station,
+		})
+	}
+	return hubs, edges
+}
@@ -0,0 +1,50 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+)
+
+func Test_unitTransport_hubRouting(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "hubs.json")
+	if err := os.WriteFile(file, []byte(`{
+		"hubs": [
+			{"name": "West", "kind": "station", "x": 0, "y": 0},
+			{"name": "Middle", "kind": "station", "x": 100000, "y": 0},
+			{"name": "East", "kind": "station", "x": 200000, "y": 0}
+		],
+		"edges": [{"from": "West", "to": "Middle"}, {"from": "Middle", "to": "East"}]
+	}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestState()
+	s.config.HubFile = file
+	hubs, err := s.newHubGraph(nil)
+	if err != nil {
+		t.Fatalf("newHubGraph: %v", err)
+	}
+	s.hubs = hubs
+
+	origin, destination := point.Point{X: 100, Y: 100}, point.Point{X: 200100, Y: 100}
+	flat := recipes.UnitTransportCost(origin, destination)
+	cost := s.unitTransport(origin, destination)
+	if cost >= flat {
+		t.Fatalf("unitTransport = %v, want the hub path under the road's %v", cost, flat)
+	}
+	if _, line := s.corridor(origin, destination); line != nil {
+		t.Fatalf("corridor took line %v, want the hub path (no line, no toll)", line)
+	}
+	if floor := s.transportFloor(origin.Distance(destination)); floor > cost {
+		t.Fatalf("transportFloor = %v, above the hub path's %v", floor, cost)
+	}
+
+	// A short hop stays on the road.
+	near := point.Point{X: 5100, Y: 100}
+	if got, want := s.unitTransport(origin, near), recipes.UnitTransportCost(origin, near); got != want {
+		t.Fatalf("unitTransport = %v for a short hop, want the road's %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package state
+
+import (
+	"log/slog"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+	"github.com/paul-freeman/satisfactory-story/state/metrics"
+)
+
+// indicatorHistoryTicks is how many ticks of macroeconomic indicators
+// the time series keeps.
+const indicatorHistoryTicks = 5000
+
+// recordIndicators samples the tick that just ran into the indicator
+// series. Runs last in Tick, after the money audit has closed.
+func (s *State) recordIndicators() {
+	sample := metrics.Sample{
+		Tick:        s.tick,
+		MoneySupply: s.moneySupply(),
+		Wallets:     make([]float64, 0),
+		Lifespans:   s.tickLifespans,
+	}
+	for _, tr := range s.ledger.trades {
+		if tr.tick != s.tick {
+			continue
+		}
+		sample.Trades = append(sample.Trades, metrics.Trade{
+			Product: tr.product, Qty: tr.qty, UnitPrice: tr.unitPrice,
+		})
+	}
+	for _, p := range s.producers {
+		f, ok := p.(*factory.Factory)
+		if !ok {
+			continue
+		}
+		sample.Wallets = append(sample.Wallets, f.Cash())
+		if f.CreatedTick == s.tick {
+			sample.Births++
+		}
+	}
+	s.indicators.Record(sample)
+	s.tickLifespans = nil
+}
+
+func (s *State) Indicators(_ *slog.Logger) []statehttp.Indicators {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	series := s.indicators.Series()
+	out := make([]statehttp.Indicators, 0, len(series))
+	for _, ind := range series {
+		out = append(out, statehttp.Indicators{
+			Tick:         ind.Tick,
+			GDP:          ind.GDP,
+			PriceIndex:   ind.PriceIndex,
+			Velocity:     ind.Velocity,
+			Gini:         ind.Gini,
+			Population:   ind.Population,
+			Births:       ind.Births,
+			Deaths:       ind.Deaths,
+			BirthRate:    ind.BirthRate,
+			DeathRate:    ind.DeathRate,
+			MeanLifespan: ind.MeanLifespan,
+		})
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+func Test_recordIndicators_samplesTheTick(t *testing.T) {
+	s := newTestState()
+	s.tick = 50
+	old := factory.New("Old", "Recipe_Old_C", point.Point{X: 0, Y: 0}, 10,
+		production.Products{}, production.Products{}, 100)
+	born := factory.New("New", "Recipe_New_C", point.Point{X: 9, Y: 9}, 50,
+		production.Products{}, production.Products{}, 300)
+	s.producers = []production.Producer{old, born}
+	s.ledger.record(49, old, born, "Ore", 100, 1) // last tick: not sampled
+	s.ledger.record(50, old, born, "Ore", 4, 2.5)
+	s.tickLifespans = []int{25}
+
+	s.recordIndicators()
+
+	series := s.indicators.Series()
+	if len(series) != 1 {
+		t.Fatalf("series length = %d, want 1", len(series))
+	}
+	got := series[0]
+	if got.Tick != 50 || got.GDP != 10 {
+		t.Fatalf("tick/GDP = %d/%v, want 50/10 (only this tick's trades)", got.Tick, got.GDP)
+	}
+	if got.Population != 2 || got.Births != 1 || got.Deaths != 1 || got.MeanLifespan != 25 {
+		t.Fatalf("demographics = %+v, want 2 live, 1 born, 1 died aged 25", got)
+	}
+	if s.tickLifespans != nil {
+		t.Fatal("tickLifespans should be reset for the next tick")
+	}
+}
+
+func Test_SetRecipe_countsRemovedFactoriesAsDeaths(t *testing.T) {
+	s := newTestState()
+	s.tick = 40
+	f := factory.New("Smelt", "Recipe_Smelt_C", point.Point{X: 0, Y: 0}, 10,
+		production.Products{}, production.Products{}, 100)
+	s.producers = []production.Producer{f}
+
+	s.SetRecipe(testLogger(), "Recipe_Smelt_C", false)
+	s.recordIndicators()
+
+	got := s.indicators.Series()[0]
+	if got.Population != 0 || got.Deaths != 1 || got.MeanLifespan != 30 {
+		t.Fatalf("demographics = %+v, want the removed factory counted as 1 death aged 30", got)
+	}
+}
+
+func Test_SetRecipe_concurrentWithTick(t *testing.T) {
+	s := newCascadeState(t, DefaultConfig(), "Plate")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 500; i++ {
+			if err := s.Tick(testLogger()); err != nil {
+				t.Errorf("tick %d failed: %v", i, err)
+				return
+			}
+		}
+	}()
+	for enabled := false; ; enabled = !enabled {
+		select {
+		case <-done:
+			if !s.money.balanced() {
+				t.Fatalf("money out of balance by %v", s.money.last.discrepancy)
+			}
+			return
+		default:
+			s.SetRecipe(testLogger(), "Recipe_Smelt_C", enabled)
+		}
+	}
+}
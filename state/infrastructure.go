@@ -0,0 +1,94 @@
+package state
+
+import (
+	"log/slog"
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/logistics"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+)
+
+// lineSpawnProbabilityPerTick is the chance, per tick, that the busiest
+// unserved trade corridor is considered for a rail line.
+const lineSpawnProbabilityPerTick = 0.01
+
+// lineBuildCost is what the treasury pays, once, to build a line;
+// lineTollPerUnit is what the line then charges every unit it carries,
+// paid back to the treasury.
+const (
+	lineBuildCost   = 3000.0
+	lineTollPerUnit = 0.05
+)
+
+// linePaybackTicks bounds how long a line may take to earn its build
+// cost back in tolls at the flow that justifies it. Longer would let the
+// treasury sink its funds into corridors that never repay them.
+const linePaybackTicks = 20000
+
+// corridor returns the per-unit flat freight from origin to destination
+// over the cheapest of the direct road, every built line and the hub
+// graph, and the line taken (nil for the road or the hubs).
+func (s *State) corridor(origin, destination point.Point) (float64, *logistics.Line) {
+	best := s.roadCost(origin, destination)
+	var taken *logistics.Line
+	for _, ln := range s.lines {
+		if cost := ln.Via(origin, destination, s.roadCost); cost < best {
+			best, taken = cost, ln
+		}
+	}
+	if s.hubs != nil {
+		if cost := s.hubs.Via(origin, destination, s.roadCost); cost < best {
+			best, taken = cost, nil
+		}
+	}
+	return best, taken
+}
+
+// corridorFloor is the least corridor cost over any two points distance
+// apart.
+func (s *State) corridorFloor(distance float64) float64 {
+	floor := recipes.UnitTransportFloor(distance)
+	for _, ln := range s.lines {
+		floor = math.Min(floor, ln.Floor(distance, recipes.UnitTransportFloor))
+	}
+	if s.hubs != nil {
+		floor = math.Min(floor, s.hubs.Floor(distance, recipes.UnitTransportFloor))
+	}
+	return floor
+}
+
+// spawnLine builds a rail line, paid for by the treasury, along the
+// busiest recent trade flow that a line would carry and whose tolls
+// would repay the build cost within linePaybackTicks. The hubs sit at
+// the flow's seller and buyer, so the line serves every trade near
+// either end.
+func (s *State) spawnLine(l *slog.Logger) {
+	if s.treasury < lineBuildCost {
+		return
+	}
+	window := float64(max(1, min(s.tick, tradeMemoryTicks)))
+	var best *logistics.Line
+	bestRevenue := 0.0
+	for _, edge := range s.ledger.edges() {
+		origin, destination := edge.seller.Location(), edge.buyer.Location()
+		candidate := logistics.NewLine(origin, destination, s.tick, lineBuildCost, lineTollPerUnit)
+		if current, _ := s.corridor(origin, destination); candidate.Via(origin, destination, s.roadCost) >= current {
+			continue // the flow would not take it
+		}
+		revenue := edge.qty / window * lineTollPerUnit * linePaybackTicks
+		if revenue >= lineBuildCost && revenue > bestRevenue {
+			best, bestRevenue = candidate, revenue
+		}
+	}
+	if best == nil {
+		return
+	}
+	s.treasury -= lineBuildCost
+	s.money.record(channelConstruction, -lineBuildCost)
+	s.lines = append(s.lines, best)
+	s.producers = append(s.producers, best)
+	l.Debug("built line",
+		slog.String("line", best.String()),
+		slog.Float64("projectedTolls", bestRevenue))
+}
@@ -0,0 +1,94 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/logistics"
+	"github.com/paul-freeman/satisfactory-story/market"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+	"github.com/paul-freeman/satisfactory-story/resources"
+)
+
+func Test_spawnLine_buildsWhereFlowsPay(t *testing.T) {
+	s := newTestState()
+	s.tick = tradeMemoryTicks
+	r := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+	}
+	f := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 100000, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	s.producers = []production.Producer{r, f}
+
+	// 1 unit a tick pays 0.05 x 20000 = 1000 in tolls: not enough.
+	s.ledger.record(s.tick, r, f, "OreIron", tradeMemoryTicks, 1)
+	s.spawnLine(testLogger())
+	if len(s.lines) != 0 {
+		t.Fatalf("built %d lines for a flow that cannot repay one", len(s.lines))
+	}
+
+	// 4 units a tick would repay it.
+	s.ledger.record(s.tick, r, f, "OreIron", 3*tradeMemoryTicks, 1)
+	before := s.treasury
+	s.spawnLine(testLogger())
+	if len(s.lines) != 1 || s.producers[len(s.producers)-1] != s.lines[0] {
+		t.Fatalf("lines = %v, want one built and added to the producers", s.lines)
+	}
+	if got := before - s.treasury; got != lineBuildCost {
+		t.Fatalf("treasury paid %v, want the build cost %v", got, lineBuildCost)
+	}
+	if cost, line := s.corridor(r.Location(), f.Location()); line != s.lines[0] || cost >= recipes.UnitTransportCost(r.Location(), f.Location()) {
+		t.Fatalf("corridor = %v via %v, want the line undercutting the road", cost, line)
+	}
+
+	// The corridor is served now; no second line for it.
+	s.spawnLine(testLogger())
+	if len(s.lines) != 1 {
+		t.Fatalf("built %d lines, want the served corridor left alone", len(s.lines))
+	}
+}
+
+func Test_executeTrade_payLineToll(t *testing.T) {
+	s := newTestState()
+	r := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+		Stock:      10,
+	}
+	f := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 100000, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	line := logistics.NewLine(point.Point{X: 5, Y: 5}, point.Point{X: 100005, Y: 5}, 0, lineBuildCost, lineTollPerUnit)
+	s.lines = []*logistics.Line{line}
+	s.producers = []production.Producer{r, f, line}
+	before := s.treasury
+	s.money.open(s.moneySupply())
+
+	qty, err := s.executeTrade(testLogger(), market.Match{
+		Seller: r, Buyer: f,
+		Order:         production.Production{Name: "OreIron", Rate: 10},
+		UnitPrice:     1,
+		UnitTransport: s.unitTransport(r.Location(), f.Location()),
+	}, nil)
+	if err != nil || qty != 10 {
+		t.Fatalf("executeTrade = %v, %v; want 10, nil", qty, err)
+	}
+	// The node is state-held, so the treasury takes the sale and the
+	// tolls.
+	if got, want := s.treasury-before, 10+10*lineTollPerUnit; got < want-1e-9 || got > want+1e-9 {
+		t.Fatalf("treasury gain = %v, want %v (sale plus tolls)", got, want)
+	}
+	if line.Carried != 10 || line.TollRevenue != 10*lineTollPerUnit {
+		t.Fatalf("line carried %v, earned %v; want 10 and %v", line.Carried, line.TollRevenue, 10*lineTollPerUnit)
+	}
+	s.money.close(s.tick, s.moneySupply())
+	if !s.money.balanced() {
+		t.Fatalf("money moved %v beyond its recorded flows: tolls must not leave with the freight", s.money.last.discrepancy)
+	}
+}
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/landuse"
+	"github.com/paul-freeman/satisfactory-story/marketmaker"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/sink"
+)
+
+// landRentRadius is how far from a factory's plot, in distance, other
+// buildings count towards the density its land rent scales with.
+const landRentRadius = 2000.0
+
+// plotFor returns the plot a producer stands on: factories by their
+// building's footprint, resource nodes by the tile reserved for their
+// extractor. Rail lines hold no plot.
+func plotFor(p production.Producer) (landuse.Plot, bool) {
+	switch p := p.(type) {
+	case *factory.Factory:
+		return landuse.PlotFor(p.Location(), p.Building.Footprint()), true
+	case *resources.Resource:
+		return landuse.PlotFor(p.Location(), landuse.NodeTileArea), true
+	case *sink.Sink:
+		return landuse.PlotFor(p.Location(), landuse.SinkArea), true
+	case *marketmaker.MarketMaker:
+		return landuse.PlotFor(p.Location(), landuse.DepotArea), true
+	}
+	return landuse.Plot{}, false
+}
+
+// landMap returns the land every producer holds right now. Placement
+// rebuilds it at the start of each phase that places buildings, so
+// producers removed since are forgotten.
+func (s *State) landMap() *landuse.Map {
+	land := landuse.NewMap()
+	for _, p := range s.producers {
+		if plot, ok := plotFor(p); ok {
+			land.Add(p, plot)
+		}
+	}
+	return land
+}
+
+// siteNear returns the free site nearest want for a building of
+// footprint area, ignoring the land ignore already holds; on terrain,
+// only buildable sites qualify. It fails when there is no free site
+// close enough.
+func (s *State) siteNear(want point.Point, area float64, ignore production.Producer) (point.Point, bool) {
+	var allowed func(point.Point) bool
+	if s.terrain != nil {
+		allowed = s.terrain.Passable
+	}
+	return s.land.SiteNear(s.passableLocation(want), area, ignore, allowed)
+}
+
+// placeFactory lets f choose where it moves to only among the sites the
+// land map leaves free, weighing the freight it would save at the
+// world's transport cost.
+func (s *State) placeFactory(f *factory.Factory) {
+	f.Place = func(want point.Point) (point.Point, bool) {
+		return s.siteNear(want, f.Building.Footprint(), f)
+	}
+	f.Transport = s.unitTransport
+}
+
+// landRent is what a factory pays per tick for its land under
+// Config.LandRent: the rate per square metre of footprint, times how
+// many other buildings crowd within landRentRadius of it.
+func (s *State) landRent(f *factory.Factory) float64 {
+	if s.config.LandRent <= 0 {
+		return 0
+	}
+	plot := landuse.PlotFor(f.Location(), f.Building.Footprint())
+	return s.config.LandRent * f.Building.Footprint() * float64(s.land.Neighbours(plot, landRentRadius, f))
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+	"github.com/paul-freeman/satisfactory-story/resources"
+)
+
+func testSmelter(loc point.Point) *factory.Factory {
+	f := factory.New("Smelter", "Recipe_IngotIron_C", loc, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	f.Building = recipes.Smelter
+	return f
+}
+
+func Test_moveProducers_undoesMoveOntoTakenLand(t *testing.T) {
+	s := newTestState()
+	s.tick = 3
+	// Both factories trade only with the same partner, so both pick its
+	// site; the first in producer order gets it.
+	first, second := testSmelter(point.Point{X: 0, Y: 0}), testSmelter(point.Point{X: 0, Y: 40000})
+	partner := point.Point{X: 50000, Y: 0}
+	for _, f := range []*factory.Factory{first, second} {
+		s.placeFactory(f)
+		f.RecordTrade(s.tick, partner, 5)
+	}
+	s.producers = []production.Producer{first, second}
+	s.money.open(s.moneySupply())
+
+	s.moveProducers(testLogger())
+
+	s.money.close(s.tick, s.moneySupply())
+	if !s.money.balanced() {
+		t.Fatalf("money out of balance by %v", s.money.last.discrepancy)
+	}
+	if first.Loc != partner || first.LastRelocation == nil {
+		t.Fatalf("first factory at %v, want moved to %v", first.Loc, partner)
+	}
+	if second.Loc != (point.Point{X: 0, Y: 40000}) || second.LastRelocation != nil || second.Cash() != 1000 {
+		t.Fatalf("second factory at %v with %v cash, want its move undone and refunded", second.Loc, second.Cash())
+	}
+	if len(s.relocations) != 1 {
+		t.Fatalf("relocations = %+v, want only the first move", s.relocations)
+	}
+}
+
+func Test_spawnNewProducer_neverOverlaps(t *testing.T) {
+	// Every spawn wants the same site, beside the one sourceable node.
+	ore := &resources.Resource{
+		Production: production.Production{Name: "Ore", Rate: 100},
+		Loc:        point.Point{X: 700, Y: 300},
+		Stock:      10,
+	}
+	rs := recipes.Recipes{{
+		ClassName:      "Recipe_Smelt_C",
+		DisplayName:    "Smelt Ore",
+		Active:         true,
+		InputProducts:  production.Products{{Name: "Ore", Rate: 5}},
+		OutputProducts: production.Products{{Name: "Ingot", Rate: 5}},
+	}}
+	s := newTestStateWithProducers(rs, []production.Producer{ore})
+	s.publishOrders(testLogger())
+	for i := 0; i < 10; i++ {
+		s.spawnNewProducer(testLogger())
+	}
+
+	if s.counters.spawns < 5 {
+		t.Fatalf("spawns = %d (%d skipped), want the crowd to find room", s.counters.spawns, s.counters.skippedSpawns)
+	}
+	land := s.landMap()
+	for _, p := range s.producers {
+		if plot, _ := plotFor(p); !land.Free(plot, p) {
+			t.Fatalf("%v overlaps another building", p)
+		}
+	}
+}
+
+func Test_applySolvency_landRent(t *testing.T) {
+	s := newTestState()
+	s.config.LandRent = 0.01
+	a, b := testSmelter(point.Point{X: 0, Y: 0}), testSmelter(point.Point{X: 500, Y: 0})
+	far := testSmelter(point.Point{X: 50000, Y: 0})
+	s.producers = []production.Producer{a, b, far}
+	treasury := s.treasury
+
+	s.applySolvency(testLogger())
+
+	rent := 0.01 * recipes.Smelter.Footprint() // one neighbour
+	if got, want := a.Cash(), 1000-upkeepPerTick-rent; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("crowded factory cash = %v, want %v after upkeep and rent", got, want)
+	}
+	if got, want := far.Cash(), 1000-upkeepPerTick; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("lone factory cash = %v, want %v after upkeep only", got, want)
+	}
+	if got, want := s.treasury-treasury, 3*upkeepPerTick+2*rent; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("treasury gained %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"log/slog"
+
+	"github.com/paul-freeman/satisfactory-story/logistics"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+)
+
+// unitTransport is the per-unit freight from origin to destination: the
+// cheapest logistics route with room left this tick when the world runs
+// a network (+Inf if there is none), otherwise the flat formula over the
+// cheapest of the road, any built line and the hub graph (see corridor).
+func (s *State) unitTransport(origin, destination point.Point) float64 {
+	if s.network != nil {
+		return s.network.UnitCost(origin, destination)
+	}
+	if len(s.lines) > 0 || s.hubs != nil {
+		cost, _ := s.corridor(origin, destination)
+		return cost
+	}
+	return s.roadCost(origin, destination)
+}
+
+// canBuildLink reports whether the treasury, which builds the network's
+// links, can afford a link by mode.
+func (s *State) canBuildLink(mode logistics.Mode) bool {
+	return s.treasury >= logistics.SpecFor(mode).BuildCost
+}
+
+// transportFloor is the least unitTransport over any two points distance
+// apart, for the book's spatial index.
+func (s *State) transportFloor(distance float64) float64 {
+	if s.network != nil {
+		return s.network.UnitFloor(distance)
+	}
+	if len(s.lines) > 0 || s.hubs != nil {
+		return s.corridorFloor(distance)
+	}
+	return recipes.UnitTransportFloor(distance)
+}
+
+func (s *State) Logistics(_ *slog.Logger) statehttp.Logistics {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	out := statehttp.Logistics{
+		Tick:    s.tick,
+		Enabled: s.network != nil,
+		Links:   make([]statehttp.Link, 0),
+	}
+	if s.network == nil {
+		return out
+	}
+	for _, l := range s.network.Links() {
+		out.Links = append(out.Links, statehttp.Link{
+			Origin:      s.location(l.Origin),
+			Destination: s.location(l.Destination),
+			Mode:        string(l.Mode),
+			Capacity:    logistics.SpecFor(l.Mode).Capacity,
+			Used:        l.Used,
+			Carried:     l.Carried,
+			BuiltTick:   l.BuiltTick,
+		})
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/paul-freeman/satisfactory-story/landuse"
+	"github.com/paul-freeman/satisfactory-story/marketmaker"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// marketMakerSeedCapital is what the treasury lends the dealer to buy
+// its first inventory with. A transfer: the money supply is unchanged.
+const marketMakerSeedCapital = 2000.0
+
+// newMarketMaker places the configured dealer's yard on the free site
+// nearest the goal sinks at base, funded out of the treasury. Its mids
+// are capped at the goal bid: no stock is worth more to it than final
+// demand pays. It returns nil when no products are configured, the
+// treasury cannot fund it, or no site is free, and fails on a product
+// no recipe produces.
+func (s *State) newMarketMaker(base point.Point) (*marketmaker.MarketMaker, error) {
+	for _, name := range s.config.MarketMakerProducts {
+		if !s.produced(name) {
+			return nil, fmt.Errorf("market maker: no recipe produces %q", name)
+		}
+	}
+	if len(s.config.MarketMakerProducts) == 0 || s.treasury < marketMakerSeedCapital {
+		return nil, nil
+	}
+	site, ok := s.siteNear(base, landuse.DepotArea, nil)
+	if !ok {
+		return nil, nil
+	}
+	stocked := make(production.Products, 0, len(s.config.MarketMakerProducts))
+	for _, name := range s.config.MarketMakerProducts {
+		stocked = append(stocked, production.Production{Name: name, Rate: s.config.MarketMakerTarget})
+	}
+	s.treasury -= marketMakerSeedCapital
+	mm := marketmaker.New("Depot", site, stocked, s.config.MarketMakerSpreadPct, marketMakerSeedCapital)
+	mm.MaxMid = goalBidUnitPrice
+	return mm, nil
+}
+
+// produced reports whether any recipe, active or not, makes the named
+// product.
+func (s *State) produced(name string) bool {
+	for _, r := range s.recipes {
+		if r.Outputs().Contains(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// postQuotes puts the dealer's two-sided quotes in the book: everything
+// it holds offered at its ask, its shortfall to target bid for at its
+// bid.
+func (s *State) postQuotes(mm *marketmaker.MarketMaker) {
+	for _, p := range mm.Stocked {
+		s.book.PostAsk(mm, p.Name, mm.Inventory.Get(p.Name), mm.AskPrice(p.Name))
+		s.book.PostBid(mm, p.Name, mm.Shortfall(p.Name), mm.BidPrice(p.Name))
+	}
+}
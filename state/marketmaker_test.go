@@ -0,0 +1,95 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/marketmaker"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+	"github.com/paul-freeman/satisfactory-story/sink"
+)
+
+func Test_marketMaker_buysAndSellsThroughTheBook(t *testing.T) {
+	s := newTestState()
+	mm := marketmaker.New("Depot", point.Point{X: 0, Y: 0},
+		production.Products{{Name: "IronIngot", Rate: 20}}, 0.2, 100)
+	seller := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 100, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		0)
+	seller.OutputStock.Add("IronIngot", 5)
+	seller.SetAskPrice("IronIngot", 0.5) // under the dealer's 0.9 bid
+	s.producers = []production.Producer{seller, mm}
+
+	s.publishOrders(testLogger())
+	if bid, ok := s.book.BestBid("IronIngot"); !ok || bid.Buyer != mm || bid.Remaining != 20 || bid.UnitPrice != 0.9 {
+		t.Fatalf("best bid = %+v (ok=%v), want the dealer's 20 at 0.9", bid, ok)
+	}
+	s.matchOrders(testLogger())
+	if got := mm.Inventory.Get("IronIngot"); got != 5 {
+		t.Fatalf("dealer inventory = %v, want the seller's 5", got)
+	}
+
+	// Next tick the dealer offers what it holds to a sink at its ask.
+	goal := sink.New("IronIngot", point.Point{X: 0, Y: 100},
+		production.Products{{Name: "IronIngot", Rate: 1}}, 10)
+	s.producers = append(s.producers, goal)
+	s.tick++
+	s.publishOrders(testLogger())
+	if ask, ok := s.book.BestAsk("IronIngot"); !ok || ask.Seller != mm || ask.Remaining != 5 || ask.UnitPrice != 1.1 {
+		t.Fatalf("best ask = %+v (ok=%v), want the dealer's 5 at 1.1", ask, ok)
+	}
+	cashBefore := mm.Cash()
+	s.matchOrders(testLogger())
+	if got := goal.TotalDelivered(); got != 5 {
+		t.Fatalf("sink delivered = %v, want 5", got)
+	}
+	if got := mm.Cash() - cashBefore; got < 5.49 || got > 5.51 { // 5 at 1.1
+		t.Fatalf("dealer takings = %v, want 5.5", got)
+	}
+}
+
+func Test_newMarketMaker_rejectsUnproducedProducts(t *testing.T) {
+	rs := recipes.Recipes{
+		{
+			ClassName:      "Recipe_Smelt_C",
+			DisplayName:    "Smelt Ore",
+			Active:         true,
+			InputProducts:  production.Products{{Name: "Ore", Rate: 5}},
+			OutputProducts: production.Products{{Name: "Ingot", Rate: 5}},
+		},
+	}
+	s := newTestStateWithProducers(rs, nil)
+	s.land = s.landMap()
+	s.config.MarketMakerProducts = []string{"Ingot"}
+	mm, err := s.newMarketMaker(point.Point{X: 500, Y: 500})
+	if err != nil || mm == nil {
+		t.Fatalf("newMarketMaker = %v, %v; want a dealer in Ingot", mm, err)
+	}
+	if mm.MaxMid != goalBidUnitPrice {
+		t.Fatalf("MaxMid = %v, want the goal bid %v", mm.MaxMid, goalBidUnitPrice)
+	}
+
+	s.config.MarketMakerProducts = []string{"Ingot", "Ingt"}
+	if _, err := s.newMarketMaker(point.Point{X: 500, Y: 500}); err == nil {
+		t.Fatal("newMarketMaker accepted a product no recipe produces")
+	}
+}
+
+// Test_cascade_marketMaker: a dealer stocking the intermediate must not
+// stop the cascade closing, and money stays conserved with it and the
+// exchange fee in play.
+func Test_cascade_marketMaker(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FeePct = 0.01
+	s := newCascadeState(t, cfg, "Plate")
+	s.producers = append(s.producers, marketmaker.New("Depot", point.Point{X: 500, Y: 500},
+		production.Products{{Name: "Ingot", Rate: 20}}, 0.1, 200))
+
+	runCascade(t, s, 5000, nil)
+	if s.counters.fees <= 0 {
+		t.Fatal("no exchange fees collected")
+	}
+}
@@ -0,0 +1,176 @@
+// Package metrics computes economy-wide indicators from per-tick
+// samples of the simulation: output, prices, money velocity, wealth
+// distribution and factory demographics. It knows nothing about the
+// engine; the state package feeds it one Sample per tick.
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Trade is one executed trade in a sample.
+type Trade struct {
+	Product   string
+	Qty       float64
+	UnitPrice float64
+}
+
+// Sample is what the engine reports at the end of a tick.
+type Sample struct {
+	Tick        int
+	Trades      []Trade
+	MoneySupply float64
+	// Wallets holds every factory's cash.
+	Wallets []float64
+	// Births counts factories spawned this tick; Lifespans holds, for
+	// every factory removed this tick, how many ticks it lived.
+	Births    int
+	Lifespans []int
+}
+
+// Indicators are the economy-wide figures for one tick.
+type Indicators struct {
+	Tick int
+	// GDP is the total value traded this tick.
+	GDP float64
+	// PriceIndex is a chained Laspeyres index over the products traded
+	// in consecutive ticks, 1 at the start.
+	PriceIndex float64
+	// Velocity is GDP over the money supply.
+	Velocity float64
+	// Gini is the Gini coefficient of factory wallets (negative balances
+	// count as zero wealth).
+	Gini       float64
+	Population int
+	Births     int
+	Deaths     int
+	// BirthRate and DeathRate are births and deaths per live factory.
+	BirthRate float64
+	DeathRate float64
+	// MeanLifespan is the mean age at removal of every factory removed
+	// so far, 0 until the first one is.
+	MeanLifespan float64
+}
+
+// Recorder turns samples into indicators and keeps a bounded series.
+type Recorder struct {
+	capacity int
+	series   []Indicators
+
+	index      float64
+	prevPrices map[string]float64
+	prevQty    map[string]float64
+
+	deaths        int
+	totalLifespan int
+}
+
+// NewRecorder returns a Recorder that keeps the latest capacity ticks.
+func NewRecorder(capacity int) *Recorder {
+	return &Recorder{capacity: capacity, index: 1}
+}
+
+// Record folds in one tick's sample and returns its indicators.
+func (r *Recorder) Record(s Sample) Indicators {
+	value := make(map[string]float64)
+	qty := make(map[string]float64)
+	gdp := 0.0
+	for _, tr := range s.Trades {
+		value[tr.Product] += tr.Qty * tr.UnitPrice
+		qty[tr.Product] += tr.Qty
+		gdp += tr.Qty * tr.UnitPrice
+	}
+	prices := make(map[string]float64, len(qty))
+	for product, q := range qty {
+		if q > 0 {
+			prices[product] = value[product] / q
+		}
+	}
+	r.chain(prices)
+	r.prevPrices, r.prevQty = prices, qty
+
+	r.deaths += len(s.Lifespans)
+	for _, lifespan := range s.Lifespans {
+		r.totalLifespan += lifespan
+	}
+
+	ind := Indicators{
+		Tick:       s.Tick,
+		GDP:        gdp,
+		PriceIndex: r.index,
+		Gini:       Gini(s.Wallets),
+		Population: len(s.Wallets),
+		Births:     s.Births,
+		Deaths:     len(s.Lifespans),
+	}
+	if s.MoneySupply > 0 {
+		ind.Velocity = gdp / s.MoneySupply
+	}
+	if ind.Population > 0 {
+		ind.BirthRate = float64(ind.Births) / float64(ind.Population)
+		ind.DeathRate = float64(ind.Deaths) / float64(ind.Population)
+	}
+	if r.deaths > 0 {
+		ind.MeanLifespan = float64(r.totalLifespan) / float64(r.deaths)
+	}
+
+	r.series = append(r.series, ind)
+	if len(r.series) > r.capacity {
+		r.series = r.series[len(r.series)-r.capacity:]
+	}
+	return ind
+}
+
+// chain links this tick's prices onto the index, weighting by last
+// tick's quantities over the products traded in both ticks. With no
+// common product the index carries over unchanged.
+func (r *Recorder) chain(prices map[string]float64) {
+	products := make([]string, 0, len(prices))
+	for product := range prices {
+		if _, ok := r.prevPrices[product]; ok {
+			products = append(products, product)
+		}
+	}
+	// Summation order must not depend on map iteration.
+	sort.Strings(products)
+	now, then := 0.0, 0.0
+	for _, product := range products {
+		now += prices[product] * r.prevQty[product]
+		then += r.prevPrices[product] * r.prevQty[product]
+	}
+	if then > 0 {
+		r.index *= now / then
+	}
+}
+
+// Series returns the recorded indicators, oldest first.
+func (r *Recorder) Series() []Indicators {
+	out := make([]Indicators, len(r.series))
+	copy(out, r.series)
+	return out
+}
+
+// Gini returns the Gini coefficient of xs: 0 for perfect equality,
+// approaching 1 as one holder owns everything. Negative values count as
+// zero. Empty or all-zero input is 0.
+func Gini(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(xs))
+	for i, x := range xs {
+		sorted[i] = math.Max(0, x)
+	}
+	sort.Float64s(sorted)
+	sum, weighted := 0.0, 0.0
+	for i, x := range sorted {
+		sum += x
+		weighted += float64(i+1) * x
+	}
+	if sum == 0 {
+		return 0
+	}
+	n := float64(len(sorted))
+	return (2*weighted)/(n*sum) - (n+1)/n
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Gini(t *testing.T) {
+	if got := Gini([]float64{5, 5, 5, 5}); got != 0 {
+		t.Errorf("equal wallets: Gini = %v, want 0", got)
+	}
+	// One holder of four owns everything: (n-1)/n.
+	if got := Gini([]float64{0, 0, 0, 100}); math.Abs(got-0.75) > 1e-12 {
+		t.Errorf("one owner: Gini = %v, want 0.75", got)
+	}
+	if got := Gini([]float64{-10, 0, 0, 100}); math.Abs(got-0.75) > 1e-12 {
+		t.Errorf("negative balances should count as zero: Gini = %v, want 0.75", got)
+	}
+	if got := Gini(nil); got != 0 {
+		t.Errorf("no wallets: Gini = %v, want 0", got)
+	}
+}
+
+func Test_Recorder(t *testing.T) {
+	r := NewRecorder(2)
+
+	first := r.Record(Sample{
+		Tick:        1,
+		Trades:      []Trade{{Product: "Ore", Qty: 10, UnitPrice: 1}, {Product: "Ingot", Qty: 5, UnitPrice: 4}},
+		MoneySupply: 300,
+		Wallets:     []float64{100, 200},
+		Births:      1,
+	})
+	if first.GDP != 30 || first.PriceIndex != 1 || first.Velocity != 0.1 {
+		t.Fatalf("first tick = %+v, want GDP 30, index 1, velocity 0.1", first)
+	}
+	if first.BirthRate != 0.5 {
+		t.Fatalf("birth rate = %v, want 0.5", first.BirthRate)
+	}
+
+	// Ore doubles in price, Ingot is not traded: the index follows the
+	// common basket (Ore) at last tick's quantity.
+	second := r.Record(Sample{
+		Tick:      2,
+		Trades:    []Trade{{Product: "Ore", Qty: 3, UnitPrice: 2}},
+		Wallets:   []float64{100},
+		Lifespans: []int{40},
+	})
+	if second.PriceIndex != 2 {
+		t.Fatalf("chained index = %v, want 2", second.PriceIndex)
+	}
+	if second.Deaths != 1 || second.MeanLifespan != 40 {
+		t.Fatalf("deaths/lifespan = %d/%v, want 1/40", second.Deaths, second.MeanLifespan)
+	}
+
+	// No common product: the index carries over.
+	third := r.Record(Sample{Tick: 3, Lifespans: []int{20}})
+	if third.PriceIndex != 2 || third.MeanLifespan != 30 {
+		t.Fatalf("third tick = %+v, want index 2, mean lifespan 30", third)
+	}
+
+	series := r.Series()
+	if len(series) != 2 || series[0].Tick != 2 || series[1].Tick != 3 {
+		t.Fatalf("series = %+v, want the latest 2 ticks", series)
+	}
+}
@@ -0,0 +1,168 @@
+package state
+
+import (
+	"log/slog"
+	"math"
+	"sort"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/marketmaker"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+)
+
+// moneyChannel names a path by which money enters or leaves the economy.
+// Transfers between accounts (seed capital, rent, node sales to their
+// owner, royalties, recycled corpses) move money without changing the
+// supply and are not channels.
+type moneyChannel string
+
+const (
+	// channelSink: goal sinks pay sellers out of nothing.
+	channelSink moneyChannel = "sink"
+	// channelSalvage: unsold output sold to on-site sinks at floorUnitPrice.
+	channelSalvage moneyChannel = "salvage"
+	// channelTransport: the freight share of every factory purchase.
+	channelTransport moneyChannel = "transport"
+	// channelConstruction: extractor, rail line and logistics link build
+	// costs paid by the treasury.
+	channelConstruction moneyChannel = "construction"
+	// channelRelocation: what factories pay to move to a new site.
+	channelRelocation moneyChannel = "relocation"
+	// channelCulled: residual cash of a removed bankrupt factory or an
+	// abandoned extractor company. Negative residuals vanish, so this is
+	// usually an inflow (forgiven debt).
+	channelCulled moneyChannel = "culled"
+	// channelRemoved: cash of factories removed with a disabled recipe.
+	channelRemoved moneyChannel = "removed"
+)
+
+// moneyAuditTolerance is the largest per-tick discrepancy the audit
+// treats as floating-point noise rather than a leak.
+const moneyAuditTolerance = 1e-6
+
+// moneyLedger attributes every change in the money supply to a channel
+// and checks, once per tick, that the supply moved by exactly the sum
+// of those flows. The zero value is ready to use; the first audit takes
+// its opening balance.
+type moneyLedger struct {
+	started bool
+	// opening is the supply at the start of the current tick.
+	opening float64
+	flows   map[moneyChannel]float64
+	// last is the previous tick's closed audit, and cumulative every
+	// channel's net flow since the world began.
+	last       moneyTick
+	cumulative map[moneyChannel]float64
+}
+
+// moneyTick is one closed audit.
+type moneyTick struct {
+	tick        int
+	opening     float64
+	closing     float64
+	flows       map[moneyChannel]float64
+	discrepancy float64
+}
+
+// record attributes delta (positive: money created, negative: money
+// destroyed) to a channel.
+func (ml *moneyLedger) record(channel moneyChannel, delta float64) {
+	if ml.flows == nil {
+		ml.flows = make(map[moneyChannel]float64)
+	}
+	ml.flows[channel] += delta
+}
+
+// open takes the opening balance the first time it is called.
+func (ml *moneyLedger) open(supply float64) {
+	if ml.started {
+		return
+	}
+	ml.started = true
+	ml.opening = supply
+}
+
+// close ends the tick's audit: the discrepancy is how far the supply
+// moved beyond what the recorded flows explain.
+func (ml *moneyLedger) close(tick int, supply float64) {
+	net := 0.0
+	for _, delta := range ml.flows {
+		net += delta
+	}
+	if ml.cumulative == nil {
+		ml.cumulative = make(map[moneyChannel]float64)
+	}
+	for channel, delta := range ml.flows {
+		ml.cumulative[channel] += delta
+	}
+	ml.last = moneyTick{
+		tick:        tick,
+		opening:     ml.opening,
+		closing:     supply,
+		flows:       ml.flows,
+		discrepancy: supply - (ml.opening + net),
+	}
+	ml.opening = supply
+	ml.flows = nil
+}
+
+// balanced reports whether the last closed tick conserved money.
+func (ml *moneyLedger) balanced() bool {
+	return math.Abs(ml.last.discrepancy) <= moneyAuditTolerance
+}
+
+// walletMoney is the cash held in every wallet in the world: factories,
+// extractor companies and the market maker.
+func (s *State) walletMoney() float64 {
+	total := 0.0
+	for _, p := range s.producers {
+		switch producer := p.(type) {
+		case *factory.Factory:
+			total += producer.Cash()
+		case *resources.Resource:
+			if producer.Extractor != nil && producer.Extractor.Owner == resources.OwnerCompany {
+				total += producer.Extractor.Wallet.Cash()
+			}
+		case *marketmaker.MarketMaker:
+			total += producer.Cash()
+		}
+	}
+	return total
+}
+
+// moneySupply is all money in the economy: wallets plus the treasury.
+func (s *State) moneySupply() float64 {
+	return s.walletMoney() + s.treasury
+}
+
+func (s *State) Money(_ *slog.Logger) statehttp.Money {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	wallets := s.walletMoney()
+	money := statehttp.Money{
+		Tick:        s.money.last.tick,
+		Supply:      wallets + s.treasury,
+		Treasury:    s.treasury,
+		Wallets:     wallets,
+		Opening:     s.money.last.opening,
+		Closing:     s.money.last.closing,
+		Discrepancy: s.money.last.discrepancy,
+		Balanced:    s.money.balanced(),
+		Channels:    make([]statehttp.MoneyChannel, 0),
+	}
+	channels := make([]string, 0, len(s.money.cumulative))
+	for channel := range s.money.cumulative {
+		channels = append(channels, string(channel))
+	}
+	sort.Strings(channels)
+	for _, channel := range channels {
+		money.Channels = append(money.Channels, statehttp.MoneyChannel{
+			Name:       channel,
+			LastTick:   s.money.last.flows[moneyChannel(channel)],
+			Cumulative: s.money.cumulative[moneyChannel(channel)],
+		})
+	}
+	return money
+}
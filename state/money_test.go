@@ -0,0 +1,46 @@
+package state
+
+import (
+	"testing"
+)
+
+func Test_moneyLedger_discrepancy(t *testing.T) {
+	var ml moneyLedger
+	ml.open(100)
+	ml.open(999) // only the first opening balance counts
+	ml.record(channelSink, 30)
+	ml.record(channelTransport, -5)
+	ml.close(1, 125)
+	if !ml.balanced() {
+		t.Fatalf("explained change left discrepancy %v", ml.last.discrepancy)
+	}
+
+	ml.record(channelSink, 10)
+	ml.close(2, 140) // 5 more than the flows explain
+	if ml.balanced() || ml.last.discrepancy != 5 {
+		t.Fatalf("discrepancy = %v (balanced=%v), want 5", ml.last.discrepancy, ml.balanced())
+	}
+	if ml.cumulative[channelSink] != 40 {
+		t.Fatalf("cumulative sink = %v, want 40", ml.cumulative[channelSink])
+	}
+}
+
+// Test_money_conserved is the conservation invariant: across a real
+// run -- spawning, extraction, trade, salvage, rent and bankruptcy --
+// every change in the money supply is attributed to a channel.
+func Test_money_conserved(t *testing.T) {
+	s := newCascadeState(t, DefaultConfig(), "Plate")
+
+	for i := 0; i < 1500; i++ {
+		if err := s.Tick(testLogger()); err != nil {
+			t.Fatalf("tick %d failed: %v", i, err)
+		}
+		if !s.money.balanced() {
+			t.Fatalf("tick %d: money supply moved %v beyond its recorded flows",
+				s.tick, s.money.last.discrepancy)
+		}
+	}
+	if s.money.cumulative[channelSink] <= 0 {
+		t.Fatal("expected sink purchases to have injected money during the run")
+	}
+}
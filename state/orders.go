@@ -4,59 +4,113 @@ import (
 	"log/slog"
 
 	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/logistics"
 	"github.com/paul-freeman/satisfactory-story/market"
+	"github.com/paul-freeman/satisfactory-story/marketmaker"
 	"github.com/paul-freeman/satisfactory-story/production"
-	"github.com/paul-freeman/satisfactory-story/recipes"
 	"github.com/paul-freeman/satisfactory-story/resources"
 	"github.com/paul-freeman/satisfactory-story/sink"
 )
 
-// publishOrders rebuilds the book from live physical state: every unit
-// of stock on hand becomes an ask, every unit of input hunger becomes a
-// bid. Zero-quantity orders are dropped by the book itself. Only prices
-// persist between ticks (on the producers); quantities can never go
-// stale because they are re-derived here every tick.
+// publishOrders republishes the book from live physical state: every
+// unit of stock on hand becomes an ask, every unit of input hunger
+// becomes a bid. The book updates each producer's standing orders in
+// place, hides zero-quantity ones and withdraws those of producers gone
+// since the last pass. Only prices persist between ticks (on the
+// producers); quantities can never go stale because they are re-derived
+// here every tick.
 func (s *State) publishOrders(_ *slog.Logger) {
-	s.book.Clear()
+	s.book.Republish(s.tick)
 	for _, p := range s.producers {
 		switch producer := p.(type) {
 		case *resources.Resource:
+			if producer.Exhausted() {
+				continue
+			}
 			name := producer.Production.Name
 			s.book.PostAsk(producer, name, producer.Stock, producer.AskPriceFor(name))
 		case *factory.Factory:
 			for _, output := range producer.Output {
-				s.book.PostAsk(producer, output.Name,
+				s.book.PostAskTerms(producer, output.Name,
 					producer.OutputStock.Get(output.Name),
-					producer.AskPriceFor(output.Name))
+					producer.AskPriceFor(output.Name),
+					market.OrderTerms{MinQty: producer.BatchSize(output.Name, s.config.AskBatchTicks)})
 			}
 			for _, input := range producer.Input {
+				if s.config.LadderBids {
+					// The deepest rung stands good-till-cancelled
+					// between renewals; the rest repost every tick.
+					ladder := producer.BidLadder(input.Name, inputStockTargetTicks)
+					levels := make([]market.Level, len(ladder))
+					for i, rung := range ladder {
+						levels[i] = market.Level{Rate: rung.Rate, UnitPrice: rung.UnitPrice}
+					}
+					deepest := len(levels) - 1
+					s.book.PostBidLadder(producer, input.Name, levels[:deepest], market.OrderTerms{})
+					if expiry, renew := producer.RenewRestock(input.Name, s.tick); renew {
+						s.book.PostBidLevel(producer, input.Name, deepest,
+							levels[deepest].Rate, levels[deepest].UnitPrice, market.OrderTerms{Expiry: expiry})
+					}
+					continue
+				}
 				s.book.PostBid(producer, input.Name,
 					producer.Hunger(input.Name, inputStockTargetTicks),
 					producer.BidPriceFor(input.Name))
 			}
 		case *sink.Sink:
+			rate := producer.Rate
+			if rate == 0 {
+				rate = sinkDemandRate
+			}
 			for _, want := range producer.Input {
-				s.book.PostBid(producer, want.Name, sinkDemandRate, producer.BidUnitPrice)
+				s.book.PostBid(producer, want.Name, rate, producer.BidUnitPrice)
 			}
+		case *marketmaker.MarketMaker:
+			s.postQuotes(producer)
 		}
 	}
 }
 
-// matchOrders crosses the book and executes a spot trade per match.
+// withdrawOrders cancels every order f has in the book, so none of its
+// good-till-cancelled orders outlives it.
+func (s *State) withdrawOrders(f *factory.Factory) {
+	for _, input := range f.Input {
+		s.book.CancelBids(f, input.Name)
+	}
+	for _, output := range f.Output {
+		s.book.CancelAsks(f, output.Name)
+	}
+}
+
+// matchOrders crosses the book and executes a spot trade per match,
+// fanning products out over the worker pool when one is configured (see
+// matchOrdersParallel) and no logistics network couples the products.
 func (s *State) matchOrders(l *slog.Logger) {
-	s.book.MatchAll(recipes.UnitTransportCost, func(m market.Match) (float64, error) {
-		return s.executeTrade(l, m)
+	s.book.SetTransportFloor(s.transportFloor)
+	if s.config.Workers > 1 && s.network == nil {
+		s.matchOrdersParallel(l)
+		return
+	}
+	s.book.MatchAll(s.unitTransport, func(m market.Match) (float64, error) {
+		return s.executeTrade(l, m, nil)
 	})
 }
 
 // executeTrade is the only place trades become real: quantity is
-// re-clamped against live seller stock and the buyer's wallet, then
-// units and money move immediately. Returns the executed quantity.
-// A factory buyer pays (unit price + unit transport) per unit and can
-// never overdraw its wallet -- this hard budget is what keeps escalated
-// bid prices honest. The transport share of the payment leaves the
-// economy (it is a cost, not anyone's income).
-func (s *State) executeTrade(l *slog.Logger, m market.Match) (float64, error) {
+// re-clamped against live seller stock, the room left on the logistics
+// link it ships by and the buyer's wallet (trading nothing if that leaves
+// less than the orders' MinQty), then units and money move immediately.
+// Returns the executed quantity; a full link truncates the fill, which
+// ends the bid's shopping this tick as a spent budget does.
+// A factory (or market-maker) buyer pays (unit price + unit transport)
+// per unit and can never overdraw its wallet -- this hard budget is what
+// keeps escalated bid prices honest. The transport share of the payment
+// leaves the economy (it is a cost, not anyone's income). The seller
+// pays the exchange fee (Config.FeePct of the trade's value) to the
+// treasury out of its proceeds; a resource node's net proceeds go to its
+// owner (see payNodeOwner). Writes to state shared across products go
+// through fx (nil applies them at once).
+func (s *State) executeTrade(l *slog.Logger, m market.Match, fx *tradeEffects) (float64, error) {
 	qty := m.Order.Rate
 
 	// Clamp by what the seller physically has.
@@ -69,43 +123,151 @@ func (s *State) executeTrade(l *slog.Logger, m market.Match) (float64, error) {
 		if have := seller.OutputStock.Get(m.Order.Name); have < qty {
 			qty = have
 		}
+	case *marketmaker.MarketMaker:
+		if have := seller.Inventory.Get(m.Order.Name); have < qty {
+			qty = have
+		}
 	default:
 		return 0, nil // sinks never sell
 	}
 
+	// Clamp by the room left on the cheapest link with any: the route
+	// the match was priced on. A link not built yet is built by the
+	// treasury; Route offers only those it can afford.
+	var route logistics.Route
+	build := false
+	if s.network != nil {
+		var ok bool
+		if route, ok = s.network.Route(m.Seller.Location(), m.Buyer.Location()); !ok {
+			return 0, nil
+		}
+		if route.Residual < qty {
+			qty = route.Residual
+		}
+		build = !s.network.Built(m.Seller.Location(), m.Buyer.Location(), route.Mode)
+	}
+
 	// Clamp by what the buyer can pay (sinks have infinite money).
 	unitDelivered := m.UnitPrice + m.UnitTransport
-	if buyer, ok := m.Buyer.(*factory.Factory); ok && unitDelivered > 0 {
-		if affordable := buyer.Wallet.Cash() / unitDelivered; affordable < qty {
+	var wallet *production.Wallet
+	switch buyer := m.Buyer.(type) {
+	case *factory.Factory:
+		wallet = &buyer.Wallet
+	case *marketmaker.MarketMaker:
+		wallet = &buyer.Wallet
+	}
+	if wallet != nil && unitDelivered > 0 {
+		if affordable := wallet.Cash() / unitDelivered; affordable < qty {
 			qty = affordable
 		}
 	}
-	if qty <= production.RateEpsilon {
+	if qty <= production.RateEpsilon || qty < m.MinQty-production.RateEpsilon {
 		return 0, nil
 	}
+	if s.network != nil {
+		if build {
+			cost := logistics.SpecFor(route.Mode).BuildCost
+			s.treasury -= cost
+			s.money.record(channelConstruction, -cost)
+		}
+		s.network.Ship(m.Seller.Location(), m.Buyer.Location(), route.Mode, qty)
+	}
 
-	// Move the goods.
+	// Move the goods. The seller keeps the proceeds net of the exchange
+	// fee.
+	fee := qty * m.UnitPrice * s.config.FeePct
+	proceeds := qty*m.UnitPrice - fee
+	var node *resources.Resource
 	switch seller := m.Seller.(type) {
 	case *resources.Resource:
 		seller.Stock -= qty
+		node = seller
 	case *factory.Factory:
 		seller.OutputStock.Take(m.Order.Name, qty)
-		seller.TickRevenue += qty * m.UnitPrice
-		seller.Wallet.Adjust(qty * m.UnitPrice)
+		seller.TickRevenue += proceeds
+		seller.Wallet.Adjust(proceeds)
 		seller.RecordTrade(s.tick, m.Buyer.Location(), qty)
+	case *marketmaker.MarketMaker:
+		seller.Inventory.Take(m.Order.Name, qty)
+		seller.Wallet.Adjust(proceeds)
 	}
+	// Off the logistics network, the goods may go over a rail line, whose
+	// toll -- paid by a buyer who pays freight -- goes to the treasury
+	// that built it instead of leaving the economy.
+	var line *logistics.Line
+	if s.network == nil && (len(s.lines) > 0 || s.hubs != nil) {
+		_, line = s.corridor(m.Seller.Location(), m.Buyer.Location())
+	}
+	tolls := 0.0
+
+	// With transit on, the buyer pays now and receives the goods when
+	// the shipment arrives (see deliverShipments).
+	var sh *shipment
+	if s.config.Transit {
+		origin, destination := m.Seller.Location(), m.Buyer.Location()
+		sh = &shipment{
+			buyer:       m.Buyer,
+			product:     m.Order.Name,
+			qty:         qty,
+			origin:      origin,
+			destination: destination,
+			departTick:  s.tick,
+			arriveTick:  s.tick + transitTicks(origin, destination, transitSpeed(route.Mode)),
+		}
+	}
+	var channel moneyChannel
+	var flow float64
 	switch buyer := m.Buyer.(type) {
 	case *factory.Factory:
-		buyer.InputStock.Add(m.Order.Name, qty)
+		if sh != nil {
+			buyer.Expect(m.Order.Name, qty)
+		} else {
+			buyer.InputStock.Add(m.Order.Name, qty)
+		}
 		buyer.Wallet.Adjust(-qty * unitDelivered)
 		buyer.TickInputSpend += qty * unitDelivered
 		buyer.RecordTrade(s.tick, m.Seller.Location(), qty)
+		if line != nil {
+			tolls = qty * line.Toll
+		}
+		channel, flow = channelTransport, -qty*m.UnitTransport+tolls
+	case *marketmaker.MarketMaker:
+		if sh != nil {
+			buyer.Expect(m.Order.Name, qty)
+		} else {
+			buyer.Inventory.Add(m.Order.Name, qty)
+		}
+		buyer.Wallet.Adjust(-qty * unitDelivered)
+		if line != nil {
+			tolls = qty * line.Toll
+		}
+		channel, flow = channelTransport, -qty*m.UnitTransport+tolls
 	case *sink.Sink:
-		buyer.RecordDelivery(m.Order.Name, qty)
+		if sh == nil {
+			buyer.RecordDelivery(m.Order.Name, qty)
+		}
+		channel, flow = channelSink, qty*m.UnitPrice
 	}
 
-	s.lastTrade[m.Order.Name] = m.UnitPrice
-	s.ledger.record(s.tick, m.Seller, m.Buyer, m.Order.Name, qty, m.UnitPrice)
+	fx.apply(func() {
+		if node != nil {
+			s.payNodeOwner(node, proceeds)
+		}
+		s.treasury += fee + tolls
+		s.counters.fees += fee
+		if line != nil {
+			line.Ship(qty, tolls)
+		}
+		if channel != "" {
+			s.money.record(channel, flow)
+		}
+		s.lastTrade[m.Order.Name] = m.UnitPrice
+		s.counters.trades++
+		s.ledger.record(s.tick, m.Seller, m.Buyer, m.Order.Name, qty, m.UnitPrice)
+		if sh != nil {
+			s.transit = append(s.transit, sh)
+		}
+	})
 	l.Debug("executed trade",
 		slog.String("product", m.Order.Name),
 		slog.Float64("qty", qty),
@@ -114,3 +276,16 @@ func (s *State) executeTrade(l *slog.Logger, m market.Match) (float64, error) {
 	)
 	return qty, nil
 }
+
+// payNodeOwner routes a resource node's sale proceeds: the royalty share
+// to the treasury, the rest to the owner -- the extractor company's
+// wallet, or the treasury for a state-held (or unclaimed) node.
+func (s *State) payNodeOwner(r *resources.Resource, proceeds float64) {
+	royalty := proceeds * s.config.RoyaltyPct
+	s.treasury += royalty
+	if r.Extractor == nil || r.Extractor.Owner != resources.OwnerCompany {
+		s.treasury += proceeds - royalty
+		return
+	}
+	r.Extractor.Wallet.Adjust(proceeds - royalty)
+}
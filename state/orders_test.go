@@ -6,10 +6,12 @@ import (
 	"testing"
 
 	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/logistics"
 	"github.com/paul-freeman/satisfactory-story/market"
 	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/production"
 	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/state/metrics"
 )
 
 func testLogger() *slog.Logger {
@@ -18,10 +20,12 @@ func testLogger() *slog.Logger {
 
 func newTestState() *State {
 	return &State{
-		book:      market.NewBook(),
-		lastTrade: make(map[string]float64),
-		ledger:    &tradeLedger{},
-		treasury:  initialTreasuryFund,
+		config:     DefaultConfig(),
+		book:       market.NewBook(),
+		lastTrade:  make(map[string]float64),
+		ledger:     &tradeLedger{},
+		indicators: metrics.NewRecorder(indicatorHistoryTicks),
+		treasury:   initialTreasuryFund,
 	}
 }
 
@@ -56,6 +60,38 @@ func Test_publishOrders_stockBacked(t *testing.T) {
 	}
 }
 
+func Test_publishOrders_ladderRestockStands(t *testing.T) {
+	s := newTestState()
+	s.config.LadderBids = true
+	f := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 100, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	s.producers = []production.Producer{f}
+
+	s.publishOrders(testLogger())
+	bids := s.book.Bids("OreIron")
+	deepest := bids[len(bids)-1]
+	if deepest.Terms.Expiry <= s.tick {
+		t.Fatalf("deepest rung terms = %+v, want good-till-cancelled", deepest.Terms)
+	}
+	price := deepest.UnitPrice
+
+	// Repriced bids and a later tick leave the standing rung as posted.
+	s.tick++
+	f.SetBidPrice("OreIron", 2*f.BidPriceFor("OreIron"))
+	s.publishOrders(testLogger())
+	bids = s.book.Bids("OreIron")
+	if got := bids[len(bids)-1]; got.UnitPrice != price || got.Terms != deepest.Terms {
+		t.Fatalf("deepest rung = %+v, want it standing at %v", got, price)
+	}
+
+	s.SetRecipe(testLogger(), "Recipe_IngotIron_C", false)
+	if bids := s.book.Bids("OreIron"); len(bids) != 0 {
+		t.Fatalf("bids = %+v, want a removed factory's standing rung withdrawn", bids)
+	}
+}
+
 func Test_executeTrade_movesGoodsAndMoney(t *testing.T) {
 	s := newTestState()
 	s.tick = 42
@@ -77,7 +113,7 @@ func Test_executeTrade_movesGoodsAndMoney(t *testing.T) {
 		UnitPrice:     2.0,
 		UnitTransport: 1.1,
 	}
-	executed, err := s.executeTrade(testLogger(), m)
+	executed, err := s.executeTrade(testLogger(), m, nil)
 	if err != nil {
 		t.Fatalf("executeTrade error: %v", err)
 	}
@@ -128,7 +164,7 @@ func Test_executeTrade_budgetClamp(t *testing.T) {
 		UnitPrice:     2.0,
 		UnitTransport: 1.1,
 	}
-	executed, err := s.executeTrade(testLogger(), m)
+	executed, err := s.executeTrade(testLogger(), m, nil)
 	if err != nil {
 		t.Fatalf("executeTrade error: %v", err)
 	}
@@ -139,3 +175,168 @@ func Test_executeTrade_budgetClamp(t *testing.T) {
 		t.Fatalf("buyer cash = %v, want ~0 (never negative from a purchase)", got)
 	}
 }
+
+func Test_publishOrders_exhaustedNodePostsNoAsk(t *testing.T) {
+	s := newTestState()
+	r := &resources.Resource{
+		Production:  production.Production{Name: "OreIron", Rate: 1},
+		Loc:         point.Point{X: 0, Y: 0},
+		Stock:       7,
+		FullReserve: 100,
+		Reserve:     0,
+	}
+	s.producers = []production.Producer{r}
+
+	s.publishOrders(testLogger())
+
+	if ask, ok := s.book.BestAsk("OreIron"); ok {
+		t.Fatalf("exhausted node posted %+v, want no ask", ask)
+	}
+}
+
+func Test_executeTrade_paysNodeOwner(t *testing.T) {
+	for _, tc := range []struct {
+		owner        resources.Owner
+		wantCompany  float64
+		wantTreasury float64
+	}{
+		// 10 units at 2.0 = 20 proceeds, 10% royalty.
+		{owner: resources.OwnerCompany, wantCompany: 18, wantTreasury: 2},
+		{owner: resources.OwnerTreasury, wantCompany: 0, wantTreasury: 20},
+	} {
+		t.Run(string(tc.owner), func(t *testing.T) {
+			s := newTestState()
+			s.config.RoyaltyPct = 0.1
+			r := &resources.Resource{
+				Production: production.Production{Name: "OreIron", Rate: 1},
+				Loc:        point.Point{X: 0, Y: 0},
+				Stock:      10,
+			}
+			if err := r.Claim(resources.MinerMk1, tc.owner, 1, 0); err != nil {
+				t.Fatalf("Claim failed: %v", err)
+			}
+			f := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 100, Y: 0}, 0,
+				production.Products{production.Production{Name: "OreIron", Rate: 1}},
+				production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+				1000)
+			before := s.treasury
+
+			qty, err := s.executeTrade(testLogger(), market.Match{
+				Seller: r, Buyer: f,
+				Order:     production.Production{Name: "OreIron", Rate: 10},
+				UnitPrice: 2.0,
+			}, nil)
+			if err != nil || qty != 10 {
+				t.Fatalf("executeTrade = %v, %v; want 10, nil", qty, err)
+			}
+			if got := r.Extractor.Wallet.Cash(); got != tc.wantCompany {
+				t.Errorf("company cash = %v, want %v", got, tc.wantCompany)
+			}
+			if got := s.treasury - before; got != tc.wantTreasury {
+				t.Errorf("treasury gain = %v, want %v", got, tc.wantTreasury)
+			}
+		})
+	}
+}
+
+func Test_executeTrade_exchangeFee(t *testing.T) {
+	s := newTestState()
+	s.config.FeePct = 0.05
+	seller := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 0, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		0)
+	seller.OutputStock.Add("IronIngot", 10)
+	buyer := factory.New("Constructor", "Recipe_IronPlate_C", point.Point{X: 100, Y: 0}, 0,
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		production.Products{production.Production{Name: "IronPlate", Rate: 1}},
+		1000)
+	before := s.treasury
+
+	qty, err := s.executeTrade(testLogger(), market.Match{
+		Seller: seller, Buyer: buyer,
+		Order:     production.Production{Name: "IronIngot", Rate: 10},
+		UnitPrice: 2.0,
+	}, nil)
+	if err != nil || qty != 10 {
+		t.Fatalf("executeTrade = %v, %v; want 10, nil", qty, err)
+	}
+	// 20 of trade value: the buyer pays all of it, the seller keeps 95%.
+	if got := buyer.Cash(); got != 980 {
+		t.Errorf("buyer cash = %v, want 980", got)
+	}
+	if got := seller.Cash(); got != 19 {
+		t.Errorf("seller cash = %v, want 19", got)
+	}
+	if got := s.treasury - before; got != 1 || s.counters.fees != 1 {
+		t.Errorf("treasury gain = %v, fees = %v; want 1 each", got, s.counters.fees)
+	}
+}
+
+func Test_executeTrade_linkCapacity(t *testing.T) {
+	s := newTestState()
+	s.network = logistics.NewNetwork(logistics.Conveyor)
+	r := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+		Stock:      30,
+	}
+	f := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 1000, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	m := market.Match{
+		Seller: r, Buyer: f,
+		Order:         production.Production{Name: "OreIron", Rate: 20},
+		UnitPrice:     1.0,
+		UnitTransport: s.unitTransport(r.Location(), f.Location()),
+	}
+
+	// A conveyor carries 12 a tick, then the link is full.
+	if qty, err := s.executeTrade(testLogger(), m, nil); err != nil || qty != 12 {
+		t.Fatalf("executeTrade = %v, %v; want 12 (link capacity), nil", qty, err)
+	}
+	if qty, _ := s.executeTrade(testLogger(), m, nil); qty != 0 {
+		t.Fatalf("second executeTrade = %v, want 0 on a full link", qty)
+	}
+	s.network.BeginTick(1)
+	if qty, _ := s.executeTrade(testLogger(), m, nil); qty != 12 {
+		t.Fatalf("next-tick executeTrade = %v, want 12", qty)
+	}
+}
+
+func Test_executeTrade_fallsBackToBuiltLink(t *testing.T) {
+	s := newTestState()
+	s.network = logistics.NewNetwork(logistics.Conveyor, logistics.Truck)
+	s.network.CanBuild = s.canBuildLink
+	r := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+		Stock:      30,
+	}
+	f := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 1000, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	s.network.Ship(r.Location(), f.Location(), logistics.Truck, 1)
+	s.network.BeginTick(1)
+	s.treasury = logistics.SpecFor(logistics.Conveyor).BuildCost / 2
+
+	// The treasury cannot build the cheaper conveyor: the built truck
+	// carries the trade, and nothing is built.
+	m := market.Match{
+		Seller: r, Buyer: f,
+		Order:         production.Production{Name: "OreIron", Rate: 5},
+		UnitPrice:     1.0,
+		UnitTransport: s.unitTransport(r.Location(), f.Location()),
+	}
+	if m.UnitTransport != logistics.Truck.UnitCost(1000) {
+		t.Fatalf("unit transport = %v, want the truck's %v", m.UnitTransport, logistics.Truck.UnitCost(1000))
+	}
+	if qty, err := s.executeTrade(testLogger(), m, nil); err != nil || qty != 5 {
+		t.Fatalf("executeTrade = %v, %v; want 5 by truck, nil", qty, err)
+	}
+	if s.network.Built(r.Location(), f.Location(), logistics.Conveyor) || s.money.cumulative[channelConstruction] != 0 {
+		t.Fatalf("construction = %v, want no conveyor built", s.money.cumulative[channelConstruction])
+	}
+}
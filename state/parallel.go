@@ -0,0 +1,141 @@
+package state
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/paul-freeman/satisfactory-story/market"
+	"github.com/paul-freeman/satisfactory-story/production"
+)
+
+// tradeEffects defers a trade's writes to state shared across products
+// -- the treasury, money and trade ledgers, last-trade prices and
+// counters -- so parallel matching can replay them in serial order. A nil
+// *tradeEffects applies each write immediately.
+type tradeEffects struct {
+	pending []func()
+}
+
+func (fx *tradeEffects) apply(write func()) {
+	if fx == nil {
+		write()
+		return
+	}
+	fx.pending = append(fx.pending, write)
+}
+
+func (fx *tradeEffects) replay() {
+	for _, write := range fx.pending {
+		write()
+	}
+	fx.pending = nil
+}
+
+// parallelFor calls fn(i) for every i in [0, n) across up to workers
+// goroutines and waits for them all. fn must only touch state owned by
+// index i.
+func parallelFor(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < n; i = int(next.Add(1) - 1) {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// matchOrdersParallel matches products concurrently with results
+// identical to the serial MatchAll. Products whose orders share a
+// producer -- a buyer's wallet spent across several inputs, a seller's
+// stock of several outputs, a sink taking several goods -- form one
+// group, matched serially in sorted product order; distinct groups touch
+// disjoint producers and run concurrently. Writes to shared state are
+// buffered per product and replayed in sorted product order afterwards,
+// so every floating-point sum accumulates in serial order.
+func (s *State) matchOrdersParallel(l *slog.Logger) {
+	products := s.book.Products()
+	groups := s.productGroups(products)
+	effects := make([]tradeEffects, len(products))
+	parallelFor(len(groups), s.config.Workers, func(g int) {
+		for _, i := range groups[g] {
+			fx := &effects[i]
+			s.book.MatchProduct(products[i], s.unitTransport, func(m market.Match) (float64, error) {
+				return s.executeTrade(l, m, fx)
+			})
+		}
+	})
+	for i := range effects {
+		effects[i].replay()
+	}
+}
+
+// productGroups partitions the indices of products into groups joined by
+// any producer with orders in more than one of them. Groups and their
+// members are in ascending index order.
+func (s *State) productGroups(products []string) [][]int {
+	parent := make([]int, len(products))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	union := func(i, j int) {
+		ri, rj := find(i), find(j)
+		if ri < rj {
+			parent[rj] = ri
+		} else if rj < ri {
+			parent[ri] = rj
+		}
+	}
+
+	firstSeen := make(map[production.Producer]int)
+	join := func(p production.Producer, i int) {
+		if j, ok := firstSeen[p]; ok {
+			union(i, j)
+			return
+		}
+		firstSeen[p] = i
+	}
+	for i, product := range products {
+		for _, ask := range s.book.Asks(product) {
+			join(ask.Seller, i)
+		}
+		for _, bid := range s.book.Bids(product) {
+			join(bid.Buyer, i)
+		}
+	}
+
+	index := make(map[int]int)
+	groups := make([][]int, 0)
+	for i := range products {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
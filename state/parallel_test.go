@@ -0,0 +1,110 @@
+package state
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/sink"
+)
+
+// parallelFixture builds a small world with independent chains (Ore to
+// Ingot, Coal to Coke) that meet in one two-input recipe, so matching
+// sees both disjoint and joined product groups.
+func parallelFixture(workers int) *State {
+	rs := recipes.Recipes{
+		{
+			ClassName: "Recipe_Smelt_C", DisplayName: "Smelt Ore", Active: true,
+			InputProducts:  production.Products{{Name: "Ore", Rate: 5}},
+			OutputProducts: production.Products{{Name: "Ingot", Rate: 5}},
+		},
+		{
+			ClassName: "Recipe_Coke_C", DisplayName: "Bake Coke", Active: true,
+			InputProducts:  production.Products{{Name: "Coal", Rate: 4}},
+			OutputProducts: production.Products{{Name: "Coke", Rate: 2}},
+		},
+		{
+			ClassName: "Recipe_Steel_C", DisplayName: "Make Steel", Active: true,
+			InputProducts:  production.Products{{Name: "Ingot", Rate: 3}, {Name: "Coke", Rate: 1}},
+			OutputProducts: production.Products{{Name: "Steel", Rate: 2}},
+		},
+	}
+	producers := []production.Producer{
+		sink.New("Ingot", point.Point{X: 900, Y: 100},
+			production.Products{{Name: "Ingot", Rate: 1}}, goalBidUnitPrice),
+		sink.New("Steel", point.Point{X: 600, Y: 600},
+			production.Products{{Name: "Steel", Rate: 1}}, goalBidUnitPrice),
+	}
+	for i := 0; i < 6; i++ {
+		producers = append(producers,
+			&resources.Resource{
+				Production: production.Production{Name: "Ore", Rate: 30},
+				Loc:        point.Point{X: 100 + 120*i, Y: 300},
+			},
+			&resources.Resource{
+				Production: production.Production{Name: "Coal", Rate: 30},
+				Loc:        point.Point{X: 100 + 120*i, Y: 800},
+			})
+	}
+	s := newTestStateWithProducers(rs, producers)
+	s.config.Workers = workers
+	return s
+}
+
+func Test_Tick_parallelMatchesSerial(t *testing.T) {
+	serial, parallel := parallelFixture(1), parallelFixture(4)
+	for i := 0; i < 1500; i++ {
+		if err := serial.Tick(testLogger()); err != nil {
+			t.Fatalf("serial tick %d failed: %v", i, err)
+		}
+		if err := parallel.Tick(testLogger()); err != nil {
+			t.Fatalf("parallel tick %d failed: %v", i, err)
+		}
+		if math.Float64bits(serial.treasury) != math.Float64bits(parallel.treasury) {
+			t.Fatalf("tick %d: treasury %v serial, %v parallel", i, serial.treasury, parallel.treasury)
+		}
+	}
+
+	want, err := json.Marshal(serial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(parallel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatal("parallel world diverged from serial")
+	}
+	if len(serial.ledger.trades) == 0 {
+		t.Fatal("fixture never traded; the comparison is vacuous")
+	}
+	if len(parallel.ledger.trades) != len(serial.ledger.trades) {
+		t.Fatalf("ledger has %d trades parallel, %d serial", len(parallel.ledger.trades), len(serial.ledger.trades))
+	}
+	for i, w := range serial.ledger.trades {
+		g := parallel.ledger.trades[i]
+		if g.tick != w.tick || g.product != w.product || g.qty != w.qty || g.unitPrice != w.unitPrice ||
+			g.seller.Location() != w.seller.Location() || g.buyer.Location() != w.buyer.Location() {
+			t.Fatalf("trade %d = %+v parallel, %+v serial", i, g, w)
+		}
+	}
+}
+
+func Test_productGroups_joinSharedProducers(t *testing.T) {
+	s := newTestState()
+	a, b, c := &resources.Resource{Loc: point.Point{X: 1}}, &resources.Resource{Loc: point.Point{X: 2}}, &resources.Resource{Loc: point.Point{X: 3}}
+	s.book.PostAsk(a, "Coal", 1, 1)
+	s.book.PostAsk(b, "Ore", 1, 1)
+	s.book.PostBid(c, "Coal", 1, 1)
+	s.book.PostBid(c, "Ingot", 1, 1)
+
+	groups := s.productGroups(s.book.Products()) // Coal, Ingot, Ore
+	if len(groups) != 2 || len(groups[0]) != 2 || groups[0][0] != 0 || groups[0][1] != 1 || groups[1][0] != 2 {
+		t.Fatalf("groups = %v, want [[0 1] [2]] (Coal+Ingot share a buyer)", groups)
+	}
+}
@@ -0,0 +1,222 @@
+package state
+
+import (
+	"log/slog"
+	rtmetrics "runtime/metrics"
+	"slices"
+	"sort"
+	"time"
+
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+)
+
+// tickPhase names one of the sequential phases of State.Tick.
+type tickPhase string
+
+const (
+	phaseDeliverShipments tickPhase = "deliverShipments"
+	phaseProduceGoods     tickPhase = "produceGoods"
+	phaseContracts        tickPhase = "settleContracts"
+	phasePublishOrders    tickPhase = "publishOrders"
+	phaseMatchOrders      tickPhase = "matchOrders"
+	phaseMoveProducers    tickPhase = "moveProducers"
+	phaseSpawn            tickPhase = "spawnProducers"
+	phaseApplySolvency    tickPhase = "applySolvency"
+	phaseAdjustPrices     tickPhase = "adjustPrices"
+)
+
+// tickPhases lists the phases in the order Tick runs them, so reports
+// iterate deterministically.
+var tickPhases = []tickPhase{
+	phaseDeliverShipments,
+	phaseProduceGoods,
+	phaseContracts,
+	phasePublishOrders,
+	phaseMatchOrders,
+	phaseMoveProducers,
+	phaseSpawn,
+	phaseApplySolvency,
+	phaseAdjustPrices,
+}
+
+// phaseIndex maps a phase to its position in tickPhases.
+var phaseIndex = func() map[tickPhase]int {
+	index := make(map[tickPhase]int, len(tickPhases))
+	for i, phase := range tickPhases {
+		index[phase] = i
+	}
+	return index
+}()
+
+// profileWindowTicks is how many recent ticks the rolling profile
+// summarizes.
+const profileWindowTicks = 1000
+
+// Allocation counters read from the runtime at the top and bottom of each
+// tick. They are process-wide, so allocations by concurrent HTTP handlers
+// are charged to whichever tick is running.
+const (
+	allocObjectsMetric = "/gc/heap/allocs:objects"
+	allocBytesMetric   = "/gc/heap/allocs:bytes"
+)
+
+// tickSample is one tick's timing and allocation profile.
+type tickSample struct {
+	phases     []time.Duration
+	total      time.Duration
+	allocs     uint64
+	allocBytes uint64
+}
+
+// phaseClock times the phases of each tick: start marks the top of the
+// tick, each lap charges the time since the previous mark to a phase, and
+// stop closes the tick into a rolling window of the last
+// profileWindowTicks samples. Wall-clock only -- nothing in the
+// simulation reads it, so it cannot affect determinism.
+type phaseClock struct {
+	begin  time.Time
+	mark   time.Time
+	totals map[tickPhase]time.Duration
+	ticks  int
+
+	window  []tickSample
+	next    int
+	filled  int
+	current *tickSample
+	allocs  []rtmetrics.Sample
+	opening [2]uint64
+}
+
+func (c *phaseClock) start() {
+	if c.window == nil {
+		c.window = make([]tickSample, profileWindowTicks)
+		for i := range c.window {
+			c.window[i].phases = make([]time.Duration, len(tickPhases))
+		}
+		c.allocs = []rtmetrics.Sample{{Name: allocObjectsMetric}, {Name: allocBytesMetric}}
+	}
+	c.current = &c.window[c.next]
+	for i := range c.current.phases {
+		c.current.phases[i] = 0
+	}
+	c.opening = c.readAllocs()
+	c.begin = time.Now()
+	c.mark = c.begin
+	c.ticks++
+}
+
+func (c *phaseClock) lap(phase tickPhase) {
+	now := time.Now()
+	if c.totals == nil {
+		c.totals = make(map[tickPhase]time.Duration)
+	}
+	c.totals[phase] += now.Sub(c.mark)
+	c.current.phases[phaseIndex[phase]] += now.Sub(c.mark)
+	c.mark = now
+}
+
+func (c *phaseClock) stop() {
+	c.current.total = time.Since(c.begin)
+	closing := c.readAllocs()
+	c.current.allocs = closing[0] - c.opening[0]
+	c.current.allocBytes = closing[1] - c.opening[1]
+	c.next = (c.next + 1) % len(c.window)
+	if c.filled < len(c.window) {
+		c.filled++
+	}
+}
+
+// readAllocs returns the runtime's cumulative allocated objects and
+// bytes. A metric the runtime does not support reads as 0.
+func (c *phaseClock) readAllocs() [2]uint64 {
+	rtmetrics.Read(c.allocs)
+	var out [2]uint64
+	for i, sample := range c.allocs {
+		if sample.Value.Kind() == rtmetrics.KindUint64 {
+			out[i] = sample.Value.Uint64()
+		}
+	}
+	return out
+}
+
+// samples returns a copy of the window's ticks, oldest first. The
+// copy shares nothing with the ring buffer, so the caller may read it
+// after releasing the state lock while later ticks overwrite the window.
+func (c *phaseClock) samples() []tickSample {
+	out := make([]tickSample, 0, c.filled)
+	for i := 0; i < c.filled; i++ {
+		sample := c.window[(c.next-c.filled+i+len(c.window))%len(c.window)]
+		sample.phases = slices.Clone(sample.phases)
+		out = append(out, sample)
+	}
+	return out
+}
+
+// summarize returns p50, p95 and max of values, by nearest rank. It
+// sorts values in place.
+func summarize(values []float64) statehttp.Quantiles {
+	if len(values) == 0 {
+		return statehttp.Quantiles{}
+	}
+	sort.Float64s(values)
+	rank := func(p float64) float64 {
+		i := int(p*float64(len(values))+0.5) - 1
+		if i < 0 {
+			i = 0
+		}
+		return values[i]
+	}
+	return statehttp.Quantiles{
+		P50: rank(0.50),
+		P95: rank(0.95),
+		Max: values[len(values)-1],
+	}
+}
+
+// Profile summarizes the last profileWindowTicks ticks: p50/p95/max wall
+// time per phase and per tick, and allocations per tick.
+func (s *State) Profile(_ *slog.Logger) statehttp.Profile {
+	s.m.Lock()
+	samples := s.clock.samples()
+	s.m.Unlock()
+
+	p := statehttp.Profile{
+		Window: len(samples),
+		Phases: make([]statehttp.PhaseProfile, 0, len(tickPhases)),
+	}
+	values := make([]float64, len(samples))
+	for i, phase := range tickPhases {
+		for j, sample := range samples {
+			values[j] = sample.phases[i].Seconds()
+		}
+		p.Phases = append(p.Phases, statehttp.PhaseProfile{
+			Phase:   string(phase),
+			Seconds: summarize(values),
+		})
+	}
+	for j, sample := range samples {
+		values[j] = sample.total.Seconds()
+	}
+	p.TickSeconds = summarize(values)
+	for j, sample := range samples {
+		values[j] = float64(sample.allocs)
+	}
+	p.Allocs = summarize(values)
+	for j, sample := range samples {
+		values[j] = float64(sample.allocBytes)
+	}
+	p.AllocBytes = summarize(values)
+	return p
+}
+
+// engineCounters are monotonic event counts since the world began.
+type engineCounters struct {
+	trades        int
+	spawns        int
+	skippedSpawns int
+	bankruptcies  int
+	extractors    int
+	relocations   int
+	// fees is the exchange fees collected, in money.
+	fees float64
+}
@@ -0,0 +1,99 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_summarize_nearestRank(t *testing.T) {
+	values := make([]float64, 0, 100)
+	for i := 100; i >= 1; i-- {
+		values = append(values, float64(i))
+	}
+	got := summarize(values)
+	if got.P50 != 50 || got.P95 != 95 || got.Max != 100 {
+		t.Fatalf("summary = %+v, want p50 50, p95 95, max 100", got)
+	}
+	if got := summarize(nil); got.Max != 0 {
+		t.Fatalf("empty summary = %+v, want zero", got)
+	}
+}
+
+func Test_phaseClock_windowKeepsNewestTicks(t *testing.T) {
+	var c phaseClock
+	for i := 0; i < profileWindowTicks+3; i++ {
+		c.start()
+		c.lap(phaseMatchOrders)
+		// Tag each sample with its tick number so order is checkable.
+		c.current.phases[phaseIndex[phaseAdjustPrices]] = time.Duration(i)
+		c.stop()
+	}
+	samples := c.samples()
+	if len(samples) != profileWindowTicks {
+		t.Fatalf("window = %d samples, want %d", len(samples), profileWindowTicks)
+	}
+	first := samples[0].phases[phaseIndex[phaseAdjustPrices]]
+	last := samples[len(samples)-1].phases[phaseIndex[phaseAdjustPrices]]
+	if first != 3 || last != profileWindowTicks+2 {
+		t.Fatalf("window spans ticks %d..%d, want 3..%d (oldest first)", first, last, profileWindowTicks+2)
+	}
+	if c.ticks != profileWindowTicks+3 {
+		t.Fatalf("ticks = %d, want %d", c.ticks, profileWindowTicks+3)
+	}
+}
+
+func Test_Profile_coversEveryPhase(t *testing.T) {
+	s := newTestStateWithProducers(nil, nil)
+	for i := 0; i < 3; i++ {
+		if err := s.Tick(testLogger()); err != nil {
+			t.Fatalf("tick %d failed: %v", i, err)
+		}
+	}
+	p := s.Profile(testLogger())
+	if p.Window != 3 || len(p.Phases) != len(tickPhases) {
+		t.Fatalf("profile = %d ticks, %d phases; want 3, %d", p.Window, len(p.Phases), len(tickPhases))
+	}
+	if p.TickSeconds.Max <= 0 || p.TickSeconds.P50 > p.TickSeconds.Max {
+		t.Fatalf("tick seconds = %+v, want positive and ordered", p.TickSeconds)
+	}
+}
+
+// Run with -race: Profile reads its samples after releasing the state
+// lock, so they must not alias the window Tick keeps writing.
+func Test_Profile_concurrentWithTick(t *testing.T) {
+	s := newTestStateWithProducers(nil, nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Past a full window, so Tick overwrites slots Profile has read.
+		for i := 0; i < profileWindowTicks+200; i++ {
+			if err := s.Tick(testLogger()); err != nil {
+				t.Errorf("tick %d failed: %v", i, err)
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			if p := s.Profile(testLogger()); p.Window != profileWindowTicks {
+				t.Fatalf("window = %d, want %d", p.Window, profileWindowTicks)
+			}
+			return
+		default:
+			s.Profile(testLogger())
+		}
+	}
+}
+
+func Test_phaseClock_samplesAreCopies(t *testing.T) {
+	var c phaseClock
+	c.start()
+	c.lap(phaseMatchOrders)
+	c.stop()
+	samples := c.samples()
+	samples[0].phases[phaseIndex[phaseMatchOrders]] = -1
+	if c.window[0].phases[phaseIndex[phaseMatchOrders]] == -1 {
+		t.Fatal("samples should not alias the clock's window")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"math"
 
 	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/marketmaker"
 	"github.com/paul-freeman/satisfactory-story/production"
 	"github.com/paul-freeman/satisfactory-story/resources"
 )
@@ -47,6 +48,12 @@ func (s *State) adjustPrices(_ *slog.Logger) {
 			}
 		}
 
+		// A ladder's price follows its highest rung still open: the
+		// rungs are priced off the standing price, so a buyer whose
+		// urgent rung is filled (or empty) but whose restocking rungs go
+		// unfilled still escalates. Bids come rung by rung in posting
+		// order, so the first open one seen is the highest.
+		adjusted := make(map[*factory.Factory]bool)
 		for _, bid := range s.book.Bids(product) {
 			if bid.Remaining <= production.RateEpsilon {
 				continue
@@ -55,6 +62,10 @@ func (s *State) adjustPrices(_ *slog.Logger) {
 			if !ok {
 				continue // sink bids are fixed
 			}
+			if adjusted[buyer] {
+				continue
+			}
+			adjusted[buyer] = true
 			escalated := buyer.BidPriceFor(product) * (1 + bidRaisePct)
 			// Wallet-grounded cap: a standing bid never promises more per
 			// unit than the wallet could pay for the full hunger. It
@@ -78,4 +89,11 @@ func (s *State) adjustPrices(_ *slog.Logger) {
 			buyer.SetBidPrice(product, escalated)
 		}
 	}
+
+	// The market maker prices off its inventory, not its fills.
+	for _, p := range s.producers {
+		if mm, ok := p.(*marketmaker.MarketMaker); ok {
+			mm.Skew()
+		}
+	}
 }
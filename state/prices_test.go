@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/market"
 	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/production"
 )
@@ -159,3 +160,35 @@ func Test_adjustPrices_zeroHungerBidEscalatesUncapped(t *testing.T) {
 		t.Fatalf("zero-hunger bid = %v, want uncapped escalation %v", got, 1.0*(1+bidRaisePct))
 	}
 }
+
+func Test_adjustPrices_ladderFollowsHighestOpenRung(t *testing.T) {
+	s := newTestState()
+	f := factory.New("Plates", "Recipe_Plates_C", point.Point{X: 0, Y: 0}, 0,
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		production.Products{production.Production{Name: "IronPlate", Rate: 2}},
+		100)
+	s.producers = []production.Producer{f}
+	f.SetBidPrice("IronIngot", 1.0)
+
+	// The urgent rung is filled, the restocking rungs are not: the
+	// buyer still escalates, once.
+	s.book.Clear()
+	s.book.PostBidLadder(f, "IronIngot", []market.Level{
+		{Rate: 2, UnitPrice: 1.0}, {Rate: 3, UnitPrice: 0.9}, {Rate: 3, UnitPrice: 0.81},
+	}, market.OrderTerms{})
+	s.book.Bids("IronIngot")[0].Remaining = 0
+	s.adjustPrices(testLogger())
+	if got := f.BidPriceFor("IronIngot"); got != 1.0*(1+bidRaisePct) {
+		t.Fatalf("laddered bid = %v, want one raise to %v", got, 1.0*(1+bidRaisePct))
+	}
+
+	// Every rung filled: no raise.
+	f.SetBidPrice("IronIngot", 1.0)
+	for _, bid := range s.book.Bids("IronIngot") {
+		bid.Remaining = 0
+	}
+	s.adjustPrices(testLogger())
+	if got := f.BidPriceFor("IronIngot"); got != 1.0 {
+		t.Fatalf("filled ladder = %v, want 1.0 unchanged", got)
+	}
+}
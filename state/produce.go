@@ -21,12 +21,12 @@ const inputStockTargetTicks = 60.0
 // resources extract into stock, factories run their recipes against
 // stock. Runs before the market so fresh goods are sellable this tick.
 func (s *State) produceGoods(_ *slog.Logger) {
-	for _, p := range s.producers {
-		switch producer := p.(type) {
+	parallelFor(len(s.producers), s.config.Workers, func(i int) {
+		switch producer := s.producers[i].(type) {
 		case *resources.Resource:
 			producer.ProduceTick(outputStockCapTicks)
 		case *factory.Factory:
 			producer.ProduceTick(outputStockCapTicks)
 		}
-	}
+	})
 }
@@ -0,0 +1,99 @@
+package state
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/geo"
+	"github.com/paul-freeman/satisfactory-story/point"
+	storyresources "github.com/paul-freeman/satisfactory-story/resources"
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+)
+
+// newRegions loads the region outlines: Config.RegionFile when one is
+// named, the built-in coarse outlines otherwise.
+func (s *State) newRegions() (*geo.Regions, error) {
+	if s.config.RegionFile == "" {
+		return geo.DefaultRegions()
+	}
+	f, err := os.Open(s.config.RegionFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open region file: %w", err)
+	}
+	defer f.Close()
+	return geo.LoadRegions(f)
+}
+
+// region is the name of the region p lies in; empty when it lies in
+// none, or the world has no regions.
+func (s *State) region(p point.Point) string {
+	if s.regions == nil {
+		return ""
+	}
+	return s.regions.Tag(p)
+}
+
+// location is p on the wire, tagged with its region.
+func (s *State) location(p point.Point) statehttp.Location {
+	return statehttp.Location{X: p.X, Y: p.Y, Region: s.region(p)}
+}
+
+func (s *State) Regions(_ *slog.Logger) statehttp.Regions {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	out := statehttp.Regions{Tick: s.tick, Regions: make([]statehttp.Region, 0)}
+	index := make(map[string]int)
+	// at indexes rather than points into out.Regions: a region first met
+	// mid-loop appends to it, which can move the backing array.
+	at := func(p point.Point) int {
+		name := s.region(p)
+		i, ok := index[name]
+		if !ok {
+			i = len(out.Regions)
+			index[name] = i
+			out.Regions = append(out.Regions, statehttp.Region{Name: name})
+		}
+		return i
+	}
+	if s.regions != nil {
+		for _, name := range s.regions.Names() {
+			index[name] = len(out.Regions)
+			out.Regions = append(out.Regions, statehttp.Region{Name: name})
+		}
+	}
+
+	for _, p := range s.producers {
+		switch producer := p.(type) {
+		case *storyresources.Resource:
+			r := &out.Regions[at(producer.Location())]
+			r.Resources++
+			if producer.Locked {
+				r.Locked++
+			}
+			if producer.Extractor != nil {
+				r.Claimed++
+				r.Cash += producer.Extractor.Wallet.Cash()
+			}
+		case *factory.Factory:
+			r := &out.Regions[at(producer.Location())]
+			r.Factories++
+			r.Cash += producer.Cash()
+		}
+	}
+
+	window := float64(max(1, min(s.tick, tradeMemoryTicks)))
+	for _, edge := range s.ledger.edges() {
+		rate := edge.qty / window
+		from, to := at(edge.seller.Location()), at(edge.buyer.Location())
+		if from == to {
+			out.Regions[from].Internal += rate
+			continue
+		}
+		out.Regions[from].Exports += rate
+		out.Regions[to].Imports += rate
+	}
+	return out
+}
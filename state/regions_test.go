@@ -0,0 +1,96 @@
+package state
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/geo"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
+)
+
+func Test_Regions_aggregates(t *testing.T) {
+	s := newTestState()
+	regions, err := geo.LoadRegions(strings.NewReader(`[
+		{"name": "West", "polygon": [[-400000, -400000], [50000, -400000], [50000, 400000], [-400000, 400000]]},
+		{"name": "East", "polygon": [[50000, -400000], [500000, -400000], [500000, 400000], [50000, 400000]]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.regions = regions
+
+	west, east := point.Point{X: 40000, Y: -80000}, point.Point{X: 120000, Y: -80000}
+	r := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        west,
+	}
+	near := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 41000, Y: -80000}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	far := factory.New("Smelter", "Recipe_IngotIron_C", east, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		500)
+	s.producers = []production.Producer{r, near, far}
+	s.tick = 10
+	s.ledger.record(s.tick, r, near, "OreIron", 20, 1)
+	s.ledger.record(s.tick, r, far, "OreIron", 30, 1)
+
+	got := s.Regions(testLogger())
+	if len(got.Regions) != 2 {
+		t.Fatalf("regions = %+v, want West and East", got.Regions)
+	}
+	w, e := got.Regions[0], got.Regions[1]
+	if w.Name != "West" || w.Resources != 1 || w.Factories != 1 || w.Cash != 1000 {
+		t.Errorf("West = %+v, want 1 resource and 1 factory holding 1000", w)
+	}
+	if w.Internal != 2 || w.Exports != 3 || w.Imports != 0 {
+		t.Errorf("West trade = %v internal, %v out, %v in; want 2, 3, 0", w.Internal, w.Exports, w.Imports)
+	}
+	if e.Name != "East" || e.Factories != 1 || e.Imports != 3 || e.Exports != 0 {
+		t.Errorf("East = %+v, want 1 factory importing 3", e)
+	}
+
+	wire := s.toHTTP()
+	if len(wire.Transports) != 2 || wire.Transports[1].Origin.Region != "West" || wire.Transports[1].Destination.Region != "East" {
+		t.Errorf("transports = %+v, want West to East tagged", wire.Transports)
+	}
+}
+
+func Test_Regions_tradeIntoUnnamedRegion(t *testing.T) {
+	s := newTestState()
+	regions, err := geo.LoadRegions(strings.NewReader(`[
+		{"name": "West", "polygon": [[-400000, -400000], [50000, -400000], [50000, 400000], [-400000, 400000]]},
+		{"name": "East", "polygon": [[50000, -400000], [500000, -400000], [500000, 400000], [50000, 400000]]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.regions = regions
+
+	r := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 40000, Y: -80000},
+	}
+	// Off every outline, and not a producer: its region is first met on
+	// the trade's buyer side.
+	stray := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 40000, Y: 900000}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		0)
+	s.producers = []production.Producer{r}
+	s.tick = 10
+	s.ledger.record(s.tick, r, stray, "OreIron", 40, 1)
+
+	got := s.Regions(testLogger())
+	if len(got.Regions) != 3 || got.Regions[2].Name != "" || got.Regions[2].Imports != 4 {
+		t.Fatalf("regions = %+v, want West, East and an unnamed region importing 4", got.Regions)
+	}
+	if got.Regions[0].Exports != 4 {
+		t.Fatalf("West exports = %v, want 4", got.Regions[0].Exports)
+	}
+}
@@ -0,0 +1,77 @@
+package state
+
+import (
+	"log/slog"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+)
+
+// relocationEventLimit is how many recent relocations the state keeps
+// for /relocations.
+const relocationEventLimit = 100
+
+// relocationEvent is one factory move, as made by factory.Move.
+type relocationEvent struct {
+	tick    int
+	factory string
+	factory.Relocation
+}
+
+// recordRelocations settles the moves the factories made this tick, in
+// producer order. The factories chose their sites concurrently against
+// the land as it stood before anyone moved, so a move onto land an
+// earlier mover has since taken is undone; the rest take their new
+// plots, their cost leaves the economy, and each is logged and kept as
+// an event.
+func (s *State) recordRelocations(l *slog.Logger) {
+	for _, p := range s.producers {
+		f, ok := p.(*factory.Factory)
+		if !ok || f.LastRelocation == nil {
+			continue
+		}
+		plot, _ := plotFor(f)
+		if !s.land.Free(plot, f) {
+			l.Debug("relocation undone: site taken",
+				slog.String("factory", f.Name),
+				slog.String("to", f.LastRelocation.To.String()))
+			f.UndoRelocation()
+			continue
+		}
+		s.land.Remove(f)
+		s.land.Add(f, plot)
+		reloc := *f.LastRelocation
+		s.money.record(channelRelocation, -reloc.Cost)
+		s.counters.relocations++
+		s.relocations = append(s.relocations, relocationEvent{tick: s.tick, factory: f.Name, Relocation: reloc})
+		l.Debug("relocated factory",
+			slog.String("factory", f.Name),
+			slog.String("from", reloc.From.String()),
+			slog.String("to", reloc.To.String()),
+			slog.Float64("cost", reloc.Cost))
+	}
+	if excess := len(s.relocations) - relocationEventLimit; excess > 0 {
+		s.relocations = append(s.relocations[:0], s.relocations[excess:]...)
+	}
+}
+
+func (s *State) Relocations(_ *slog.Logger) statehttp.Relocations {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	out := statehttp.Relocations{
+		Tick:   s.tick,
+		Events: make([]statehttp.Relocation, 0, len(s.relocations)),
+	}
+	for _, ev := range s.relocations {
+		out.Events = append(out.Events, statehttp.Relocation{
+			Tick:     ev.tick,
+			Factory:  ev.factory,
+			From:     s.location(ev.From),
+			To:       s.location(ev.To),
+			Distance: ev.Distance(),
+			Cost:     ev.Cost,
+		})
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+)
+
+func Test_moveProducers_recordsRelocations(t *testing.T) {
+	s := newTestState()
+	s.tick = 7
+	f := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 0, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	f.Building = recipes.Smelter
+	f.RecordTrade(s.tick, point.Point{X: 50000, Y: 0}, 5)
+	s.producers = []production.Producer{f}
+	s.money.open(s.moneySupply())
+
+	s.moveProducers(testLogger())
+
+	s.money.close(s.tick, s.moneySupply())
+	if !s.money.balanced() {
+		t.Fatalf("money out of balance by %v after a relocation", s.money.last.discrepancy)
+	}
+	if len(s.relocations) != 1 || s.counters.relocations != 1 {
+		t.Fatalf("relocations = %+v, want the one move recorded", s.relocations)
+	}
+	ev := s.relocations[0]
+	if ev.tick != 7 || ev.factory != "Smelter" || ev.To != f.Loc || ev.Cost != f.RelocationCost(ev.Distance()) {
+		t.Fatalf("event = %+v, want tick 7's move of the Smelter to %v at its relocation cost", ev, f.Loc)
+	}
+	if got := s.money.last.flows[channelRelocation]; got != -ev.Cost {
+		t.Fatalf("relocation channel = %v, want -%v", got, ev.Cost)
+	}
+	if wire := s.Relocations(testLogger()); len(wire.Events) != 1 || wire.Events[0].Distance != ev.Distance() {
+		t.Fatalf("wire relocations = %+v, want the move", wire.Events)
+	}
+}
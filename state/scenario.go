@@ -0,0 +1,320 @@
+package state
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+	storyresources "github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/sink"
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+	"github.com/paul-freeman/satisfactory-story/terrain"
+)
+
+//go:embed scenarios/*.json
+var bundledScenarios embed.FS
+
+// Scenario declares a starting economy: the engine config and seed, which
+// resource nodes exist, what is already built on them, the factories and
+// sinks standing at tick 0, the treasury, and which recipes are on. Every
+// part is optional; what a scenario leaves out starts as New would.
+type Scenario struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Seed and Treasury, when set, replace the caller's seed and
+	// initialTreasuryFund.
+	Seed     *int64   `json:"seed,omitempty"`
+	Treasury *float64 `json:"treasury,omitempty"`
+	// Config is read over DefaultConfig, so a scenario names only the
+	// fields it changes.
+	Config Config `json:"config"`
+	// Nodes, when set, limits the world to the resource nodes it admits.
+	Nodes *ScenarioNodes `json:"nodes,omitempty"`
+	// Recipes turns recipes on (true) or off (false) by ID.
+	Recipes    map[string]bool     `json:"recipes,omitempty"`
+	Extractors []ScenarioExtractor `json:"extractors,omitempty"`
+	Factories  []ScenarioFactory   `json:"factories,omitempty"`
+	// Sinks, when set, replace the goal sinks at the bases.
+	Sinks []ScenarioSink `json:"sinks,omitempty"`
+}
+
+// ScenarioNodes admits the resource nodes of the listed products in the
+// listed regions; an empty list admits all.
+type ScenarioNodes struct {
+	Products []string `json:"products,omitempty"`
+	Regions  []string `json:"regions,omitempty"`
+}
+
+// ScenarioExtractor is an extractor built at tick 0 on the unclaimed node
+// of Product nearest Near, held by Config.NodeOwner with Cash in its
+// wallet.
+type ScenarioExtractor struct {
+	Product string                       `json:"product"`
+	Near    point.Point                  `json:"near"`
+	Kind    storyresources.ExtractorKind `json:"kind"`
+	Cash    float64                      `json:"cash"`
+}
+
+// ScenarioFactory is a factory standing at tick 0, with its own cash (on
+// top of the treasury) and stock on hand.
+type ScenarioFactory struct {
+	Recipe      string             `json:"recipe"`
+	Location    point.Point        `json:"location"`
+	Cash        float64            `json:"cash"`
+	InputStock  map[string]float64 `json:"inputStock,omitempty"`
+	OutputStock map[string]float64 `json:"outputStock,omitempty"`
+}
+
+// ScenarioSink is a sink bidding Bid per unit (goalBidUnitPrice when 0)
+// for Rate units of Product per tick (sinkDemandRate when 0), standing
+// at Location (the first base when unset).
+type ScenarioSink struct {
+	Product  string       `json:"product"`
+	Location *point.Point `json:"location,omitempty"`
+	Bid      float64      `json:"bid"`
+	Rate     float64      `json:"rate"`
+}
+
+// LoadScenario reads a JSON scenario. Unknown fields and unknown
+// transport modes, node owners and matchers are errors, so a misspelt
+// setting cannot silently fall back to its default.
+func LoadScenario(r io.Reader) (*Scenario, error) {
+	sc := &Scenario{Config: DefaultConfig()}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(sc); err != nil {
+		return nil, fmt.Errorf("failed to decode scenario: %w", err)
+	}
+	if err := sc.Config.validate(); err != nil {
+		return nil, fmt.Errorf("scenario config: %w", err)
+	}
+	return sc, nil
+}
+
+// OpenScenario loads the scenario file at name, or the bundled scenario
+// of that name when there is no such file.
+func OpenScenario(name string) (*Scenario, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return BundledScenario(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scenario: %w", err)
+	}
+	defer f.Close()
+	return LoadScenario(f)
+}
+
+// BundledScenario loads the bundled scenario of the given name: its file
+// name, with or without the .json extension.
+func BundledScenario(name string) (*Scenario, error) {
+	if path.Ext(name) != ".json" {
+		name += ".json"
+	}
+	f, err := bundledScenarios.Open(path.Join("scenarios", name))
+	if err != nil {
+		return nil, fmt.Errorf("no scenario %q: %w", name, err)
+	}
+	defer f.Close()
+	return LoadScenario(f)
+}
+
+// NewFromScenario creates a State starting from the economy sc declares,
+// seeded with the scenario's seed when it has one. Reset starts the same
+// scenario over.
+func NewFromScenario(l *slog.Logger, logLevel *slog.Level, seed int64, sc *Scenario) (*State, error) {
+	if sc.Seed != nil {
+		seed = *sc.Seed
+	}
+	s := &State{config: sc.Config, scenario: sc}
+	err := s.getInitialState(l, logLevel, seed)
+	return s, err
+}
+
+// Scenarios lists the bundled scenarios.
+func (s *State) Scenarios(l *slog.Logger) []statehttp.Scenario {
+	entries, err := bundledScenarios.ReadDir("scenarios")
+	if err != nil {
+		l.Error("failed to list scenarios: " + err.Error())
+		return nil
+	}
+	out := make([]statehttp.Scenario, 0, len(entries))
+	for _, entry := range entries {
+		sc, err := BundledScenario(entry.Name())
+		if err != nil {
+			l.Error("failed to load bundled scenario: " + err.Error())
+			continue
+		}
+		out = append(out, statehttp.Scenario{
+			File:        entry.Name(),
+			Name:        sc.Name,
+			Description: sc.Description,
+		})
+	}
+	return out
+}
+
+// scenarioRecipes applies the scenario's recipe toggles. It fails on a
+// recipe the data does not have.
+func (s *State) scenarioRecipes(rs recipes.Recipes) error {
+	if s.scenario == nil {
+		return nil
+	}
+	for id, active := range s.scenario.Recipes {
+		found := false
+		for _, r := range rs {
+			if r.ID() == id {
+				r.Active, found = active, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("scenario: unknown recipe %q", id)
+		}
+	}
+	return nil
+}
+
+// scenarioNodes keeps the resource nodes the scenario admits. It fails
+// on a region the map does not know or a product no node produces.
+func (s *State) scenarioNodes(nodes []*storyresources.Resource) ([]*storyresources.Resource, error) {
+	if s.scenario == nil || s.scenario.Nodes == nil {
+		return nodes, nil
+	}
+	admit := s.scenario.Nodes
+	known := s.regions.Names()
+	for _, name := range admit.Regions {
+		if !slices.Contains(known, name) {
+			return nil, fmt.Errorf("scenario: unknown region %q", name)
+		}
+	}
+	for _, product := range admit.Products {
+		if !slices.ContainsFunc(nodes, func(node *storyresources.Resource) bool {
+			return node.Production.Name == product
+		}) {
+			return nil, fmt.Errorf("scenario: no resource node produces %q", product)
+		}
+	}
+	kept := make([]*storyresources.Resource, 0, len(nodes))
+	for _, node := range nodes {
+		if len(admit.Products) > 0 && !slices.Contains(admit.Products, node.Production.Name) {
+			continue
+		}
+		if len(admit.Regions) > 0 && !slices.Contains(admit.Regions, s.region(node.Location())) {
+			continue
+		}
+		kept = append(kept, node)
+	}
+	return kept, nil
+}
+
+// scenarioSinks returns the scenario's sinks, or the goal sinks at the
+// bases when it declares none. It fails on a product that neither a
+// recipe nor a resource node produces, or a sink outside the world
+// bounds b.
+func (s *State) scenarioSinks(rs recipes.Recipes, nodes []*storyresources.Resource, b terrain.Bounds) ([]*sink.Sink, error) {
+	if s.scenario == nil || len(s.scenario.Sinks) == 0 {
+		return newSinks(rs, s.bases), nil
+	}
+	sinks := make([]*sink.Sink, 0, len(s.scenario.Sinks))
+	for i, spec := range s.scenario.Sinks {
+		produced := slices.ContainsFunc(rs, func(r *recipes.Recipe) bool {
+			return r.Outputs().Contains(spec.Product)
+		}) || slices.ContainsFunc(nodes, func(node *storyresources.Resource) bool {
+			return node.Production.Name == spec.Product
+		})
+		if !produced {
+			return nil, fmt.Errorf("scenario: sink %d: nothing produces %q", i, spec.Product)
+		}
+		loc := s.bases[0]
+		if spec.Location != nil {
+			loc = *spec.Location
+		}
+		if !b.Contains(loc) {
+			return nil, fmt.Errorf("scenario: sink %d at %v lies outside the world bounds %+v", i, loc, b)
+		}
+		bid := spec.Bid
+		if bid == 0 {
+			bid = goalBidUnitPrice
+		}
+		sk := sink.New(spec.Product, loc, production.Products{production.New(spec.Product, 1, 1)}, bid)
+		sk.Rate = spec.Rate
+		sinks = append(sinks, sk)
+	}
+	return sinks, nil
+}
+
+// placeScenario builds the scenario's extractors and factories. A
+// factory must name a known recipe and stand on free, buildable land
+// within the world bounds b.
+func (s *State) placeScenario(b terrain.Bounds) error {
+	if s.scenario == nil {
+		return nil
+	}
+	for i, spec := range s.scenario.Extractors {
+		node := s.nearestOpenNode(spec.Product, spec.Near)
+		if node == nil {
+			return fmt.Errorf("scenario: extractor %d: no unclaimed %s node", i, spec.Product)
+		}
+		if err := node.Claim(spec.Kind, s.config.NodeOwner, defaultExtractorClock, 0); err != nil {
+			return fmt.Errorf("scenario: extractor %d: %w", i, err)
+		}
+		node.Extractor.Wallet.Adjust(spec.Cash)
+	}
+
+	for i, spec := range s.scenario.Factories {
+		var recipe *recipes.Recipe
+		for _, r := range s.recipes {
+			if r.ID() == spec.Recipe {
+				recipe = r
+			}
+		}
+		if recipe == nil {
+			return fmt.Errorf("scenario: factory %d: unknown recipe %q", i, spec.Recipe)
+		}
+		if !b.Contains(spec.Location) {
+			return fmt.Errorf("scenario: factory %d at %v lies outside the world bounds %+v", i, spec.Location, b)
+		}
+		f := factory.New(recipe.Name(), recipe.ID(), spec.Location, 0,
+			recipe.Inputs(), recipe.Outputs(), spec.Cash)
+		f.Building = recipe.ProducedIn
+		plot, _ := plotFor(f)
+		if !s.land.Free(plot, nil) || (s.terrain != nil && !s.terrain.Passable(f.Loc)) {
+			return fmt.Errorf("scenario: factory %d: no room for a %s at %v", i, f.Building, f.Loc)
+		}
+		for product, qty := range spec.InputStock {
+			f.InputStock.Add(product, qty)
+		}
+		for product, qty := range spec.OutputStock {
+			f.OutputStock.Add(product, qty)
+		}
+		s.placeFactory(f)
+		s.land.Add(f, plot)
+		s.producers = append(s.producers, f)
+	}
+	return nil
+}
+
+// nearestOpenNode is the unclaimed, unlocked node of product nearest p,
+// or nil if there is none.
+func (s *State) nearestOpenNode(product string, p point.Point) *storyresources.Resource {
+	var best *storyresources.Resource
+	for _, producer := range s.producers {
+		r, ok := producer.(*storyresources.Resource)
+		if !ok || r.Locked || r.Claimed() || r.Production.Name != product {
+			continue
+		}
+		if best == nil || p.Distance(r.Location()) < p.Distance(best.Location()) {
+			best = r
+		}
+	}
+	return best
+}
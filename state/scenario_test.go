@@ -0,0 +1,254 @@
+package state
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/geo"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/terrain"
+)
+
+func Test_LoadScenario(t *testing.T) {
+	sc, err := LoadScenario(strings.NewReader(`{
+		"name": "Test",
+		"treasury": 500,
+		"config": {"transit": true}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Treasury == nil || *sc.Treasury != 500 || sc.Seed != nil {
+		t.Fatalf("treasury/seed = %v/%v, want 500/unset", sc.Treasury, sc.Seed)
+	}
+	want := DefaultConfig()
+	if !sc.Config.Transit || sc.Config.HubClusters != want.HubClusters || sc.Config.Matcher != want.Matcher {
+		t.Fatalf("config = %+v, want the defaults with Transit on", sc.Config)
+	}
+
+	for _, bad := range []string{
+		`{"config": {"transti": true}}`,
+		`{"config": {"transportModes": ["Truck"]}}`,
+		`{"config": {"nodeOwner": "Company"}}`,
+		`{"config": {"matcher": "kdouble"}}`,
+	} {
+		if _, err := LoadScenario(strings.NewReader(bad)); err == nil {
+			t.Fatalf("LoadScenario(%s) should fail on the misspelt setting", bad)
+		}
+	}
+}
+
+func Test_Scenarios_bundled(t *testing.T) {
+	s := newTestState()
+	list := s.Scenarios(testLogger())
+	if len(list) == 0 {
+		t.Fatal("expected bundled scenarios")
+	}
+	for _, entry := range list {
+		if entry.Name == "" || entry.Description == "" {
+			t.Errorf("bundled scenario %s lacks a name or description", entry.File)
+		}
+		if _, err := OpenScenario(strings.TrimSuffix(entry.File, ".json")); err != nil {
+			t.Errorf("OpenScenario(%s): %v", entry.File, err)
+		}
+	}
+	if _, err := OpenScenario("no-such-scenario"); err == nil {
+		t.Fatal("an unknown scenario should fail to open")
+	}
+}
+
+func Test_NewFromScenario_everyBundled(t *testing.T) {
+	if rs, err := recipes.New(); err != nil || len(rs) == 0 {
+		t.Skip("recipes/Docs.json is the stub: bundled scenarios name real recipes")
+	}
+	for _, entry := range newTestState().Scenarios(testLogger()) {
+		name := strings.TrimSuffix(entry.File, ".json")
+		t.Run(name, func(t *testing.T) {
+			sc, err := BundledScenario(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewFromScenario(testLogger(), nil, 1, sc); err != nil {
+				t.Fatalf("NewFromScenario: %v", err)
+			}
+		})
+	}
+}
+
+func Test_NewFromScenario_boundsFitAdmittedNodes(t *testing.T) {
+	whole, err := New(testLogger(), nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := &Scenario{
+		Config: DefaultConfig(),
+		Nodes:  &ScenarioNodes{Regions: []string{"Grass Fields"}},
+	}
+	s, err := NewFromScenario(testLogger(), nil, 1, sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.xmax-s.xmin >= whole.xmax-whole.xmin || s.ymax-s.ymin >= whole.ymax-whole.ymin {
+		t.Fatalf("bounds = x %d..%d y %d..%d, want narrower than the whole map's x %d..%d y %d..%d",
+			s.xmin, s.xmax, s.ymin, s.ymax, whole.xmin, whole.xmax, whole.ymin, whole.ymax)
+	}
+	for _, p := range s.producers {
+		if r, ok := p.(*resources.Resource); ok {
+			loc := r.Location()
+			if loc.X < s.xmin || loc.X > s.xmax || loc.Y < s.ymin || loc.Y > s.ymax {
+				t.Fatalf("node %s lies outside the bounds", r.PrettyPrint())
+			}
+		}
+	}
+}
+
+func Test_NewFromScenario_startRegion(t *testing.T) {
+	sc, err := BundledScenario("grass-fields-start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	treasury := 1234.0
+	sc.Treasury = &treasury
+	s, err := NewFromScenario(testLogger(), nil, 1, sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.treasury != treasury || s.bases[0] != sc.Config.Bases[0] {
+		t.Fatalf("treasury/base = %v/%v, want %v/%v", s.treasury, s.bases[0], treasury, sc.Config.Bases[0])
+	}
+	open, locked := 0, 0
+	for _, p := range s.producers {
+		if r, ok := p.(*resources.Resource); ok {
+			if r.Locked {
+				locked++
+			} else if s.region(r.Location()) == "Grass Fields" {
+				open++
+			} else {
+				t.Fatalf("node %s in %q is open", r.PrettyPrint(), s.region(r.Location()))
+			}
+		}
+	}
+	if open == 0 || locked == 0 {
+		t.Fatalf("open/locked nodes = %d/%d, want both", open, locked)
+	}
+
+	s.Reset(testLogger(), nil)
+	if s.treasury != treasury {
+		t.Fatalf("treasury after Reset = %v, want the scenario's %v", s.treasury, treasury)
+	}
+}
+
+func Test_placeScenario(t *testing.T) {
+	rs := recipes.Recipes{{
+		ClassName:      "Recipe_Smelt_C",
+		DisplayName:    "Smelt Ore",
+		Active:         true,
+		ProducedIn:     recipes.Smelter,
+		InputProducts:  production.Products{{Name: "Ore", Rate: 1}},
+		OutputProducts: production.Products{{Name: "Ingot", Rate: 1}},
+	}}
+	ore := &resources.Resource{
+		Production: production.Production{Name: "Ore", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+	}
+	s := newTestStateWithProducers(rs, []production.Producer{ore})
+	s.bases = []point.Point{{X: 5000, Y: 5000}}
+	s.scenario = &Scenario{
+		Recipes: map[string]bool{"Recipe_Smelt_C": false},
+		Extractors: []ScenarioExtractor{
+			{Product: "Ore", Near: point.Point{X: 100, Y: 100}, Kind: resources.MinerMk1, Cash: 50},
+		},
+		Factories: []ScenarioFactory{
+			{Recipe: "Recipe_Smelt_C", Location: point.Point{X: 2000, Y: 0}, Cash: 300,
+				InputStock: map[string]float64{"Ore": 7}},
+		},
+		Sinks: []ScenarioSink{{Product: "Ingot", Bid: 12, Rate: 3}},
+	}
+
+	world := terrain.Bounds{Xmin: 0, Xmax: 10000, Ymin: 0, Ymax: 10000}
+
+	if err := s.scenarioRecipes(s.recipes); err != nil || rs[0].Active {
+		t.Fatalf("scenarioRecipes = %v, active %v, want the recipe off", err, rs[0].Active)
+	}
+	sinks, err := s.scenarioSinks(s.recipes, []*resources.Resource{ore}, world)
+	if err != nil || len(sinks) != 1 || sinks[0].Loc != s.bases[0] || sinks[0].BidUnitPrice != 12 || sinks[0].Rate != 3 {
+		t.Fatalf("sinks = %+v, %v, want one bidding 12 for 3 at the base", sinks, err)
+	}
+	s.scenario.Sinks = []ScenarioSink{{Product: "Ingto"}}
+	if _, err := s.scenarioSinks(s.recipes, []*resources.Resource{ore}, world); err == nil {
+		t.Fatal("a sink for a product nothing makes should be rejected")
+	}
+	s.scenario.Sinks = []ScenarioSink{{Product: "Ingot", Location: &point.Point{X: 20000, Y: 0}}}
+	if _, err := s.scenarioSinks(s.recipes, []*resources.Resource{ore}, world); err == nil {
+		t.Fatal("a sink outside the world bounds should be rejected")
+	}
+	s.scenario.Sinks = []ScenarioSink{{Product: "Ingot", Bid: 12, Rate: 3}}
+	s.land = s.landMap()
+	if err := s.placeScenario(world); err != nil {
+		t.Fatal(err)
+	}
+	if !ore.Claimed() || ore.Extractor.Wallet.Cash() != 50 {
+		t.Fatalf("ore extractor = %+v, want a funded miner", ore.Extractor)
+	}
+	f, ok := s.producers[len(s.producers)-1].(*factory.Factory)
+	if !ok || f.Cash() != 300 || f.InputStock.Get("Ore") != 7 || f.Building != recipes.Smelter || f.Place == nil {
+		t.Fatalf("placed factory = %+v, want the stocked, funded smelter", s.producers[len(s.producers)-1])
+	}
+
+	s.producers = append(s.producers, sinks[0])
+	s.publishOrders(testLogger())
+	if bid, ok := s.book.BestBid("Ingot"); !ok || bid.Remaining != 3 || bid.UnitPrice != 12 {
+		t.Fatalf("sink bid = %+v, want 3 at 12", bid)
+	}
+
+	s.scenario.Extractors = nil                                  // built already
+	s.scenario.Factories[0].Location = point.Point{X: 10, Y: 10} // on the node's tile
+	if err := s.placeScenario(world); err == nil {
+		t.Fatal("a factory on taken land should be rejected")
+	}
+	s.scenario.Factories[0].Location = point.Point{X: -2000, Y: 0}
+	if err := s.placeScenario(world); err == nil {
+		t.Fatal("a factory outside the world bounds should be rejected")
+	}
+	s.scenario = &Scenario{Recipes: map[string]bool{"Recipe_Nope_C": true}}
+	if err := s.scenarioRecipes(s.recipes); err == nil {
+		t.Fatal("an unknown recipe should be rejected")
+	}
+}
+
+func Test_scenarioNodes_checksNames(t *testing.T) {
+	s := newTestState()
+	regions, err := geo.LoadRegions(strings.NewReader(`[
+		{"name": "West", "polygon": [[-400000, -400000], [0, -400000], [0, 400000], [-400000, 400000]]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.regions = regions
+	west := &resources.Resource{
+		Production: production.Production{Name: "Ore", Rate: 1},
+		Loc:        geo.WorldToPoint(geo.World{X: -100000, Y: 0}),
+	}
+	east := &resources.Resource{
+		Production: production.Production{Name: "Ore", Rate: 1},
+		Loc:        geo.WorldToPoint(geo.World{X: 100000, Y: 0}),
+	}
+	nodes := []*resources.Resource{west, east}
+
+	s.scenario = &Scenario{Nodes: &ScenarioNodes{Products: []string{"Ore"}, Regions: []string{"West"}}}
+	if kept, err := s.scenarioNodes(nodes); err != nil || len(kept) != 1 || kept[0] != west {
+		t.Fatalf("kept = %v, %v, want only the western node", kept, err)
+	}
+	s.scenario.Nodes = &ScenarioNodes{Regions: []string{"Wset"}}
+	if _, err := s.scenarioNodes(nodes); err == nil {
+		t.Fatal("an unknown region should be rejected")
+	}
+	s.scenario.Nodes = &ScenarioNodes{Products: []string{"Oer"}}
+	if _, err := s.scenarioNodes(nodes); err == nil {
+		t.Fatal("a product no node produces should be rejected")
+	}
+}
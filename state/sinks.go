@@ -21,28 +21,32 @@ const spaceElevatorPartPrefix = "SpaceElevatorPart_"
 // supply tiers.
 const goalBidUnitPrice = 1000.0
 
-// sinkDemandRate is the standing bid rate for sinks. Effectively
-// unlimited against realistic production rates (single recipes run at
-// ~0.1-10 units/sec) while staying readable in the UI and safe in
-// min() arithmetic.
+// sinkDemandRate is the standing bid rate for sinks that set none of
+// their own (see sink.Sink.Rate). Effectively unlimited against
+// realistic production rates (single recipes run at ~0.1-10 units/sec)
+// while staying readable in the UI and safe in min() arithmetic.
 const sinkDemandRate = 100.0
 
 // newSinks creates one Sink per distinct space-elevator part product found
-// among the recipe outputs, all located at the center of the world bounds
-// (representing the player's base).
-func newSinks(rs recipes.Recipes, xmin, xmax, ymin, ymax int) []*sink.Sink {
-	center := point.Point{X: (xmin + xmax) / 2, Y: (ymin + ymax) / 2}
-
+// among the recipe outputs at each of the player's bases.
+func newSinks(rs recipes.Recipes, bases []point.Point) []*sink.Sink {
 	seen := make(map[string]bool)
-	sinks := make([]*sink.Sink, 0)
+	parts := make([]string, 0)
 	for _, recipe := range rs {
 		for _, output := range recipe.Outputs() {
 			if !strings.HasPrefix(output.Name, spaceElevatorPartPrefix) || seen[output.Name] {
 				continue
 			}
 			seen[output.Name] = true
-			sinks = append(sinks, sink.New(output.Name, center, production.Products{
-				production.New(output.Name, 1, 1),
+			parts = append(parts, output.Name)
+		}
+	}
+
+	sinks := make([]*sink.Sink, 0, len(parts)*len(bases))
+	for _, base := range bases {
+		for _, part := range parts {
+			sinks = append(sinks, sink.New(part, base, production.Products{
+				production.New(part, 1, 1),
 			}, goalBidUnitPrice))
 		}
 	}
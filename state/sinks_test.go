@@ -3,6 +3,7 @@ package state
 import (
 	"testing"
 
+	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/production"
 	"github.com/paul-freeman/satisfactory-story/recipes"
 )
@@ -15,7 +16,7 @@ func Test_newSinks_finds_distinct_space_elevator_parts(t *testing.T) {
 		{DisplayName: "D", OutputProducts: production.Products{{Name: "IronPlate", Rate: 1}}}, // not a sink product
 	}
 
-	sinks := newSinks(rs, 0, 1000, 0, 1000)
+	sinks := newSinks(rs, []point.Point{{X: 500, Y: 500}})
 	if len(sinks) != 2 {
 		t.Fatalf("expected 2 distinct sinks, got %d", len(sinks))
 	}
@@ -32,3 +33,25 @@ func Test_newSinks_finds_distinct_space_elevator_parts(t *testing.T) {
 		}
 	}
 }
+
+func Test_newSinks_oneSetPerBase(t *testing.T) {
+	rs := recipes.Recipes{
+		{DisplayName: "A", OutputProducts: production.Products{{Name: "SpaceElevatorPart_1", Rate: 1}}},
+		{DisplayName: "C", OutputProducts: production.Products{{Name: "SpaceElevatorPart_2", Rate: 1}}},
+	}
+	bases := []point.Point{{X: 0, Y: 0}, {X: 9000, Y: 0}}
+
+	sinks := newSinks(rs, bases)
+	if len(sinks) != 4 {
+		t.Fatalf("expected 2 sinks at each of 2 bases, got %d", len(sinks))
+	}
+	at := map[point.Point]int{}
+	for _, sk := range sinks {
+		at[sk.Location()]++
+	}
+	for _, base := range bases {
+		if at[base] != 2 {
+			t.Errorf("base %v hosts %d sinks, want 2", base, at[base])
+		}
+	}
+}
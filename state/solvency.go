@@ -5,6 +5,7 @@ import (
 
 	"github.com/paul-freeman/satisfactory-story/factory"
 	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
 )
 
 // upkeepPerTick is the fixed cost every factory pays per tick just for
@@ -12,10 +13,16 @@ import (
 // Phase 6 it is no longer a macro-level money drain: applySolvency
 // collects it into the treasury as rent (funding future seed capital)
 // rather than burning it. The remaining macro drains are the transport
-// share of trades, purchases from wallet-less resource nodes, and
-// negative culled residuals.
+// share of trades and negative culled residuals; resource-node sales
+// reach the node's owner (see payNodeOwner).
 const upkeepPerTick = 0.5
 
+// extractorUpkeepPerTick is the rent an extractor company pays the
+// treasury per tick. A company whose node stops selling runs its wallet
+// negative and, after insolvencyGrace, abandons the node so it can be
+// reclaimed when demand returns.
+const extractorUpkeepPerTick = 0.25
+
 // insolvencyGrace is how many consecutive ticks a factory's wallet may
 // sit below zero before it is removed as bankrupt. Purchases can never
 // overdraw a wallet (budget clamp at trade time); only upkeep drags a
@@ -39,12 +46,19 @@ const salvageTrickleFraction = 0.25
 const inputSpendSmoothing = 0.05
 
 // applySolvency runs each factory's tick economics: salvage trickle on
-// capped outputs, fold trade flows into the EMAs, apply upkeep, remove
+// capped outputs, fold trade flows into the EMAs, apply upkeep and any
+// land rent, remove
 // the persistently insolvent. Trade money itself already moved at trade
 // time (executeTrade); this is the once-per-tick accounting call.
 func (s *State) applySolvency(l *slog.Logger) {
+	if s.config.LandRent > 0 {
+		s.land = s.landMap()
+	}
 	survivors := make([]production.Producer, 0, len(s.producers))
 	for _, p := range s.producers {
+		if r, ok := p.(*resources.Resource); ok {
+			s.chargeExtractorRent(l, r)
+		}
 		f, ok := p.(*factory.Factory)
 		if !ok {
 			survivors = append(survivors, p)
@@ -60,13 +74,16 @@ func (s *State) applySolvency(l *slog.Logger) {
 			}
 		}
 		f.TickRevenue += salvage
+		s.money.record(channelSalvage, salvage)
 		f.FoldTickFlows(inputSpendSmoothing)
-		f.Wallet.Apply(salvage - upkeepPerTick)
+		rent := s.landRent(f)
+		f.Wallet.Apply(salvage - upkeepPerTick - rent)
 		// Rent: the upkeep the factory just paid is collected into the
 		// treasury rather than burned. The factory's wallet change above
 		// is identical either way, so solvency dynamics are unchanged --
 		// only the money's destination moves, funding future seed capital.
-		s.treasury += upkeepPerTick
+		// Land rent, when charged, goes the same way.
+		s.treasury += upkeepPerTick + rent
 
 		if f.Wallet.InsolventFor(insolvencyGrace) {
 			l.Debug("removing bankrupt factory",
@@ -79,7 +96,12 @@ func (s *State) applySolvency(l *slog.Logger) {
 			// phase that might cull profitable-but-idle factories.
 			if cash := f.Wallet.Cash(); cash > 0 {
 				s.treasury += cash
+			} else {
+				s.money.record(channelCulled, -cash)
 			}
+			s.tickLifespans = append(s.tickLifespans, s.tick-f.CreatedTick)
+			s.withdrawOrders(f)
+			s.counters.bankruptcies++
 			continue // not kept: the factory and its stock vanish
 		}
 
@@ -87,3 +109,26 @@ func (s *State) applySolvency(l *slog.Logger) {
 	}
 	s.producers = survivors
 }
+
+// chargeExtractorRent collects an extractor company's upkeep into the
+// treasury and releases the node once the company has been insolvent
+// for insolvencyGrace ticks. State-held nodes pay no rent.
+func (s *State) chargeExtractorRent(l *slog.Logger, r *resources.Resource) {
+	if r.Extractor == nil || r.Extractor.Owner != resources.OwnerCompany {
+		return
+	}
+	r.Extractor.Wallet.Apply(-extractorUpkeepPerTick)
+	s.treasury += extractorUpkeepPerTick
+	if r.Extractor.Wallet.InsolventFor(insolvencyGrace) {
+		l.Debug("releasing abandoned resource node",
+			slog.String("node", r.PrettyPrint()),
+			slog.Float64("cash", r.Extractor.Wallet.Cash()))
+		// Same residual accounting as a culled factory.
+		if cash := r.Extractor.Wallet.Cash(); cash > 0 {
+			s.treasury += cash
+		} else {
+			s.money.record(channelCulled, -cash)
+		}
+		r.Release()
+	}
+}
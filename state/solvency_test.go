@@ -6,6 +6,7 @@ import (
 	"github.com/paul-freeman/satisfactory-story/factory"
 	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
 )
 
 func Test_applySolvency_salvageTrickleOnlyWhenCapped(t *testing.T) {
@@ -90,3 +91,30 @@ func Test_applySolvency_removesInsolvent(t *testing.T) {
 		t.Fatal("factory should be removed once insolvent for the full grace window")
 	}
 }
+
+func Test_applySolvency_releasesAbandonedExtractor(t *testing.T) {
+	s := newTestState()
+	r := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+	}
+	if err := r.Claim(resources.MinerMk1, resources.OwnerCompany, 1, 0); err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	s.producers = []production.Producer{r}
+
+	before := s.treasury
+	s.applySolvency(testLogger())
+	if got := s.treasury; got != before+extractorUpkeepPerTick {
+		t.Fatalf("treasury = %v, want %v (extractor rent collected)", got, before+extractorUpkeepPerTick)
+	}
+	for i := 1; i < insolvencyGrace && r.Claimed(); i++ {
+		s.applySolvency(testLogger())
+	}
+	if r.Claimed() {
+		t.Fatalf("a company that never sold should abandon its node after %d ticks", insolvencyGrace)
+	}
+	if len(s.producers) != 1 {
+		t.Fatal("the released node itself must stay in the world")
+	}
+}
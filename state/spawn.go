@@ -7,6 +7,7 @@ import (
 	"github.com/paul-freeman/satisfactory-story/factory"
 	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/recipes"
+	"github.com/paul-freeman/satisfactory-story/resources"
 )
 
 // seedCapitalBufferTicks funds a new factory with this many ticks' worth
@@ -96,12 +97,24 @@ func (s *State) spawnNewProducer(l *slog.Logger) {
 		l.Debug("spawn skipped: treasury short",
 			slog.Float64("treasury", s.treasury),
 			slog.Float64("seedCapital", seedCapital))
+		s.counters.skippedSpawns++
+		return
+	}
+	site, ok := s.spawnLocation(chosenRecipe)
+	if !ok {
+		l.Debug("spawn skipped: no free site",
+			slog.String("recipe", chosenRecipe.Name()))
+		s.counters.skippedSpawns++
 		return
 	}
 	s.treasury -= seedCapital
 
-	newFactory := factory.New(chosenRecipe.Name(), chosenRecipe.ID(), s.spawnLocation(chosenRecipe), s.tick,
+	newFactory := factory.New(chosenRecipe.Name(), chosenRecipe.ID(), site, s.tick,
 		chosenRecipe.Inputs(), chosenRecipe.Outputs(), seedCapital)
+	newFactory.Building = chosenRecipe.ProducedIn
+	s.placeFactory(newFactory)
+	plot, _ := plotFor(newFactory)
+	s.land.Add(newFactory, plot)
 	// Start bidding at the going rate where one exists; the price loop
 	// escalates from there if the bids go unfilled.
 	for _, input := range chosenRecipe.Inputs() {
@@ -110,6 +123,7 @@ func (s *State) spawnNewProducer(l *slog.Logger) {
 		}
 	}
 	s.producers = append(s.producers, newFactory)
+	s.counters.spawns++
 	l.Debug("spawned producer", slog.String("factory", newFactory.Name))
 }
 
@@ -135,7 +149,8 @@ func (s *State) expectedProfit(r *recipes.Recipe) float64 {
 
 // estimatedUnitCost is the best current estimate of what one unit of
 // product costs to buy: the best standing ask, else the last traded
-// price, else a pessimistic default.
+// price, else the quote of an unclaimed resource node that could be
+// extracted for it, else a pessimistic default.
 func (s *State) estimatedUnitCost(product string) float64 {
 	if ask, ok := s.book.BestAsk(product); ok {
 		return ask.UnitPrice
@@ -143,9 +158,32 @@ func (s *State) estimatedUnitCost(product string) float64 {
 	if price, ok := s.lastTrade[product]; ok {
 		return price
 	}
+	if price, ok := s.prospectiveAsk(product); ok {
+		return price
+	}
 	return unknownInputUnitCost
 }
 
+// prospectiveAsk is the lowest standing quote among unclaimed, unlocked
+// resource nodes of the product. An unclaimed node posts no asks (it has
+// no stock), but its quote is public: without it, a raw input nobody has
+// extracted yet would look as unsourceable as a missing tier, and the
+// first consumer -- whose bids are what summon an extractor -- would
+// never spawn.
+func (s *State) prospectiveAsk(product string) (float64, bool) {
+	best, found := 0.0, false
+	for _, p := range s.producers {
+		r, ok := p.(*resources.Resource)
+		if !ok || r.Locked || r.Claimed() || r.Exhausted() || r.Production.Name != product {
+			continue
+		}
+		if price := r.AskPriceFor(product); !found || price < best {
+			best, found = price, true
+		}
+	}
+	return best, found
+}
+
 // estimatedDeliveredCost is the best current estimate of what one unit
 // of product costs to buy AND ship here.
 func (s *State) estimatedDeliveredCost(product string) float64 {
@@ -165,27 +203,19 @@ func (s *State) recipeCrowding() map[string]int {
 	return crowd
 }
 
-// spawnOffsetFromInput keeps a freshly-spawned factory from landing
-// exactly on a seller's coordinates. recipes.UnitTransportCost treats
-// any distance <= 1 as a same-location collision and charges 1e12 for
-// it (see recipes.go) -- specifically to stop Move() from doing this --
-// so spawning right on top of a seller would make that input
-// permanently unaffordable instead of cheap. The offset only needs to
-// clear that threshold, not model any real construction footprint.
-const spawnOffsetFromInput = 5
-
 // spawnLocation places a new factory near its currently sourceable
-// inputs: the centroid of the best-ask sellers' locations for every
-// input that has one right now, nudged away from exact collision (see
-// spawnOffsetFromInput). This shrinks the transport-cost gap a fresh
-// bid has to close to cross an ask, and stops freshly-spawned
-// factories from starting nowhere near what they need. It reads only
-// the live book (already-public ask locations), never the recipe tree,
-// so it doesn't compromise the "prices only" demand-cascade design --
-// a recipe with no currently sourceable input (the common case for a
-// deep, not-yet-summoned tier) falls back to a random location, exactly
-// as before.
-func (s *State) spawnLocation(r *recipes.Recipe) point.Point {
+// inputs: on the free site nearest the centroid of the best-ask sellers'
+// locations for every input that has one right now. This shrinks the
+// transport-cost gap a fresh bid has to close to cross an ask, and stops
+// freshly-spawned factories from starting nowhere near what they need.
+// It reads only the live book (already-public ask locations), never the
+// recipe tree, so it doesn't compromise the "prices only" demand-cascade
+// design -- a recipe with no currently sourceable input (the common case
+// for a deep, not-yet-summoned tier) falls back to a random location,
+// exactly as before. Either way the site must be free land (see
+// landuse.go), buildable on terrain; it fails when none is near.
+func (s *State) spawnLocation(r *recipes.Recipe) (point.Point, bool) {
+	s.land = s.landMap()
 	sumX, sumY, n := 0, 0, 0
 	for _, input := range r.Inputs() {
 		ask, ok := s.book.BestAsk(input.Name)
@@ -197,13 +227,13 @@ func (s *State) spawnLocation(r *recipes.Recipe) point.Point {
 		sumY += loc.Y
 		n++
 	}
+	var want point.Point
 	if n == 0 {
-		return s.randomLocation()
-	}
-	return point.Point{
-		X: sumX/n + spawnOffsetFromInput,
-		Y: sumY/n + spawnOffsetFromInput,
+		want = s.randomLocation()
+	} else {
+		want = point.Point{X: sumX / n, Y: sumY / n}
 	}
+	return s.siteNear(want, r.ProducedIn.Footprint(), nil)
 }
 
 func (s *State) randomLocation() point.Point {
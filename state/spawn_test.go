@@ -11,18 +11,21 @@ import (
 	"github.com/paul-freeman/satisfactory-story/production"
 	"github.com/paul-freeman/satisfactory-story/recipes"
 	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/state/metrics"
 )
 
 func newTestStateWithProducers(rs recipes.Recipes, producers []production.Producer) *State {
 	return &State{
-		recipes:   rs,
-		producers: producers,
-		book:      market.NewBook(),
-		lastTrade: make(map[string]float64),
-		ledger:    &tradeLedger{},
-		randSrc:   rand.New(rand.NewSource(1)),
-		xmin:      0, xmax: 1000, ymin: 0, ymax: 1000,
-		treasury:  initialTreasuryFund,
+		config:     DefaultConfig(),
+		recipes:    rs,
+		producers:  producers,
+		book:       market.NewBook(),
+		lastTrade:  make(map[string]float64),
+		ledger:     &tradeLedger{},
+		indicators: metrics.NewRecorder(indicatorHistoryTicks),
+		randSrc:    rand.New(rand.NewSource(1)),
+		xmin:       0, xmax: 1000, ymin: 0, ymax: 1000,
+		treasury: initialTreasuryFund,
 	}
 }
 
@@ -204,15 +207,15 @@ func Test_spawnNewProducer_spawns_near_a_sourceable_input(t *testing.T) {
 	if f == nil {
 		t.Fatal("expected a factory to spawn")
 	}
-	// Near, not AT -- recipes.UnitTransportCost treats distance <= 1 as a
-	// same-location collision and charges 1e12 to avoid it (see
-	// recipes.go), so a factory that spawns exactly on its seller's
-	// coordinates would make that seller permanently unaffordable.
-	if got := f.Loc.Distance(ore.Loc); got <= 1 {
-		t.Errorf("expected the factory to spawn near, not on, its seller %v -- got %v (distance %f, must exceed the UnitTransportCost collision threshold)", ore.Loc, f.Loc, got)
+	// Near, not ON -- the node's tile is reserved for its extractor (see
+	// landuse.go), so the factory takes the nearest free plot beside it.
+	orePlot, _ := plotFor(ore)
+	plot, _ := plotFor(f)
+	if plot.Overlaps(orePlot) {
+		t.Errorf("expected the factory to spawn beside, not on, its seller's tile %v -- got %v", ore.Loc, f.Loc)
 	}
-	if got := f.Loc.Distance(ore.Loc); got > 20 {
-		t.Errorf("expected the factory to spawn close to its only sourceable input %v, got %v (distance %f)", ore.Loc, f.Loc, got)
+	if got, limit := f.Loc.Distance(ore.Loc), 2*float64(plot.Half+orePlot.Half); got > limit {
+		t.Errorf("expected the factory to spawn close to its only sourceable input %v, got %v (distance %f, want at most %f)", ore.Loc, f.Loc, got, limit)
 	}
 }
 
@@ -252,19 +255,19 @@ func Test_spawnNewProducer_spawns_at_centroid_of_multiple_sourceable_inputs(t *t
 	if f == nil {
 		t.Fatal("expected a factory to spawn")
 	}
-	centroid := point.Point{X: 500, Y: 500} // midpoint of (400,400) and (600,600)
-	if got := f.Loc.Distance(centroid); got > 20 {
+	// The midpoint of (400,400) and (600,600) lies on both nodes' tiles,
+	// so the factory takes the nearest free plot in the ring around it.
+	centroid := point.Point{X: 500, Y: 500}
+	plot, _ := plotFor(f)
+	if got := f.Loc.Distance(centroid); got > 2*float64(plot.Half)*math.Sqrt2+1 {
 		t.Errorf("expected the factory to spawn near the centroid %v of its sourceable inputs, got %v (distance %f)", centroid, f.Loc, got)
 	}
 }
 
 func Test_spawnNewProducer_never_collides_with_a_sourceable_input(t *testing.T) {
-	// Regression test: recipes.UnitTransportCost charges an astronomical
-	// 1e12 for any contract between two points <= 1 apart (see
-	// recipes.go), specifically to stop factories from sitting exactly
-	// on top of another producer. spawnLocation must always clear that
-	// threshold, or the input it just spawned next to becomes
-	// permanently unaffordable -- exactly the bug this guards against.
+	// Regression test: a factory must never spawn on top of another
+	// producer. Land use reserves the node's tile for its extractor, so
+	// spawnLocation has to find free land beside it.
 	ore := &resources.Resource{
 		Production: production.Production{Name: "Ore", Rate: 100},
 		Loc:        point.Point{X: 400, Y: 400},
@@ -284,8 +287,9 @@ func Test_spawnNewProducer_never_collides_with_a_sourceable_input(t *testing.T)
 	s.spawnNewProducer(testLogger())
 
 	f := s.producers[len(s.producers)-1].(*factory.Factory)
-	if got := f.Loc.Distance(ore.Loc); got <= 1 {
-		t.Fatalf("factory spawned at distance %f from its seller -- recipes.UnitTransportCost would charge 1e12", got)
+	orePlot, _ := plotFor(ore)
+	if plot, _ := plotFor(f); plot.Overlaps(orePlot) {
+		t.Fatalf("factory spawned at %v, on its seller's tile at %v", f.Loc, ore.Loc)
 	}
 }
 
@@ -11,13 +11,20 @@ import (
 	"sync"
 
 	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/geo"
+	"github.com/paul-freeman/satisfactory-story/landuse"
+	"github.com/paul-freeman/satisfactory-story/logistics"
 	"github.com/paul-freeman/satisfactory-story/market"
+	"github.com/paul-freeman/satisfactory-story/marketmaker"
+	"github.com/paul-freeman/satisfactory-story/point"
 	"github.com/paul-freeman/satisfactory-story/production"
 	"github.com/paul-freeman/satisfactory-story/recipes"
 	"github.com/paul-freeman/satisfactory-story/resources"
 	storyresources "github.com/paul-freeman/satisfactory-story/resources"
 	"github.com/paul-freeman/satisfactory-story/sink"
 	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+	"github.com/paul-freeman/satisfactory-story/state/metrics"
+	"github.com/paul-freeman/satisfactory-story/terrain"
 )
 
 const (
@@ -38,8 +45,8 @@ type State struct {
 
 	producers []production.Producer
 	recipes   recipes.Recipes
-	// book is the per-tick order book: rebuilt from live producer state
-	// by publishOrders, crossed by matchOrders. Persisted on State so
+	// book is the order book: republished from live producer state by
+	// publishOrders each tick, crossed by matchOrders. Persisted on State so
 	// later phases of the same tick (spawning, price adjustment, the
 	// wire format) can read post-matching residuals.
 	book *market.Book
@@ -52,10 +59,52 @@ type State struct {
 	// replenished by upkeep-as-rent. Never negative. See the Phase 6 spec
 	// (docs/superpowers/specs/2026-07-22-treasury-seed-capital-design.md).
 	treasury float64
-
-	seed   int64
-	tick   int
-	cancel context.CancelFunc
+	// money audits the money supply tick by tick (see money.go).
+	money moneyLedger
+	// indicators keeps the macroeconomic time series; tickLifespans
+	// collects the ages of factories removed during the current tick.
+	indicators    *metrics.Recorder
+	tickLifespans []int
+	// contracts holds the standing supply agreements (see contracts.go).
+	contracts contractBook
+	// network carries goods when Config.TransportModes is set; nil means
+	// the flat freight formula (see logistics.go).
+	network *logistics.Network
+	// lines are the rail lines built under Config.Infrastructure, oldest
+	// first; each is also among the producers (see infrastructure.go).
+	lines []*logistics.Line
+	// hubs is the hub graph under Config.HubRouting; nil otherwise (see
+	// hubs.go).
+	hubs *logistics.HubGraph
+	// terrain is the cost raster under Config.TerrainFile; nil means open
+	// ground everywhere (see terrain.go).
+	terrain *terrain.Grid
+	// regions tags locations with their map region (see regions.go).
+	regions *geo.Regions
+	// bases are where the player's bases stand, each with its goal
+	// sinks (see world.go).
+	bases []point.Point
+	// transit holds the shipments on the road, in dispatch order, when
+	// Config.Transit is on (see transit.go).
+	transit []*shipment
+	// land is the land the producers hold, as of the last phase that
+	// placed buildings (see landuse.go).
+	land *landuse.Map
+	// relocations are the most recent factory moves, oldest first (see
+	// relocation.go).
+	relocations []relocationEvent
+
+	// clock and counters feed the /metrics exporter and /profile.
+	clock    phaseClock
+	counters engineCounters
+
+	config Config
+	// scenario is the starting economy the state was built from, nil for
+	// New's (see scenario.go).
+	scenario *Scenario
+	seed     int64
+	tick     int
+	cancel   context.CancelFunc
 
 	randSrc *rand.Rand
 
@@ -68,7 +117,13 @@ type State struct {
 }
 
 func New(l *slog.Logger, logLevel *slog.Level, seed int64) (*State, error) {
-	s := new(State)
+	return NewWithConfig(l, logLevel, seed, DefaultConfig())
+}
+
+// NewWithConfig creates a State running one of the alternate economies
+// described by config.
+func NewWithConfig(l *slog.Logger, logLevel *slog.Level, seed int64, config Config) (*State, error) {
+	s := &State{config: config}
 	err := s.getInitialState(l, logLevel, seed)
 	return s, err
 }
@@ -84,50 +139,90 @@ func (s *State) getInitialState(l *slog.Logger, logLevel *slog.Level, seed int64
 		return fmt.Errorf("failed to create recipes: %w", err)
 	}
 
-	// Find resource location bounds
-	xmin := resources[0].Location().X
-	xmax := resources[0].Location().X
-	ymin := resources[0].Location().Y
-	ymax := resources[0].Location().Y
-	for _, resource := range resources {
-		loc := resource.Location()
-		if loc.X < xmin {
-			xmin = loc.X
-		}
-		if loc.X > xmax {
-			xmax = loc.X
-		}
-		if loc.Y < ymin {
-			ymin = loc.Y
-		}
-		if loc.Y > ymax {
-			ymax = loc.Y
-		}
+	if err := s.scenarioRecipes(recipes); err != nil {
+		return err
 	}
 
-	borderPaddingX := float64(xmax-xmin) * borderPaddingPct
-	borderPaddingY := float64(ymax-ymin) * borderPaddingPct
-	paddedXmin := int(float64(xmin) - borderPaddingX)
-	paddedXmax := int(float64(xmax) + borderPaddingX)
-	paddedYmin := int(float64(ymin) - borderPaddingY)
-	paddedYmax := int(float64(ymax) + borderPaddingY)
+	// Admit the scenario's nodes, then lay the world and the player's
+	// bases out around them
+	if s.regions, err = s.newRegions(); err != nil {
+		return fmt.Errorf("failed to load regions: %w", err)
+	}
+	if resources, err = s.scenarioNodes(resources); err != nil {
+		return err
+	}
+	bounds, err := s.worldBounds(resources)
+	if err != nil {
+		return err
+	}
+	if s.bases, err = s.baseLocations(bounds); err != nil {
+		return err
+	}
 
 	// Create producers
 	producers := make([]production.Producer, 0)
 	for _, resource := range resources {
+		s.config.Depletion.Apply(resource)
 		producers = append(producers, resource)
 	}
-	for _, sk := range newSinks(recipes, paddedXmin, paddedXmax, paddedYmin, paddedYmax) {
+	sinks, err := s.scenarioSinks(recipes, resources, bounds)
+	if err != nil {
+		return err
+	}
+	for _, sk := range sinks {
 		producers = append(producers, sk)
 	}
 
+	matcher, err := market.NewMatcher(s.config.Matcher, s.config.MatcherK)
+	if err != nil {
+		return fmt.Errorf("failed to create matcher: %w", err)
+	}
+
 	// Populate state
 	s.producers = producers
 	s.recipes = recipes
 	s.book = market.NewBook()
+	s.book.SetMatcher(matcher)
 	s.lastTrade = make(map[string]float64)
 	s.ledger = &tradeLedger{}
 	s.treasury = initialTreasuryFund
+	s.money = moneyLedger{}
+	s.indicators = metrics.NewRecorder(indicatorHistoryTicks)
+	s.tickLifespans = nil
+	s.clock = phaseClock{}
+	s.counters = engineCounters{}
+	s.contracts = contractBook{}
+	s.network = nil
+	s.lines = nil
+	s.hubs = nil
+	s.terrain = nil
+	s.transit = nil
+	s.relocations = nil
+	s.land = s.landMap()
+	if s.scenario != nil && s.scenario.Treasury != nil {
+		s.treasury = *s.scenario.Treasury
+	}
+	if err := s.lockOutsideStartRegions(resources); err != nil {
+		return err
+	}
+	if len(s.config.TransportModes) > 0 {
+		s.network = logistics.NewNetwork(s.config.TransportModes...)
+		s.network.CanBuild = s.canBuildLink
+	}
+	if s.config.TerrainFile != "" {
+		if s.terrain, err = terrain.Load(s.config.TerrainFile, bounds); err != nil {
+			return fmt.Errorf("failed to load terrain: %w", err)
+		}
+	}
+	if s.config.HubRouting {
+		nodes := make([]point.Point, 0, len(resources))
+		for _, resource := range resources {
+			nodes = append(nodes, resource.Location())
+		}
+		if s.hubs, err = s.newHubGraph(nodes); err != nil {
+			return fmt.Errorf("failed to create hub graph: %w", err)
+		}
+	}
 
 	s.seed = seed
 	s.tick = 0
@@ -135,13 +230,24 @@ func (s *State) getInitialState(l *slog.Logger, logLevel *slog.Level, seed int64
 
 	s.randSrc = rand.New(rand.NewSource(seed))
 
-	s.xmin = paddedXmin
-	s.xmax = paddedXmax
-	s.ymin = paddedYmin
-	s.ymax = paddedYmax
+	s.xmin = bounds.Xmin
+	s.xmax = bounds.Xmax
+	s.ymin = bounds.Ymin
+	s.ymax = bounds.Ymax
 
 	s.logLevel = logLevel
 
+	if err := s.placeScenario(bounds); err != nil {
+		return err
+	}
+	mm, err := s.newMarketMaker(s.bases[0])
+	if err != nil {
+		return err
+	}
+	if mm != nil {
+		s.producers = append(s.producers, mm)
+	}
+
 	return nil
 }
 
@@ -152,26 +258,59 @@ func (s *State) Tick(parentLogger *slog.Logger) error {
 
 	s.tick++
 	l := parentLogger.With(slog.Int("tick", s.tick))
+	s.money.open(s.moneySupply())
+	s.clock.start()
 
-	// Physical production first, then discovery: the book is rebuilt
+	// Arrivals and physical production first, then discovery: the book is rebuilt
 	// from live stock and crossed, so every later mechanism this tick
 	// (moving, spawning, solvency, price adjustment) sees post-trade
 	// reality.
+	s.deliverShipments(l)
+	s.clock.lap(phaseDeliverShipments)
 	s.produceGoods(l)
+	s.clock.lap(phaseProduceGoods)
+	if s.network != nil {
+		s.network.BeginTick(s.tick)
+	}
+	s.settleContracts(l)
+	s.clock.lap(phaseContracts)
 	s.publishOrders(l)
+	s.clock.lap(phasePublishOrders)
 	s.matchOrders(l)
+	s.formContracts(l)
+	s.clock.lap(phaseMatchOrders)
 	s.moveProducers(l)
+	s.clock.lap(phaseMoveProducers)
+	if s.config.RegionUnlockTicks > 0 && s.tick%s.config.RegionUnlockTicks == 0 {
+		s.unlockRegion(l)
+	}
 	if s.randSrc.Float64() < spawnProbabilityPerTick {
 		s.spawnNewProducer(l)
 	}
+	if s.randSrc.Float64() < extractorSpawnProbabilityPerTick {
+		s.spawnExtractor(l)
+	}
+	if s.config.Infrastructure && s.network == nil && s.randSrc.Float64() < lineSpawnProbabilityPerTick {
+		s.spawnLine(l)
+	}
+	s.clock.lap(phaseSpawn)
 	s.applySolvency(l)
+	s.clock.lap(phaseApplySolvency)
 	s.adjustPrices(l)
+	s.clock.lap(phaseAdjustPrices)
 	s.ledger.prune(s.tick, tradeMemoryTicks)
 	for _, p := range s.producers {
 		if f, ok := p.(*factory.Factory); ok {
 			f.PruneTrades(s.tick, tradeMemoryTicks)
 		}
 	}
+	s.money.close(s.tick, s.moneySupply())
+	if !s.money.balanced() {
+		l.Error("money supply out of balance",
+			slog.Float64("discrepancy", s.money.last.discrepancy))
+	}
+	s.recordIndicators()
+	s.clock.stop()
 
 	return nil
 }
@@ -216,6 +355,13 @@ func (s *State) Reset(l *slog.Logger, logLevel *slog.Level) {
 }
 
 func (s *State) Recipes(_ *slog.Logger) []statehttp.Recipe {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.recipesForWire()
+}
+
+// recipesForWire lists the recipes for the wire. The caller holds s.m.
+func (s *State) recipesForWire() []statehttp.Recipe {
 	recipes := make([]statehttp.Recipe, 0, len(s.recipes))
 	for _, recipe := range s.recipes {
 		recipes = append(recipes, statehttp.Recipe{
@@ -240,7 +386,11 @@ func (s *State) Recipes(_ *slog.Logger) []statehttp.Recipe {
 	return recipes
 }
 
-func (s *State) SetRecipe(l *slog.Logger, recipeID string, enabled bool) []statehttp.Recipe {
+func (s *State) SetRecipe(_ *slog.Logger, recipeID string, enabled bool) []statehttp.Recipe {
+	// Lock state: the HTTP handler calls this while Run ticks
+	s.m.Lock()
+	defer s.m.Unlock()
+
 	// Find recipe
 	for _, r := range s.recipes {
 		if r.ID() == recipeID {
@@ -249,10 +399,14 @@ func (s *State) SetRecipe(l *slog.Logger, recipeID string, enabled bool) []state
 	}
 
 	if !enabled {
-		// Remove all producers using this recipe.
+		// Remove all producers using this recipe. They die like culled
+		// factories, so the indicators count them in the next sample.
 		kept := make([]production.Producer, 0, len(s.producers))
 		for _, p := range s.producers {
 			if f, ok := p.(*factory.Factory); ok && f.RecipeClass == recipeID {
+				s.money.record(channelRemoved, -f.Cash())
+				s.tickLifespans = append(s.tickLifespans, s.tick-f.CreatedTick)
+				s.withdrawOrders(f)
 				continue
 			}
 			kept = append(kept, p)
@@ -260,7 +414,7 @@ func (s *State) SetRecipe(l *slog.Logger, recipeID string, enabled bool) []state
 		s.producers = kept
 	}
 
-	return s.Recipes(l)
+	return s.recipesForWire()
 }
 
 func (s *State) setCancellationFunc(cancel context.CancelFunc, logger *slog.Logger) {
@@ -273,17 +427,26 @@ func (s *State) setCancellationFunc(cancel context.CancelFunc, logger *slog.Logg
 	s.cancel = cancel
 }
 
+// moveProducers lets every moveable producer move, concurrently, onto
+// land free at the start of the phase, then settles the moves in
+// producer order (see recordRelocations).
 func (s *State) moveProducers(l *slog.Logger) {
-	for _, producer := range s.producers {
-		switch producer := producer.(type) {
+	s.land = s.landMap()
+	errs := make([]error, len(s.producers))
+	parallelFor(len(s.producers), s.config.Workers, func(i int) {
+		switch producer := s.producers[i].(type) {
 		case production.MoveableProducer:
-			if err := producer.Move(); err != nil {
-				l.Error("failed to move producer: " + err.Error())
-			}
+			errs[i] = producer.Move()
 		default:
 			// Do nothing
 		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			l.Error("failed to move producer: " + err.Error())
+		}
 	}
+	s.recordRelocations(l)
 }
 
 func (s *State) ListFactories(l *slog.Logger) {
@@ -353,21 +516,30 @@ func (s *State) toHTTP() statehttp.State {
 	resources := make([]statehttp.Resource, 0)
 	factories := make([]statehttp.Factory, 0)
 	sinks := make([]statehttp.Sink, 0)
+	marketMakers := make([]statehttp.MarketMaker, 0)
+	lines := make([]statehttp.Line, 0)
 
 	recentSellers := s.ledger.recentSellers()
 
 	for _, p := range s.producers {
 		switch producer := p.(type) {
 		case *storyresources.Resource:
+			extractor, owner, cash := "", "", 0.0
+			if producer.Extractor != nil {
+				extractor = string(producer.Extractor.Kind)
+				owner = string(producer.Extractor.Owner)
+				cash = producer.Extractor.Wallet.Cash()
+			}
 			resources = append(resources, statehttp.Resource{
-				Location: statehttp.Location{
-					X: producer.Location().X,
-					Y: producer.Location().Y,
-				},
+				Location:      s.location(producer.Location()),
 				Recipe:        producer.Production.Name,
 				Product:       producer.Production.Name,
 				Profitability: 0,
 				Active:        recentSellers[p],
+				Locked:        producer.Locked,
+				Extractor:     extractor,
+				Owner:         owner,
+				Cash:          cash,
 			})
 		case *factory.Factory:
 			products := make([]string, 0)
@@ -383,10 +555,7 @@ func (s *State) toHTTP() statehttp.State {
 				label += " (idle)"
 			}
 			factories = append(factories, statehttp.Factory{
-				Location: statehttp.Location{
-					X: producer.Location().X,
-					Y: producer.Location().Y,
-				},
+				Location:      s.location(producer.Location()),
 				Recipe:        label,
 				Products:      products,
 				Profitability: profitability,
@@ -394,11 +563,33 @@ func (s *State) toHTTP() statehttp.State {
 			})
 		case *sink.Sink:
 			sinks = append(sinks, statehttp.Sink{
-				Location: statehttp.Location{
-					X: producer.Location().X,
-					Y: producer.Location().Y,
-				},
-				Label: fmt.Sprintf("%s Sink (%.1f delivered)", producer.Name, producer.TotalDelivered()),
+				Location: s.location(producer.Location()),
+				Label:    fmt.Sprintf("%s Sink (%.1f delivered)", producer.Name, producer.TotalDelivered()),
+			})
+		case *marketmaker.MarketMaker:
+			quotes := make([]statehttp.DealerQuote, 0, len(producer.Stocked))
+			for _, stocked := range producer.Stocked {
+				quotes = append(quotes, statehttp.DealerQuote{
+					Product:   stocked.Name,
+					Bid:       producer.BidPrice(stocked.Name),
+					Ask:       producer.AskPrice(stocked.Name),
+					Inventory: producer.Inventory.Get(stocked.Name),
+				})
+			}
+			marketMakers = append(marketMakers, statehttp.MarketMaker{
+				Location: s.location(producer.Location()),
+				Label:    producer.String(),
+				Cash:     producer.Cash(),
+				Quotes:   quotes,
+			})
+		case *logistics.Line:
+			lines = append(lines, statehttp.Line{
+				A:           s.location(producer.A),
+				B:           s.location(producer.B),
+				BuiltTick:   producer.BuiltTick,
+				BuildCost:   producer.BuildCost,
+				Carried:     producer.Carried,
+				TollRevenue: producer.TollRevenue,
 			})
 		}
 	}
@@ -415,15 +606,9 @@ func (s *State) toHTTP() statehttp.State {
 	transports := make([]statehttp.Transport, 0)
 	for _, edge := range s.ledger.edges() {
 		transports = append(transports, statehttp.Transport{
-			Origin: statehttp.Location{
-				X: edge.seller.Location().X,
-				Y: edge.seller.Location().Y,
-			},
-			Destination: statehttp.Location{
-				X: edge.buyer.Location().X,
-				Y: edge.buyer.Location().Y,
-			},
-			Rate: edge.qty / float64(window),
+			Origin:      s.location(edge.seller.Location()),
+			Destination: s.location(edge.buyer.Location()),
+			Rate:        edge.qty / float64(window),
 		})
 	}
 
@@ -434,14 +619,21 @@ func (s *State) toHTTP() statehttp.State {
 		Ymax: s.ymax,
 	}
 
+	hubs, hubEdges := s.hubsForWire()
+
 	return statehttp.State{
-		Resources:  resources,
-		Factories:  factories,
-		Transports: transports,
-		Sinks:      sinks,
-		Shortages:  s.shortagesForWire(),
-		Tick:       s.tick,
-		Running:    s.cancel != nil,
-		Bounds:     bounds,
+		Resources:    resources,
+		Factories:    factories,
+		Transports:   transports,
+		Shipments:    s.shipmentsForWire(),
+		Lines:        lines,
+		Hubs:         hubs,
+		HubEdges:     hubEdges,
+		Sinks:        sinks,
+		MarketMakers: marketMakers,
+		Shortages:    s.shortagesForWire(),
+		Tick:         s.tick,
+		Running:      s.cancel != nil,
+		Bounds:       bounds,
 	}
 }
@@ -41,10 +41,22 @@ func Test_state_Tick(t *testing.T) {
 		assert.NotEqual(t, 0, testState.xmax, "xmax should not be 0")
 		assert.NotEqual(t, 0, testState.ymin, "ymin should not be 0")
 		assert.NotEqual(t, 0, testState.ymax, "ymax should not be 0")
+		// SAM ore is extractable but no recipe in this data set consumes
+		// it yet: its nodes are idle supply, checked below to stay
+		// unclaimed rather than skipped.
+		consumed := func(name string) bool {
+			for _, recipe := range testState.recipes {
+				if recipe.Inputs().Contains(name) {
+					return true
+				}
+			}
+			return false
+		}
+		idle := false
 		for _, producer := range testState.producers {
 			for _, product := range producer.Products() {
-				// TODO: What do these products do?
-				if product.Name == "sam" || product.Name == "geyser" {
+				if product.Name == "SAM" && !consumed(product.Name) {
+					idle = true
 					continue
 				}
 				// Sink-wanted products (e.g. space elevator parts) are
@@ -75,6 +87,45 @@ func Test_state_Tick(t *testing.T) {
 				}
 			}
 		}
+		if !idle {
+			return
+		}
+		for i := 0; i < 200; i++ {
+			assert.NoError(t, testState.Tick(l), "failed to tick state")
+		}
+		for _, producer := range testState.producers {
+			if r, ok := producer.(*resources.Resource); ok && r.Production.Name == "SAM" {
+				assert.False(t, r.Claimed(), "nothing consumes SAM, so no extractor should claim %s", r.PrettyPrint())
+			}
+		}
+	})
+	t.Run("every recipe input should have reachable supply", func(t *testing.T) {
+		l := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level:       slog.LevelInfo,
+			ReplaceAttr: removeTimeAndLevel,
+		}))
+		logLevel := new(slog.Level)
+		testState, err := New(l, logLevel, 11)
+		assert.NoError(t, err, "failed to create state")
+		supplied := make(map[string]bool)
+		for _, producer := range testState.producers {
+			if _, ok := producer.(*resources.Resource); ok {
+				for _, product := range producer.Products() {
+					supplied[product.Name] = true
+				}
+			}
+		}
+		for _, recipe := range testState.recipes {
+			for _, output := range recipe.Outputs() {
+				supplied[output.Name] = true
+			}
+		}
+		for _, recipe := range testState.recipes {
+			for _, input := range recipe.Inputs() {
+				assert.True(t, supplied[input.Name] || resources.PlaceableAnywhere(input.Name),
+					"%s needs %s, which no node, well, site or recipe supplies", recipe.ID(), input.Name)
+			}
+		}
 	})
 	t.Run("can run one tick", func(t *testing.T) {
 		l := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
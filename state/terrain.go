@@ -0,0 +1,26 @@
+package state
+
+import (
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+)
+
+// roadCost is the per-unit road freight from origin to destination:
+// recipes.UnitTransportCost, over the least-cost path across the terrain
+// when the world has one. Terrain only ever lengthens a haul, so
+// recipes.UnitTransportFloor still bounds it.
+func (s *State) roadCost(origin, destination point.Point) float64 {
+	if s.terrain == nil {
+		return recipes.UnitTransportCost(origin, destination)
+	}
+	return recipes.UnitTransportFloor(s.terrain.PathLength(origin, destination))
+}
+
+// passableLocation moves p off impassable terrain to the nearest cell
+// that can be built on.
+func (s *State) passableLocation(p point.Point) point.Point {
+	if s.terrain == nil {
+		return p
+	}
+	return s.terrain.NearestPassable(p)
+}
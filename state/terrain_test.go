@@ -0,0 +1,83 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/recipes"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/terrain"
+)
+
+// wallTerrain is a 5x5 grid of 20000-unit cells over 100000x100000 with
+// an impassable wall down the middle column, open only at the south end.
+func wallTerrain(t *testing.T) *terrain.Grid {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "terrain.csv")
+	if err := os.WriteFile(file, []byte("1,1,0,1,1\n1,1,0,1,1\n1,1,0,1,1\n1,1,0,1,1\n1,1,1,1,1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := terrain.Load(file, terrain.Bounds{Xmin: 0, Xmax: 100000, Ymin: 0, Ymax: 100000})
+	if err != nil {
+		t.Fatalf("terrain.Load: %v", err)
+	}
+	return g
+}
+
+func Test_unitTransport_terrain(t *testing.T) {
+	s := newTestState()
+	s.terrain = wallTerrain(t)
+
+	west, east := point.Point{X: 10000, Y: 90000}, point.Point{X: 90000, Y: 90000}
+	if got, flat := s.unitTransport(west, east), recipes.UnitTransportCost(west, east); got <= flat {
+		t.Fatalf("unitTransport across the wall = %v, want the detour dearer than the straight %v", got, flat)
+	}
+	if got, floor := s.unitTransport(west, east), s.transportFloor(west.Distance(east)); got < floor {
+		t.Fatalf("unitTransport = %v, under transportFloor %v", got, floor)
+	}
+	south := point.Point{X: 10000, Y: 10000}
+	if got, want := s.unitTransport(west, south), recipes.UnitTransportCost(west, south); got != want {
+		t.Fatalf("unitTransport along open ground = %v, want the flat %v", got, want)
+	}
+}
+
+func Test_spawnAndMove_avoidImpassable(t *testing.T) {
+	s := newTestState()
+	s.terrain = wallTerrain(t)
+
+	// The centroid of the inputs' sellers lands in the wall.
+	r := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 50000, Y: 70000},
+		Stock:      10,
+	}
+	s.producers = []production.Producer{r}
+	s.book.PostAsk(r, "OreIron", 10, 1)
+	rec := &recipes.Recipe{
+		ClassName: "Recipe_IngotIron_C", DisplayName: "Iron Ingot", Active: true,
+		InputProducts:  production.Products{{Name: "OreIron", Rate: 1}},
+		OutputProducts: production.Products{{Name: "IronIngot", Rate: 1}},
+	}
+	if loc, ok := s.spawnLocation(rec); !ok || !s.terrain.Passable(loc) {
+		t.Fatalf("spawnLocation = %v, %v, want a site on passable terrain", loc, ok)
+	}
+
+	// A factory whose best site lies in the wall stops at its edge.
+	f := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 10000, Y: 70000}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		1000)
+	s.land = s.landMap()
+	s.placeFactory(f)
+	f.RecordTrade(0, point.Point{X: 50000, Y: 70000}, 10)
+	if err := f.Move(); err != nil {
+		t.Fatal(err)
+	}
+	if f.LastRelocation == nil || !s.terrain.Passable(f.Loc) {
+		t.Fatalf("factory moved to %v (relocation %+v), want towards its partner on passable terrain", f.Loc, f.LastRelocation)
+	}
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"log/slog"
+	"math"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/logistics"
+	"github.com/paul-freeman/satisfactory-story/marketmaker"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/sink"
+	statehttp "github.com/paul-freeman/satisfactory-story/state/http"
+)
+
+// flatTransitSpeed is the distance goods cover per tick under the flat
+// freight formula; on a logistics network each mode has its own speed.
+// A cross-map haul takes ~20 ticks, well inside inputStockTargetTicks.
+const flatTransitSpeed = 10000.0
+
+// shipment is a traded lot on its way to the buyer: paid for at
+// dispatch, received on arrival.
+type shipment struct {
+	buyer       production.Producer
+	product     string
+	qty         float64
+	origin      point.Point
+	destination point.Point
+	departTick  int
+	arriveTick  int
+}
+
+// transitTicks is how many ticks goods take from origin to destination:
+// the distance over the speed they travel at, never less than one.
+func transitTicks(origin, destination point.Point, speed float64) int {
+	return max(1, int(math.Ceil(origin.Distance(destination)/speed)))
+}
+
+// transitSpeed is the speed goods shipped by mode travel at; mode is
+// empty under flat freight.
+func transitSpeed(mode logistics.Mode) float64 {
+	if mode == "" {
+		return flatTransitSpeed
+	}
+	return logistics.SpecFor(mode).Speed
+}
+
+// deliverShipments hands every shipment due by this tick to its buyer,
+// in dispatch order. Goods bound for a factory that has since left the
+// world are lost with it.
+func (s *State) deliverShipments(_ *slog.Logger) {
+	pending := s.transit[:0]
+	for _, sh := range s.transit {
+		if sh.arriveTick > s.tick {
+			pending = append(pending, sh)
+			continue
+		}
+		switch buyer := sh.buyer.(type) {
+		case *factory.Factory:
+			buyer.Receive(sh.product, sh.qty)
+		case *marketmaker.MarketMaker:
+			buyer.Receive(sh.product, sh.qty)
+		case *sink.Sink:
+			buyer.RecordDelivery(sh.product, sh.qty)
+		}
+	}
+	clear(s.transit[len(pending):])
+	s.transit = pending
+}
+
+// shipmentsForWire lists the goods in transit with how far along their
+// journey each is.
+func (s *State) shipmentsForWire() []statehttp.Shipment {
+	out := make([]statehttp.Shipment, 0, len(s.transit))
+	for _, sh := range s.transit {
+		progress := float64(s.tick-sh.departTick) / float64(sh.arriveTick-sh.departTick)
+		out = append(out, statehttp.Shipment{
+			Origin:      s.location(sh.origin),
+			Destination: s.location(sh.destination),
+			Product:     sh.product,
+			Qty:         sh.qty,
+			Progress:    progress,
+		})
+	}
+	return out
+}
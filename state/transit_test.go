@@ -0,0 +1,84 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/factory"
+	"github.com/paul-freeman/satisfactory-story/market"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/sink"
+)
+
+func Test_executeTrade_transitDelaysDelivery(t *testing.T) {
+	s := newTestState()
+	s.config.Transit = true
+	s.tick = 10
+	r := &resources.Resource{
+		Production: production.Production{Name: "OreIron", Rate: 1},
+		Loc:        point.Point{X: 0, Y: 0},
+		Stock:      10,
+	}
+	// 25000 away at 10000 per tick: three ticks on the road.
+	f := factory.New("Smelter", "Recipe_IngotIron_C", point.Point{X: 25000, Y: 0}, 0,
+		production.Products{production.Production{Name: "OreIron", Rate: 1}},
+		production.Products{production.Production{Name: "IronIngot", Rate: 1}},
+		100)
+	s.producers = []production.Producer{r, f}
+
+	qty, err := s.executeTrade(testLogger(), market.Match{
+		Seller: r, Buyer: f,
+		Order:     production.Production{Name: "OreIron", Rate: 4},
+		UnitPrice: 2.0,
+	}, nil)
+	if err != nil || qty != 4 {
+		t.Fatalf("executeTrade = %v, %v; want 4, nil", qty, err)
+	}
+	if r.Stock != 6 || f.Cash() != 92 {
+		t.Fatalf("seller stock %v, buyer cash %v; want 6 and 92 at dispatch", r.Stock, f.Cash())
+	}
+	if f.InputStock.Get("OreIron") != 0 || f.Incoming.Get("OreIron") != 4 {
+		t.Fatalf("buyer stock %v, incoming %v; want 0 and 4", f.InputStock.Get("OreIron"), f.Incoming.Get("OreIron"))
+	}
+	if got := f.Hunger("OreIron", inputStockTargetTicks); got != inputStockTargetTicks-4 {
+		t.Fatalf("Hunger = %v, want %v (incoming counts as held)", got, inputStockTargetTicks-4)
+	}
+	if wire := s.shipmentsForWire(); len(wire) != 1 || wire[0].Progress != 0 || wire[0].Qty != 4 {
+		t.Fatalf("wire shipments = %+v, want one lot of 4 just dispatched", wire)
+	}
+
+	for s.tick = 11; s.tick < 13; s.tick++ {
+		s.deliverShipments(testLogger())
+		if f.InputStock.Get("OreIron") != 0 {
+			t.Fatalf("tick %d: goods arrived early", s.tick)
+		}
+	}
+	s.deliverShipments(testLogger())
+	if f.InputStock.Get("OreIron") != 4 || f.Incoming.Get("OreIron") != 0 || len(s.transit) != 0 {
+		t.Fatalf("tick %d: stock %v, incoming %v, in transit %d; want the lot received",
+			s.tick, f.InputStock.Get("OreIron"), f.Incoming.Get("OreIron"), len(s.transit))
+	}
+}
+
+// Test_cascade_transit: the two-tier cascade must still close when
+// goods take time to arrive.
+func Test_cascade_transit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Transit = true
+	s := newCascadeState(t, cfg, "Plate")
+	// A distant goal keeps the last leg on the road for several ticks.
+	for _, p := range s.producers {
+		if goal, ok := p.(*sink.Sink); ok {
+			goal.Loc = point.Point{X: 60000, Y: 60000}
+		}
+	}
+
+	sawTransit := false
+	runCascade(t, s, 5000, func() {
+		sawTransit = sawTransit || len(s.transit) > 0
+	})
+	if !sawTransit {
+		t.Fatal("no shipment was ever in transit")
+	}
+}
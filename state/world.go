@@ -0,0 +1,120 @@
+package state
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/terrain"
+)
+
+// worldBounds is the rectangle spawns, terrain and the map are laid
+// over: Config.Bounds when set, otherwise the resource nodes' extent
+// padded by borderPaddingPct on every side.
+func (s *State) worldBounds(nodes []*resources.Resource) (terrain.Bounds, error) {
+	if b := s.config.Bounds; b != nil {
+		if b.Xmax <= b.Xmin || b.Ymax <= b.Ymin {
+			return terrain.Bounds{}, fmt.Errorf("empty world bounds %+v", *b)
+		}
+		return *b, nil
+	}
+	if len(nodes) == 0 {
+		return terrain.Bounds{}, fmt.Errorf("no resource nodes to bound the world")
+	}
+
+	xmin, xmax := nodes[0].Location().X, nodes[0].Location().X
+	ymin, ymax := nodes[0].Location().Y, nodes[0].Location().Y
+	for _, node := range nodes {
+		loc := node.Location()
+		xmin, xmax = min(xmin, loc.X), max(xmax, loc.X)
+		ymin, ymax = min(ymin, loc.Y), max(ymax, loc.Y)
+	}
+	borderPaddingX := float64(xmax-xmin) * borderPaddingPct
+	borderPaddingY := float64(ymax-ymin) * borderPaddingPct
+	return terrain.Bounds{
+		Xmin: int(float64(xmin) - borderPaddingX),
+		Xmax: int(float64(xmax) + borderPaddingX),
+		Ymin: int(float64(ymin) - borderPaddingY),
+		Ymax: int(float64(ymax) + borderPaddingY),
+	}, nil
+}
+
+// baseLocations is where the player's bases stand: Config.Bases when
+// set, otherwise one base at the centre of the world bounds. It fails on
+// a configured base outside the bounds.
+func (s *State) baseLocations(b terrain.Bounds) ([]point.Point, error) {
+	if len(s.config.Bases) == 0 {
+		return []point.Point{{X: (b.Xmin + b.Xmax) / 2, Y: (b.Ymin + b.Ymax) / 2}}, nil
+	}
+	for _, base := range s.config.Bases {
+		if !b.Contains(base) {
+			return nil, fmt.Errorf("base %v lies outside the world bounds %+v", base, b)
+		}
+	}
+	return slices.Clone(s.config.Bases), nil
+}
+
+// lockOutsideStartRegions locks every resource node outside
+// Config.StartRegions, so a playthrough begins with only its start
+// regions in reach. It fails on a region the map does not know.
+func (s *State) lockOutsideStartRegions(nodes []*resources.Resource) error {
+	if len(s.config.StartRegions) == 0 {
+		return nil
+	}
+	known := s.regions.Names()
+	for _, name := range s.config.StartRegions {
+		if !slices.Contains(known, name) {
+			return fmt.Errorf("unknown start region %q", name)
+		}
+	}
+	for _, node := range nodes {
+		node.Locked = !slices.Contains(s.config.StartRegions, s.region(node.Location()))
+	}
+	return nil
+}
+
+// unlockRegion opens the locked region nearest any base -- measured to
+// its closest locked node, ties to the region met first in producer
+// order -- as the playthrough expands outwards. Nodes outside every
+// region count as a region of their own, opened in turn like the rest.
+func (s *State) unlockRegion(l *slog.Logger) {
+	nearest := make(map[string]float64)
+	order := make([]string, 0)
+	for _, p := range s.producers {
+		r, ok := p.(*resources.Resource)
+		if !ok || !r.Locked {
+			continue
+		}
+		name := s.region(r.Location())
+		if _, seen := nearest[name]; !seen {
+			nearest[name] = math.Inf(1)
+			order = append(order, name)
+		}
+		for _, base := range s.bases {
+			nearest[name] = math.Min(nearest[name], base.Distance(r.Location()))
+		}
+	}
+	if len(order) == 0 {
+		return
+	}
+
+	chosen := order[0]
+	for _, name := range order[1:] {
+		if nearest[name] < nearest[chosen] {
+			chosen = name
+		}
+	}
+	unlocked := 0
+	for _, p := range s.producers {
+		if r, ok := p.(*resources.Resource); ok && r.Locked && s.region(r.Location()) == chosen {
+			r.Locked = false
+			unlocked++
+		}
+	}
+	l.Info("unlocked region",
+		slog.String("region", chosen),
+		slog.Int("nodes", unlocked))
+}
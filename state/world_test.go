@@ -0,0 +1,95 @@
+package state
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/geo"
+	"github.com/paul-freeman/satisfactory-story/point"
+	"github.com/paul-freeman/satisfactory-story/production"
+	"github.com/paul-freeman/satisfactory-story/resources"
+	"github.com/paul-freeman/satisfactory-story/terrain"
+)
+
+func Test_worldBounds(t *testing.T) {
+	s := newTestState()
+	nodes := []*resources.Resource{
+		{Loc: point.Point{X: 0, Y: 0}},
+		{Loc: point.Point{X: 1000, Y: 500}},
+	}
+	got, err := s.worldBounds(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (terrain.Bounds{Xmin: -100, Xmax: 1100, Ymin: -50, Ymax: 550}); got != want {
+		t.Fatalf("padded bounds = %+v, want %+v", got, want)
+	}
+	if bases, err := s.baseLocations(got); err != nil || len(bases) != 1 || bases[0] != (point.Point{X: 500, Y: 250}) {
+		t.Fatalf("default bases = %v, %v, want the centre", bases, err)
+	}
+
+	s.config.Bounds = &terrain.Bounds{Xmin: -5000, Xmax: 5000, Ymin: -2000, Ymax: 2000}
+	s.config.Bases = []point.Point{{X: 100, Y: 100}, {X: -100, Y: -100}}
+	if got, err := s.worldBounds(nodes); err != nil || got != *s.config.Bounds {
+		t.Fatalf("custom bounds = %+v, %v, want %+v", got, err, *s.config.Bounds)
+	}
+	if bases, err := s.baseLocations(*s.config.Bounds); err != nil || len(bases) != 2 || bases[1] != (point.Point{X: -100, Y: -100}) {
+		t.Fatalf("configured bases = %v, %v, want %v", bases, err, s.config.Bases)
+	}
+	s.config.Bases = append(s.config.Bases, point.Point{X: 6000, Y: 0})
+	if _, err := s.baseLocations(*s.config.Bounds); err == nil {
+		t.Fatal("a base outside the world bounds should be rejected")
+	}
+
+	s.config.Bounds = &terrain.Bounds{Xmin: 10, Xmax: 10, Ymin: 0, Ymax: 5}
+	if _, err := s.worldBounds(nodes); err == nil {
+		t.Fatal("empty custom bounds should be rejected")
+	}
+}
+
+func Test_startRegions_lockAndUnlock(t *testing.T) {
+	s := newTestState()
+	regions, err := geo.LoadRegions(strings.NewReader(`[
+		{"name": "West", "polygon": [[-400000, -400000], [0, -400000], [0, 400000], [-400000, 400000]]},
+		{"name": "Middle", "polygon": [[0, -400000], [200000, -400000], [200000, 400000], [0, 400000]]},
+		{"name": "East", "polygon": [[200000, -400000], [500000, -400000], [500000, 400000], [200000, 400000]]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.regions = regions
+	node := func(x float64) *resources.Resource {
+		return &resources.Resource{
+			Production: production.Production{Name: "OreIron", Rate: 1},
+			Loc:        geo.WorldToPoint(geo.World{X: x, Y: 0}),
+		}
+	}
+	west, middle, east := node(-200000), node(100000), node(300000)
+	nodes := []*resources.Resource{east, middle, west}
+	s.producers = []production.Producer{east, middle, west}
+	s.bases = []point.Point{west.Location()}
+
+	s.config.StartRegions = []string{"Nowhere"}
+	if err := s.lockOutsideStartRegions(nodes); err == nil {
+		t.Fatal("an unknown start region should be rejected")
+	}
+	s.config.StartRegions = []string{"West"}
+	if err := s.lockOutsideStartRegions(nodes); err != nil {
+		t.Fatal(err)
+	}
+	if west.Locked || !middle.Locked || !east.Locked {
+		t.Fatalf("locked west/middle/east = %v/%v/%v, want only the start region open", west.Locked, middle.Locked, east.Locked)
+	}
+	if _, ok := s.prospectiveAsk("OreIron"); !ok {
+		t.Fatal("the open node should still quote")
+	}
+
+	s.unlockRegion(testLogger())
+	if middle.Locked || !east.Locked {
+		t.Fatalf("after one unlock middle/east locked = %v/%v, want the region nearest the base open", middle.Locked, east.Locked)
+	}
+	s.unlockRegion(testLogger())
+	if east.Locked {
+		t.Fatal("after two unlocks every region should be open")
+	}
+}
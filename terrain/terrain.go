@@ -0,0 +1,342 @@
+// Package terrain lays a cost raster over the world: each cell of the
+// grid scales what it costs to haul goods across it, and some cells --
+// cliffs, ocean -- cannot be crossed or built on at all. Haulage follows
+// the least-cost path between two points instead of the straight line.
+package terrain
+
+import (
+	"container/heap"
+	"container/list"
+	"encoding/csv"
+	"fmt"
+	"image"
+	_ "image/png" // register the PNG decoder for Read
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+// Impassable is the cost of a cell nothing can cross.
+const Impassable = 0.0
+
+// Bounds is the world rectangle a grid is stretched over.
+type Bounds struct {
+	Xmin int `json:"xmin"`
+	Xmax int `json:"xmax"`
+	Ymin int `json:"ymin"`
+	Ymax int `json:"ymax"`
+}
+
+// Contains reports whether p lies within the bounds, edges included.
+func (b Bounds) Contains(p point.Point) bool {
+	return p.X >= b.Xmin && p.X <= b.Xmax && p.Y >= b.Ymin && p.Y <= b.Ymax
+}
+
+// Grid is a terrain cost raster. Row 0 is the north edge (Ymax), as the
+// map is drawn; column 0 the west edge (Xmin). A cell's cost is a
+// multiplier on distance, at least 1 for open ground, or Impassable.
+type Grid struct {
+	bounds     Bounds
+	cols, rows int
+	cost       []float64
+
+	// paths caches, per source cell, the least path cost to every cell,
+	// for the cacheSources sources used most recently (front of recent
+	// first); guarded by m since parallel matching prices trades
+	// concurrently.
+	m            sync.Mutex
+	paths        map[int]*list.Element
+	recent       *list.List
+	cacheSources int
+}
+
+// pathCacheFloats budgets the path cache: each source cell kept costs a
+// float per cell, and a grid keeps as many sources as fit in this many
+// floats (128 MiB) -- every cell, on grids of up to 4096 cells -- but
+// never fewer than pathCacheMinSources. The least recently used source
+// is evicted.
+const pathCacheFloats = 1 << 24
+
+// pathCacheMinSources is the fewest source cells a grid's path cache
+// keeps, however large the grid.
+const pathCacheMinSources = 256
+
+// cachedPaths is one source cell's entry in the path cache.
+type cachedPaths struct {
+	src  int
+	dist []float64
+}
+
+// New returns a grid over bounds from rows of cell costs, north first.
+// Every row must be the same length, and every cost at least 1 or
+// Impassable.
+func New(cost [][]float64, bounds Bounds) (*Grid, error) {
+	if len(cost) == 0 || len(cost[0]) == 0 {
+		return nil, fmt.Errorf("empty terrain grid")
+	}
+	if bounds.Xmax <= bounds.Xmin || bounds.Ymax <= bounds.Ymin {
+		return nil, fmt.Errorf("empty terrain bounds %+v", bounds)
+	}
+	g := &Grid{
+		bounds: bounds,
+		cols:   len(cost[0]),
+		rows:   len(cost),
+		cost:   make([]float64, 0, len(cost)*len(cost[0])),
+		paths:  make(map[int]*list.Element),
+		recent: list.New(),
+	}
+	cells := g.cols * g.rows
+	g.cacheSources = min(cells, max(pathCacheMinSources, pathCacheFloats/cells))
+	for r, row := range cost {
+		if len(row) != g.cols {
+			return nil, fmt.Errorf("terrain row %d has %d cells, want %d", r, len(row), g.cols)
+		}
+		for c, v := range row {
+			if v != Impassable && !(v >= 1) {
+				return nil, fmt.Errorf("terrain cell (%d, %d) costs %v: want at least 1, or %v for impassable", r, c, v, Impassable)
+			}
+			g.cost = append(g.cost, v)
+		}
+	}
+	return g, nil
+}
+
+// Load reads a grid over bounds from a file: CSV when it ends in .csv
+// (see ReadCSV), an image otherwise (see ReadImage).
+func Load(path string, bounds Bounds) (*Grid, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open terrain file: %w", err)
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return ReadCSV(f, bounds)
+	}
+	return ReadImage(f, bounds)
+}
+
+// ReadCSV reads a grid from rows of comma-separated cell costs, north
+// row first.
+func ReadCSV(r io.Reader, bounds Bounds) (*Grid, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terrain CSV: %w", err)
+	}
+	cost := make([][]float64, len(records))
+	for i, record := range records {
+		cost[i] = make([]float64, len(record))
+		for j, field := range record {
+			if cost[i][j], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+				return nil, fmt.Errorf("failed to parse terrain cell (%d, %d): %w", i, j, err)
+			}
+		}
+	}
+	return New(cost, bounds)
+}
+
+// ReadImage reads a grid from an image, one cell per pixel: white is
+// open ground, darker is costlier (a pixel of luminance l costs 1/l)
+// and black is impassable.
+func ReadImage(r io.Reader, bounds Bounds) (*Grid, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode terrain image: %w", err)
+	}
+	b := img.Bounds()
+	cost := make([][]float64, b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := make([]float64, b.Dx())
+		for x := b.Min.X; x < b.Max.X; x++ {
+			red, green, blue, _ := img.At(x, y).RGBA()
+			l := (0.299*float64(red) + 0.587*float64(green) + 0.114*float64(blue)) / 0xffff
+			if l <= 0 {
+				row[x-b.Min.X] = Impassable
+			} else {
+				row[x-b.Min.X] = math.Max(1, 1/l)
+			}
+		}
+		cost[y-b.Min.Y] = row
+	}
+	return New(cost, bounds)
+}
+
+// cellSize is the width and height of a cell in world units.
+func (g *Grid) cellSize() (float64, float64) {
+	return float64(g.bounds.Xmax-g.bounds.Xmin) / float64(g.cols),
+		float64(g.bounds.Ymax-g.bounds.Ymin) / float64(g.rows)
+}
+
+// cell returns the index of the cell under p; points off the grid take
+// the nearest edge cell.
+func (g *Grid) cell(p point.Point) int {
+	w, h := g.cellSize()
+	c := min(g.cols-1, max(0, int(math.Floor(float64(p.X-g.bounds.Xmin)/w))))
+	r := min(g.rows-1, max(0, int(math.Floor(float64(g.bounds.Ymax-p.Y)/h))))
+	return r*g.cols + c
+}
+
+// centre is the world point at the middle of cell i.
+func (g *Grid) centre(i int) point.Point {
+	w, h := g.cellSize()
+	r, c := i/g.cols, i%g.cols
+	return point.Point{
+		X: g.bounds.Xmin + int((float64(c)+0.5)*w),
+		Y: g.bounds.Ymax - int((float64(r)+0.5)*h),
+	}
+}
+
+// Passable reports whether goods and factories may stand at p.
+func (g *Grid) Passable(p point.Point) bool {
+	return g.cost[g.cell(p)] != Impassable
+}
+
+// NearestPassable returns p when it is passable, otherwise the centre of
+// the passable cell nearest it (p itself if there is none).
+func (g *Grid) NearestPassable(p point.Point) point.Point {
+	if g.Passable(p) {
+		return p
+	}
+	best, bestDistance := p, math.Inf(1)
+	for i, v := range g.cost {
+		if v == Impassable {
+			continue
+		}
+		if d := p.Distance(g.centre(i)); d < bestDistance {
+			best, bestDistance = g.centre(i), d
+		}
+	}
+	return best
+}
+
+// PathLength is the terrain-weighted length of the least-cost path from
+// origin to destination: the straight-line distance scaled by how much
+// dearer the best path over the grid is than a straight run over open
+// ground, so open terrain leaves distances as they were while rough
+// ground and detours around impassable cells lengthen them. It is +Inf
+// when no path connects the two.
+func (g *Grid) PathLength(origin, destination point.Point) float64 {
+	from, to := g.cell(origin), g.cell(destination)
+	if from == to {
+		return origin.Distance(destination) * math.Max(1, g.cost[from])
+	}
+	return origin.Distance(destination) * g.pathsFrom(from)[to] / g.openLength(from, to)
+}
+
+// openLength is the length of the shortest path from cell a to cell b
+// over open ground, in 8-connected steps between cell centres.
+func (g *Grid) openLength(a, b int) float64 {
+	w, h := g.cellSize()
+	dc := math.Abs(float64(a%g.cols - b%g.cols))
+	dr := math.Abs(float64(a/g.cols - b/g.cols))
+	diagonal := math.Min(dc, dr)
+	return diagonal*math.Hypot(w, h) + (dc-diagonal)*w + (dr-diagonal)*h
+}
+
+// pathsFrom returns the least path cost from cell src to every cell,
+// from the cache or else solved (see solvePaths) without holding the
+// lock, so concurrent callers only wait on each other for the cache.
+// Two callers missing on the same source both solve it, to the same
+// result.
+func (g *Grid) pathsFrom(src int) []float64 {
+	g.m.Lock()
+	if e, ok := g.paths[src]; ok {
+		g.recent.MoveToFront(e)
+		g.m.Unlock()
+		return e.Value.(*cachedPaths).dist
+	}
+	g.m.Unlock()
+
+	dist := g.solvePaths(src)
+
+	g.m.Lock()
+	defer g.m.Unlock()
+	if e, ok := g.paths[src]; ok {
+		g.recent.MoveToFront(e)
+		return e.Value.(*cachedPaths).dist
+	}
+	g.paths[src] = g.recent.PushFront(&cachedPaths{src: src, dist: dist})
+	if g.recent.Len() > g.cacheSources {
+		oldest := g.recent.Back()
+		g.recent.Remove(oldest)
+		delete(g.paths, oldest.Value.(*cachedPaths).src)
+	}
+	return dist
+}
+
+// solvePaths is Dijkstra over 8-connected cells from src. An impassable
+// cell can be reached as an endpoint -- a producer may stand on one --
+// but no path runs through it.
+func (g *Grid) solvePaths(src int) []float64 {
+	w, h := g.cellSize()
+	crossing := func(i int) float64 {
+		if g.cost[i] == Impassable {
+			return 1 // only ever the endpoints
+		}
+		return g.cost[i]
+	}
+	dist := make([]float64, len(g.cost))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	dist[src] = 0
+	queue := &cellQueue{{cell: src}}
+	for queue.Len() > 0 {
+		next := heap.Pop(queue).(cellCost)
+		u := next.cell
+		if next.cost > dist[u] || (u != src && g.cost[u] == Impassable) {
+			continue
+		}
+		ur, uc := u/g.cols, u%g.cols
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				r, c := ur+dr, uc+dc
+				if (dr == 0 && dc == 0) || r < 0 || r >= g.rows || c < 0 || c >= g.cols {
+					continue
+				}
+				// No squeezing diagonally between two impassable cells.
+				if dr != 0 && dc != 0 && (g.cost[ur*g.cols+c] == Impassable || g.cost[r*g.cols+uc] == Impassable) {
+					continue
+				}
+				v := r*g.cols + c
+				step := math.Hypot(float64(dc)*w, float64(dr)*h)
+				if d := dist[u] + step*(crossing(u)+crossing(v))/2; d < dist[v] {
+					dist[v] = d
+					heap.Push(queue, cellCost{cell: v, cost: d})
+				}
+			}
+		}
+	}
+	return dist
+}
+
+// cellCost is a cell queued at the path cost it was reached for.
+type cellCost struct {
+	cell int
+	cost float64
+}
+
+// cellQueue is a min-heap of cells by cost, ties to the lower index so
+// the search is deterministic.
+type cellQueue []cellCost
+
+func (q cellQueue) Len() int { return len(q) }
+func (q cellQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].cell < q[j].cell
+}
+func (q cellQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *cellQueue) Push(x any)   { *q = append(*q, x.(cellCost)) }
+func (q *cellQueue) Pop() any {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
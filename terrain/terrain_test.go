@@ -0,0 +1,194 @@
+package terrain
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/paul-freeman/satisfactory-story/point"
+)
+
+// bounds stretches a 5x5 grid over 5000x5000: 1000-unit cells.
+var bounds = Bounds{Xmin: 0, Xmax: 5000, Ymin: 0, Ymax: 5000}
+
+func mustCSV(t *testing.T, csv string) *Grid {
+	t.Helper()
+	g, err := ReadCSV(strings.NewReader(csv), bounds)
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	return g
+}
+
+func Test_Grid_PathLength(t *testing.T) {
+	open := mustCSV(t, "1,1,1,1,1\n1,1,1,1,1\n1,1,1,1,1\n1,1,1,1,1\n1,1,1,1,1\n")
+	a, b := point.Point{X: 500, Y: 2500}, point.Point{X: 4500, Y: 2600}
+	if got, want := open.PathLength(a, b), a.Distance(b); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("PathLength over open ground = %v, want the straight line's %v", got, want)
+	}
+
+	// A wall down the middle column with a gap at the south end: the
+	// haul detours through it.
+	walled := mustCSV(t, "1,1,0,1,1\n1,1,0,1,1\n1,1,0,1,1\n1,1,0,1,1\n1,1,1,1,1\n")
+	if got := walled.PathLength(a, b); got <= a.Distance(b)*1.2 {
+		t.Fatalf("PathLength through a wall's gap = %v, want a detour well past %v", got, a.Distance(b))
+	}
+	if got := walled.PathLength(a, b); got != walled.PathLength(a, b) {
+		t.Fatalf("PathLength = %v from the cache, want it unchanged", got)
+	}
+
+	// Rough ground costs more than open.
+	rough := mustCSV(t, "3,3,3,3,3\n3,3,3,3,3\n3,3,3,3,3\n3,3,3,3,3\n3,3,3,3,3\n")
+	if got, want := rough.PathLength(a, b), 3*a.Distance(b); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("PathLength over rough ground = %v, want %v", got, want)
+	}
+
+	// No gap: no path.
+	sealed := mustCSV(t, "1,1,0,1,1\n1,1,0,1,1\n1,1,0,1,1\n1,1,0,1,1\n1,1,0,1,1\n")
+	if got := sealed.PathLength(a, b); !math.IsInf(got, 1) {
+		t.Fatalf("PathLength across a sealed wall = %v, want +Inf", got)
+	}
+}
+
+func Test_Grid_pathsFrom_boundedCache(t *testing.T) {
+	const side = 20
+	rows := make([][]float64, side)
+	for r := range rows {
+		rows[r] = make([]float64, side)
+		for c := range rows[r] {
+			rows[r][c] = 1
+		}
+	}
+	g, err := New(rows, bounds)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if g.cacheSources != side*side {
+		t.Fatalf("a %d-cell grid caches %d sources, want every cell", side*side, g.cacheSources)
+	}
+	g.cacheSources = side * side / 2 // as if the grid were too large to cache whole
+	want := g.solvePaths(0)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for src := 0; src < side*side; src++ {
+				g.pathsFrom(src)
+				g.pathsFrom(0) // keep cell 0 in use
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(g.paths) != g.cacheSources || g.recent.Len() != g.cacheSources {
+		t.Fatalf("cache holds %d sources (%d in recency order), want %d", len(g.paths), g.recent.Len(), g.cacheSources)
+	}
+	if _, ok := g.paths[0]; !ok {
+		t.Fatal("the most used source should not be evicted")
+	}
+	for i, d := range g.pathsFrom(0) {
+		if d != want[i] {
+			t.Fatalf("cached cost to cell %d = %v, want %v", i, d, want[i])
+		}
+	}
+}
+
+func Test_Grid_NearestPassable(t *testing.T) {
+	g := mustCSV(t, "1,1,1,1,1\n1,0,0,0,1\n1,0,0,0,1\n1,0,0,0,1\n1,1,1,1,1\n")
+	inside := point.Point{X: 2500, Y: 2500}
+	if g.Passable(inside) {
+		t.Fatalf("%v is passable, want the lake in the middle impassable", inside)
+	}
+	moved := g.NearestPassable(point.Point{X: 1600, Y: 2500})
+	if !g.Passable(moved) || moved != (point.Point{X: 500, Y: 2500}) {
+		t.Fatalf("NearestPassable = %v, want the west shore (500, 2500)", moved)
+	}
+	shore := point.Point{X: 500, Y: 500}
+	if got := g.NearestPassable(shore); got != shore {
+		t.Fatalf("NearestPassable = %v, want passable %v left alone", got, shore)
+	}
+}
+
+func Test_ReadCSV_errors(t *testing.T) {
+	for _, bad := range []string{
+		"",
+		"1,1\n1\n",
+		"1,0.5\n1,1\n",
+		"1,x\n1,1\n",
+	} {
+		if _, err := ReadCSV(strings.NewReader(bad), bounds); err == nil {
+			t.Errorf("ReadCSV(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func Test_ReadImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 1))
+	img.SetGray(0, 0, color.Gray{Y: 255})
+	img.SetGray(1, 0, color.Gray{Y: 0})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	g, err := ReadImage(&buf, bounds)
+	if err != nil {
+		t.Fatalf("ReadImage: %v", err)
+	}
+	if !g.Passable(point.Point{X: 1000, Y: 2500}) || g.Passable(point.Point{X: 4000, Y: 2500}) {
+		t.Fatalf("cells = %v, want white open and black impassable", g.cost)
+	}
+}
+
+// benchmarkPathLength prices the haul from each of sellers sellers to
+// each of 10 buyers, buyer by buyer as matching does, on a rough 32x32
+// grid with the path cache holding sources sources (0 for the grid's own
+// sizing).
+func benchmarkPathLength(b *testing.B, sellers, sources int) {
+	r := rand.New(rand.NewSource(1))
+	const side = 32
+	rows := make([][]float64, side)
+	for i := range rows {
+		rows[i] = make([]float64, side)
+		for j := range rows[i] {
+			rows[i][j] = float64(1 + r.Intn(3))
+		}
+	}
+	world := Bounds{Xmin: 0, Xmax: 32000, Ymin: 0, Ymax: 32000}
+	g, err := New(rows, world)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if sources > 0 {
+		g.cacheSources = sources
+	}
+	at := func() point.Point { return point.Point{X: r.Intn(world.Xmax), Y: r.Intn(world.Ymax)} }
+	from := make([]point.Point, sellers)
+	for i := range from {
+		from[i] = at()
+	}
+	to := make([]point.Point, 10)
+	for i := range to {
+		to[i] = at()
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, dst := range to {
+			for _, src := range from {
+				g.PathLength(src, dst)
+			}
+		}
+	}
+}
+
+func BenchmarkGrid_PathLength_1000sellers(b *testing.B) { benchmarkPathLength(b, 1000, 0) }
+func BenchmarkGrid_PathLength_1000sellers_256sources(b *testing.B) {
+	benchmarkPathLength(b, 1000, pathCacheMinSources)
+}